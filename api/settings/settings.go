@@ -11,6 +11,7 @@ type Settings struct {
 	AppEnvironment           string
 	LogAPIRequests           bool
 	Auth0Domain              string
+	BaseCurrency             string
 }
 
 // GetSettingsFromEnvironment returns a pointer to a Configuration struct with all of its values initialized from environment variables
@@ -20,6 +21,7 @@ func GetSettingsFromEnvironment() *Settings {
 		DatabaseConnectionString: os.Getenv("DATABASE_CONNECTION_STRING"),
 		LogzioToken:              os.Getenv("LOGZIO_TOKEN"),
 		Auth0Domain:              os.Getenv("AUTH0_DOMAIN"),
+		BaseCurrency:             os.Getenv("BASE_CURRENCY"),
 		LogAPIRequests:           true, // default this to true for deployed envs, can be overwritten by test code to avoid polluting the output
 	}
 }
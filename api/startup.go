@@ -110,10 +110,10 @@ func (s *Server) Bootstrap() {
 	allowedOrderFields["updated_at_utc"] = true
 	allowedOrderFields["id"] = true
 
-	productRepository := &repositories.ProductRepository{DB: db}
+	productRepository := &repositories.ProductRepository{DB: db, BaseCurrency: s.Settings.BaseCurrency}
 	productSetRepository := &repositories.ProductSetRepository{DB: db}
 
-	productsController := &controllers.ProductController{Repository: productRepository, AllowedOrderFields: allowedOrderFields}
+	productsController := &controllers.ProductController{Repository: productRepository, ExchangeRateRepository: &repositories.ExchangeRateRepository{DB: db}, BaseCurrency: s.Settings.BaseCurrency, AllowedOrderFields: allowedOrderFields}
 	productSetController := &controllers.ProductSetController{Service: &services.ProductSetService{ProductRepository: productRepository, ProductSetRepository: productSetRepository}, Repository: productSetRepository}
 	marketingController := &controllers.MarketingController{Repository: &repositories.MarketingRepository{DB: db}}
 
@@ -1,17 +1,22 @@
 package controllers
 
 import (
+	"atgatt-backend/persistence/entities"
 	"atgatt-backend/persistence/queries"
 	"atgatt-backend/persistence/repositories"
+	gocontext "context"
 	"net/http"
 
+	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/labstack/echo/v4"
 )
 
 // ProductController contains functions related to filtering and updating Products
 type ProductController struct {
-	Repository         *repositories.ProductRepository
-	AllowedOrderFields map[string]bool
+	Repository             *repositories.ProductRepository
+	ExchangeRateRepository *repositories.ExchangeRateRepository
+	BaseCurrency           string
+	AllowedOrderFields     map[string]bool
 }
 
 // FilterProducts returns a subset of products from the database based off a user-supplied query, where all parameters are AND'd together
@@ -26,11 +31,31 @@ func (p *ProductController) FilterProducts(context echo.Context) (err error) {
 		return context.JSON(http.StatusBadRequest, err)
 	}
 
-	products, err := p.Repository.FilterProducts(query)
+	products, err := p.Repository.FilterProducts(context.Request().Context(), query)
 	if err != nil {
 		return err
 	}
 
+	exchangeRates, err := p.getDisplayExchangeRates(context.Request().Context(), query.DisplayCurrency)
+	if err != nil {
+		return err
+	}
+
+	if exchangeRates != nil {
+		for i := range products {
+			err = products[i].ConvertPrices(exchangeRates, query.DisplayCurrency)
+			if err != nil {
+				return context.JSON(http.StatusBadRequest, validation.Errors{"displayCurrency": err})
+			}
+		}
+	}
+
+	if query.Region != "" {
+		for i := range products {
+			products[i].DisplayModel = products[i].GetDisplayModel(query.Region)
+		}
+	}
+
 	return context.JSON(http.StatusOK, products)
 }
 
@@ -40,14 +65,56 @@ func (p *ProductController) CreateReview(context echo.Context) (err error) {
 	return context.NoContent(http.StatusOK)
 }
 
-// GetProductDetails returns all of the information about a specific product, formatted as a JSON document
+// GetProductDetails returns all of the information about a specific product, formatted as a JSON document, or a 404 if the product isn't sold in the requested region
 func (p *ProductController) GetProductDetails(context echo.Context) (err error) {
 	uuid := context.Param("uuid")
-	product, err := p.Repository.GetByUUID(uuid)
+	product, err := p.Repository.GetByUUID(context.Request().Context(), uuid)
+
+	if err != nil {
+		return err
+	}
+
+	displayCurrency := context.QueryParam("currency")
+	err = queries.Currency(displayCurrency)
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, validation.Errors{"currency": err})
+	}
 
+	region := context.QueryParam("region")
+	err = queries.Region(region)
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, validation.Errors{"region": err})
+	}
+
+	// Products that aren't sold in the region are hidden in the same way that the filter endpoint leaves them out
+	if region != "" && !product.IsAvailableInRegion(region) {
+		return repositories.ErrEntityNotFound
+	}
+
+	exchangeRates, err := p.getDisplayExchangeRates(context.Request().Context(), displayCurrency)
 	if err != nil {
 		return err
 	}
 
+	if exchangeRates != nil {
+		err = product.ConvertPrices(exchangeRates, displayCurrency)
+		if err != nil {
+			return context.JSON(http.StatusBadRequest, validation.Errors{"currency": err})
+		}
+	}
+
+	if region != "" {
+		product.DisplayModel = product.GetDisplayModel(region)
+	}
+
 	return context.JSON(http.StatusOK, product)
 }
+
+// getDisplayExchangeRates returns the exchange rates needed to convert prices to the given display currency, or nil if prices should be left in the base currency
+func (p *ProductController) getDisplayExchangeRates(ctx gocontext.Context, displayCurrency string) (*entities.ExchangeRates, error) {
+	if displayCurrency == "" || entities.NormalizeCurrency(displayCurrency) == entities.NormalizeCurrency(p.BaseCurrency) {
+		return nil, nil
+	}
+
+	return p.ExchangeRateRepository.GetByBaseCurrency(ctx, p.BaseCurrency)
+}
@@ -99,6 +99,144 @@ func Test_FilterProducts_should_only_return_airbag_jackets_when_the_filters_are_
 	}
 }
 
+func Test_FilterProducts_should_only_return_jackets_with_at_least_the_minimum_garment_class(t *testing.T) {
+	RegisterTestingT(t)
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, Type: "jacket", JacketCertifications: &queries.JacketCertificationsQueryParams{
+		MinimumGarmentClass: entities.GarmentClassAA,
+	}}
+	request.Order.Field = "created_at_utc"
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	Expect(*responseBody).ToNot(BeEmpty())
+	for _, item := range *responseBody {
+		Expect(item.Type).To(Equal("jacket"))
+		Expect(item.JacketCertifications.GarmentClass).To(Equal(entities.GarmentClassAAA))
+	}
+}
+
+func Test_FilterProducts_should_only_return_pants_with_at_least_the_minimum_garment_class(t *testing.T) {
+	RegisterTestingT(t)
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, Type: "pants", PantsCertifications: &queries.PantsCertificationsQueryParams{
+		MinimumGarmentClass: entities.GarmentClassA,
+	}}
+	request.Order.Field = "created_at_utc"
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	Expect(*responseBody).ToNot(BeEmpty())
+	for _, item := range *responseBody {
+		Expect(item.Type).To(Equal("pants"))
+		Expect(item.PantsCertifications.GarmentClass).To(Equal(entities.GarmentClassAA))
+	}
+}
+
+func Test_FilterProducts_should_return_bad_request_when_the_minimum_garment_class_is_unknown(t *testing.T) {
+	RegisterTestingT(t)
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, Type: "jacket", JacketCertifications: &queries.JacketCertificationsQueryParams{
+		MinimumGarmentClass: "AAAA",
+	}}
+	request.Order.Field = "created_at_utc"
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+}
+
+func Test_FilterProducts_should_only_return_boots_with_at_least_the_minimum_EN_13634_ratings(t *testing.T) {
+	RegisterTestingT(t)
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, Type: "boots", BootsCertifications: &queries.BootsCertificationsQueryParams{
+		MinimumHeight:             2,
+		MinimumTransverseRigidity: 2,
+	}}
+	request.Order.Field = "created_at_utc"
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	Expect(*responseBody).ToNot(BeEmpty())
+	for _, item := range *responseBody {
+		Expect(item.Type).To(Equal("boots"))
+		Expect(item.BootsCertifications.EN13634).ToNot(BeNil())
+		Expect(item.BootsCertifications.EN13634.Height).To(Equal(2))
+		Expect(item.BootsCertifications.EN13634.TransverseRigidity).To(Equal(2))
+	}
+}
+
+func Test_FilterProducts_should_return_bad_request_when_the_display_currency_is_not_a_currency_code(t *testing.T) {
+	RegisterTestingT(t)
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, DisplayCurrency: "dollars"}
+	request.Order.Field = "created_at_utc"
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+}
+
+func Test_FilterProducts_should_return_bad_request_when_there_is_no_exchange_rate_for_the_display_currency(t *testing.T) {
+	RegisterTestingT(t)
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, DisplayCurrency: "XYZ"}
+	request.Order.Field = "created_at_utc"
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+}
+
+func Test_FilterProducts_should_return_bad_request_when_a_minimum_EN_13634_rating_is_out_of_range(t *testing.T) {
+	RegisterTestingT(t)
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, Type: "boots", BootsCertifications: &queries.BootsCertificationsQueryParams{
+		MinimumAbrasion: 3,
+	}}
+	request.Order.Field = "created_at_utc"
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+}
+
+func Test_FilterProducts_should_only_return_gloves_with_the_given_EN_13594_rating(t *testing.T) {
+	RegisterTestingT(t)
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, Type: "gloves", GlovesCertifications: &queries.GlovesCertificationsQueryParams{
+		MinimumLevel:         2,
+		HasKnuckleProtection: true,
+	}}
+	request.Order.Field = "created_at_utc"
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	Expect(*responseBody).ToNot(BeEmpty())
+	for _, item := range *responseBody {
+		Expect(item.Type).To(Equal("gloves"))
+		Expect(item.GlovesCertifications.EN13594).ToNot(BeNil())
+		Expect(item.GlovesCertifications.EN13594.Level).To(Equal(2))
+		Expect(item.GlovesCertifications.EN13594.HasKnuckleProtection).To(BeTrue())
+	}
+}
+
 func Test_FilterProducts_should_return_all_of_the_products_that_have_the_given_subtype_when_the_subtypes_array_has_one_element(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -282,6 +420,71 @@ func Test_FilterProducts_should_return_products_whose_manufacturers_start_with_t
 	}
 }
 
+func Test_FilterProducts_should_only_return_products_available_in_the_specified_region(t *testing.T) {
+	RegisterTestingT(t)
+
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, Type: "helmet", Region: "uk"}
+	request.Order.Field = "created_at_utc"
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	Expect(*responseBody).ToNot(BeEmpty())
+	for _, item := range *responseBody {
+		Expect(item.IsAvailableInRegion(entities.RegionUK)).To(BeTrue())
+		Expect(item.IsSoldInRegion(entities.RegionUS)).To(BeFalse())
+		Expect(item.DisplayModel).To(Equal(item.GetDisplayModel(entities.RegionUK)))
+	}
+}
+
+func Test_FilterProducts_should_only_return_products_with_an_in_stock_variant_in_the_specified_sizes(t *testing.T) {
+	RegisterTestingT(t)
+
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, Type: "helmet", AvailableSizes: []string{"s"}}
+	request.Order.Field = "created_at_utc"
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	Expect(*responseBody).ToNot(BeEmpty())
+	for _, item := range *responseBody {
+		Expect(item.IsSizeInStock("S")).To(BeTrue())
+	}
+}
+
+func Test_FilterProducts_should_not_return_products_whose_variants_in_the_specified_sizes_are_out_of_stock(t *testing.T) {
+	RegisterTestingT(t)
+
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, Type: "helmet", AvailableSizes: []string{"XL"}}
+	request.Order.Field = "created_at_utc"
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	Expect(*responseBody).To(BeEmpty())
+}
+
+func Test_FilterProducts_should_return_bad_request_when_the_region_is_unknown(t *testing.T) {
+	RegisterTestingT(t)
+
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, Region: "Narnia"}
+	request.Order.Field = "created_at_utc"
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+}
+
 func Test_FilterProducts_should_return_products_with_SNELL_certifications(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -301,6 +504,40 @@ func Test_FilterProducts_should_return_products_with_SNELL_certifications(t *tes
 	}
 }
 
+func Test_FilterProducts_should_return_products_with_at_least_the_minimum_SNELL_standard(t *testing.T) {
+	RegisterTestingT(t)
+
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, HelmetCertifications: &queries.HelmetCertificationsQueryParams{}}
+	request.Order.Field = "created_at_utc"
+	request.HelmetCertifications.MinimumSNELLStandard = entities.SNELLStandardM2020D
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	Expect(*responseBody).ToNot(BeEmpty())
+	for _, item := range *responseBody {
+		Expect(item.HelmetCertifications.SNELL).To(BeTrue())
+		Expect(entities.IsCurrentSNELLStandard(item.HelmetCertifications.SNELLStandard)).To(BeTrue())
+	}
+}
+
+func Test_FilterProducts_should_return_bad_request_when_the_minimum_SNELL_standard_is_unknown(t *testing.T) {
+	RegisterTestingT(t)
+
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, HelmetCertifications: &queries.HelmetCertificationsQueryParams{}}
+	request.Order.Field = "created_at_utc"
+	request.HelmetCertifications.MinimumSNELLStandard = "M1985"
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+}
+
 func Test_FilterProducts_should_return_products_with_ECE_certifications(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -320,6 +557,61 @@ func Test_FilterProducts_should_return_products_with_ECE_certifications(t *testi
 	}
 }
 
+func Test_FilterProducts_should_return_products_with_at_least_the_minimum_ECE_version(t *testing.T) {
+	RegisterTestingT(t)
+
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, HelmetCertifications: &queries.HelmetCertificationsQueryParams{}}
+	request.Order.Field = "created_at_utc"
+	request.HelmetCertifications.MinimumECEVersion = entities.ECEVersion2206
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	Expect(*responseBody).ToNot(BeEmpty())
+	for _, item := range *responseBody {
+		Expect(item.HelmetCertifications.ECE).To(BeTrue())
+		Expect(item.HelmetCertifications.ECEVersion).To(Equal(entities.ECEVersion2206))
+	}
+}
+
+func Test_FilterProducts_should_return_bad_request_when_the_minimum_ECE_version_is_unknown(t *testing.T) {
+	RegisterTestingT(t)
+
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, HelmetCertifications: &queries.HelmetCertificationsQueryParams{}}
+	request.Order.Field = "created_at_utc"
+	request.HelmetCertifications.MinimumECEVersion = "22.04"
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+}
+
+func Test_FilterProducts_should_return_products_with_FIM_homologations(t *testing.T) {
+	RegisterTestingT(t)
+
+	request := &queries.FilterProductsQuery{Start: 0, Limit: 25, UsdPriceRange: []int{0, 2000000}, HelmetCertifications: &queries.HelmetCertificationsQueryParams{}}
+	request.Order.Field = "created_at_utc"
+	request.HelmetCertifications.FIM = true
+	request.HelmetCertifications.MinimumFIMStandard = entities.FIMStandardFRHPhe02
+
+	responseBody := &[]*entities.Product{}
+	resp, err := httpHelpers.MakeJSONPOSTRequest(fmt.Sprintf("%s/v1/products/filter", APIBaseURL), request, responseBody)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	Expect(*responseBody).ToNot(BeEmpty())
+	for _, item := range *responseBody {
+		Expect(item.HelmetCertifications.FIM).ToNot(BeNil())
+		Expect(item.HelmetCertifications.FIM.Standard).To(Equal(entities.FIMStandardFRHPhe02))
+	}
+}
+
 func Test_FilterProducts_should_return_products_with_DOT_certifications(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -623,6 +915,41 @@ func Test_GetProductDetails_should_return_the_product_details_when_the_UUID_is_v
 	Expect(responseBody).To(Equal(expectedProduct))
 }
 
+func Test_GetProductDetails_should_return_the_display_model_for_the_requested_region(t *testing.T) {
+	RegisterTestingT(t)
+
+	expectedProduct := seeds.GetProductSeeds()[19]
+
+	responseBody := &entities.Product{}
+	resp, err := httpHelpers.MakeJSONGETRequest(fmt.Sprintf("%s/v1/products/%s?region=US", APIBaseURL, expectedProduct.UUID.String()), responseBody)
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	Expect(responseBody.DisplayModel).To(Equal("RF-1300"))
+
+	responseBody = &entities.Product{}
+	resp, err = httpHelpers.MakeJSONGETRequest(fmt.Sprintf("%s/v1/products/%s?region=UK", APIBaseURL, expectedProduct.UUID.String()), responseBody)
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	Expect(responseBody.DisplayModel).To(Equal(expectedProduct.Model))
+}
+
+func Test_GetProductDetails_should_return_a_StatusNotFound_status_code_when_the_product_isnt_sold_in_the_requested_region(t *testing.T) {
+	RegisterTestingT(t)
+
+	expectedProduct := seeds.GetProductSeeds()[0]
+	Expect(expectedProduct.Regions).To(Equal([]string{entities.RegionUS}))
+
+	responseBody := &entities.Product{}
+	resp, err := httpHelpers.MakeJSONGETRequest(fmt.Sprintf("%s/v1/products/%s?region=UK", APIBaseURL, expectedProduct.UUID.String()), responseBody)
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+
+	responseBody = &entities.Product{}
+	resp, err = httpHelpers.MakeJSONGETRequest(fmt.Sprintf("%s/v1/products/%s?region=US", APIBaseURL, expectedProduct.UUID.String()), responseBody)
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+}
+
 func Test_GetProductDetails_NotFound(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -25,7 +25,7 @@ func (p *ProductSetController) CreateProductSet(context echo.Context) (err error
 		return err
 	}
 
-	uuidCreated, err := p.Service.UpsertProductSet(request.SourceProductSetID, request.ProductID)
+	uuidCreated, err := p.Service.UpsertProductSet(context.Request().Context(), request.SourceProductSetID, request.ProductID)
 	if err != nil {
 		return err
 	}
@@ -47,11 +47,13 @@ func (p *ProductSetController) GetProductSetDetails(context echo.Context) (err e
 	}
 
 	return context.JSON(http.StatusOK, &responses.GetProductSetDetailsResponse{
-		ID:            productSet.UUID,
-		HelmetProduct: productSet.HelmetProduct,
-		JacketProduct: productSet.JacketProduct,
-		PantsProduct:  productSet.PantsProduct,
-		BootsProduct:  productSet.BootsProduct,
-		GlovesProduct: productSet.GlovesProduct,
+		ID:               productSet.UUID,
+		HelmetProduct:    productSet.HelmetProduct,
+		JacketProduct:    productSet.JacketProduct,
+		PantsProduct:     productSet.PantsProduct,
+		BootsProduct:     productSet.BootsProduct,
+		GlovesProduct:    productSet.GlovesProduct,
+		AirbagProduct:    productSet.AirbagProduct,
+		ProtectorProduct: productSet.ProtectorProduct,
 	})
 }
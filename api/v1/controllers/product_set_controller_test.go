@@ -139,6 +139,16 @@ func Test_CreateProductSet_should_create_a_ProductSet_with_all_products_without_
 		return product.Type == entities.ProductTypeGloves
 	}).ToSlice(&glovesSeeds)
 
+	airbagSeeds := []*entities.Product{}
+	golinq.From(seeds).WhereT(func(product *entities.Product) bool {
+		return product.Type == entities.ProductTypeAirbag
+	}).ToSlice(&airbagSeeds)
+
+	protectorSeeds := []*entities.Product{}
+	golinq.From(seeds).WhereT(func(product *entities.Product) bool {
+		return product.Type == entities.ProductTypeProtector
+	}).ToSlice(&protectorSeeds)
+
 	expectedHelmet := helmetSeeds[1]
 	initialUUID, getResponseBody := applyProductToProductSet(expectedHelmet, nil)
 	firstUUID := initialUUID // save the first generated UUID, we should never use this one again
@@ -173,9 +183,23 @@ func Test_CreateProductSet_should_create_a_ProductSet_with_all_products_without_
 	Expect(nextUUID).To(Not(Equal(firstUUID)))
 	initialUUID = nextUUID
 
+	expectedAirbag := airbagSeeds[0]
+	nextUUID, getResponseBody = applyProductToProductSet(expectedAirbag, &initialUUID)
+	Expect(nextUUID).To(Not(Equal(initialUUID)))
+	Expect(nextUUID).To(Not(Equal(firstUUID)))
+	initialUUID = nextUUID
+
+	expectedProtector := protectorSeeds[0]
+	nextUUID, getResponseBody = applyProductToProductSet(expectedProtector, &initialUUID)
+	Expect(nextUUID).To(Not(Equal(initialUUID)))
+	Expect(nextUUID).To(Not(Equal(firstUUID)))
+	initialUUID = nextUUID
+
 	Expect(getResponseBody.HelmetProduct).To(Equal(expectedHelmet))
 	Expect(getResponseBody.JacketProduct).To(Equal(expectedJacket))
 	Expect(getResponseBody.PantsProduct).To(Equal(expectedPants))
 	Expect(getResponseBody.BootsProduct).To(Equal(expectedBoots))
 	Expect(getResponseBody.GlovesProduct).To(Equal(expectedGloves))
+	Expect(getResponseBody.AirbagProduct).To(Equal(expectedAirbag))
+	Expect(getResponseBody.ProtectorProduct).To(Equal(expectedProtector))
 }
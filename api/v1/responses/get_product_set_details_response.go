@@ -11,9 +11,11 @@ type GetProductSetDetailsResponse struct {
 	ID uuid.UUID `json:"id"`
 
 	// Products
-	HelmetProduct *entities.Product `json:"helmetProduct"`
-	JacketProduct *entities.Product `json:"jacketProduct"`
-	PantsProduct  *entities.Product `json:"pantsProduct"`
-	BootsProduct  *entities.Product `json:"bootsProduct"`
-	GlovesProduct *entities.Product `json:"glovesProduct"`
+	HelmetProduct    *entities.Product `json:"helmetProduct"`
+	JacketProduct    *entities.Product `json:"jacketProduct"`
+	PantsProduct     *entities.Product `json:"pantsProduct"`
+	BootsProduct     *entities.Product `json:"bootsProduct"`
+	GlovesProduct    *entities.Product `json:"glovesProduct"`
+	AirbagProduct    *entities.Product `json:"airbagProduct"`
+	ProtectorProduct *entities.Product `json:"protectorProduct"`
 }
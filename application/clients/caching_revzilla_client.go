@@ -0,0 +1,170 @@
+package clients
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"atgatt-backend/persistence/entities"
+)
+
+// RevzillaCacheModeCache serves pages from the cache while they are younger than the TTL, and fetches and caches them otherwise
+const RevzillaCacheModeCache = "cache"
+
+// RevzillaCacheModeRecord always fetches pages and overwrites the cache with them, which is used to capture a fresh snapshot
+const RevzillaCacheModeRecord = "record"
+
+// RevzillaCacheModeReplay only serves pages that were previously recorded no matter how old they are, and never fetches anything
+const RevzillaCacheModeReplay = "replay"
+
+// DefaultRevzillaCacheTTL is how long cached pages are served for when no TTL is given
+const DefaultRevzillaCacheTTL = 24 * time.Hour
+
+// ErrRevzillaPageNotRecorded is returned in replay mode when a page was never recorded
+var ErrRevzillaPageNotRecorded = errors.New("The page was not recorded, so it cannot be replayed")
+
+// CachingRevzillaClient is a RevzillaClient that caches the HTML returned by another RevzillaClient on disk, keyed by URL. It can also replay previously recorded pages so that jobs can be re-run offline and deterministically.
+type CachingRevzillaClient struct {
+	revzillaClient RevzillaClient
+	cacheDirectory string
+	ttl            time.Duration
+	mode           string
+}
+
+// NewCachingRevzillaClient initializes a CachingRevzillaClient that stores pages in the given directory, creating it if needed. A TTL of zero uses DefaultRevzillaCacheTTL, and the wrapped client can be nil in replay mode.
+func NewCachingRevzillaClient(revzillaClient RevzillaClient, cacheDirectory string, ttl time.Duration, mode string) (*CachingRevzillaClient, error) {
+	if cacheDirectory == "" {
+		return nil, errors.New("cacheDirectory cannot be empty")
+	}
+
+	if mode != RevzillaCacheModeCache && mode != RevzillaCacheModeRecord && mode != RevzillaCacheModeReplay {
+		return nil, fmt.Errorf("The cache mode must be one of %s, %s, or %s", RevzillaCacheModeCache, RevzillaCacheModeRecord, RevzillaCacheModeReplay)
+	}
+
+	if revzillaClient == nil && mode != RevzillaCacheModeReplay {
+		return nil, errors.New("revzillaClient cannot be nil unless replaying")
+	}
+
+	if err := os.MkdirAll(cacheDirectory, 0755); err != nil {
+		return nil, err
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultRevzillaCacheTTL
+	}
+
+	return &CachingRevzillaClient{revzillaClient: revzillaClient, cacheDirectory: cacheDirectory, ttl: ttl, mode: mode}, nil
+}
+
+// GetRetailer returns the name of the retailer this client communicates with
+func (c *CachingRevzillaClient) GetRetailer() string {
+	if c.revzillaClient == nil {
+		return entities.RetailerRevzilla
+	}
+
+	return c.revzillaClient.GetRetailer()
+}
+
+// GetAllProductOverviewsHTML returns the cached listing page for the given category, fetching it from the wrapped client if needed
+func (c *CachingRevzillaClient) GetAllProductOverviewsHTML(ctx context.Context, productURLPrefix string) (*goquery.Document, error) {
+	return c.getDocument(GetRevzillaProductOverviewsURL(productURLPrefix), func() (*goquery.Document, error) {
+		return c.revzillaClient.GetAllProductOverviewsHTML(ctx, productURLPrefix)
+	})
+}
+
+// GetDescriptionPartsHTMLByURL returns the cached detail page at the given URL, fetching it from the wrapped client if needed
+func (c *CachingRevzillaClient) GetDescriptionPartsHTMLByURL(ctx context.Context, url string) (*goquery.Document, error) {
+	return c.getDocument(url, func() (*goquery.Document, error) {
+		return c.revzillaClient.GetDescriptionPartsHTMLByURL(ctx, url)
+	})
+}
+
+func (c *CachingRevzillaClient) getDocument(url string, fetch func() (*goquery.Document, error)) (*goquery.Document, error) {
+	cachePath := c.getCachePath(url)
+	if c.mode != RevzillaCacheModeRecord {
+		cachedDoc, err := c.readCachedDocument(cachePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if cachedDoc != nil {
+			return cachedDoc, nil
+		}
+
+		if c.mode == RevzillaCacheModeReplay {
+			return nil, ErrRevzillaPageNotRecorded
+		}
+	}
+
+	doc, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := doc.Html()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.writeCachedHTML(cachePath, html); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// readCachedDocument returns the cached page at the given path, or nil if it was never cached or has expired. Pages never expire when replaying.
+func (c *CachingRevzillaClient) readCachedDocument(cachePath string) (*goquery.Document, error) {
+	fileInfo, err := os.Stat(cachePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.mode != RevzillaCacheModeReplay && time.Since(fileInfo.ModTime()) > c.ttl {
+		return nil, nil
+	}
+
+	htmlBytes, err := ioutil.ReadFile(cachePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return goquery.NewDocumentFromReader(strings.NewReader(string(htmlBytes)))
+}
+
+// writeCachedHTML writes to a temp file first and then renames it, so that concurrent reads never see a partially written page
+func (c *CachingRevzillaClient) writeCachedHTML(cachePath string, html string) error {
+	tempFile, err := ioutil.TempFile(c.cacheDirectory, "page-*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tempFile.WriteString(html)
+	closeErr := tempFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), cachePath)
+}
+
+func (c *CachingRevzillaClient) getCachePath(url string) string {
+	urlHash := sha256.Sum256([]byte(url))
+	return filepath.Join(c.cacheDirectory, hex.EncodeToString(urlHash[:])+".html")
+}
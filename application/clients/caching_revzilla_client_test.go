@@ -0,0 +1,123 @@
+package clients_test
+
+import (
+	"atgatt-backend/application/clients"
+	"atgatt-backend/persistence/entities"
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	. "github.com/onsi/gomega"
+)
+
+type countingRevzillaClient struct {
+	html  string
+	calls int
+}
+
+func (c *countingRevzillaClient) GetRetailer() string {
+	return entities.RetailerRevzilla
+}
+
+func (c *countingRevzillaClient) GetAllProductOverviewsHTML(ctx context.Context, productURLPrefix string) (*goquery.Document, error) {
+	return c.GetDescriptionPartsHTMLByURL(ctx, clients.GetRevzillaProductOverviewsURL(productURLPrefix))
+}
+
+func (c *countingRevzillaClient) GetDescriptionPartsHTMLByURL(ctx context.Context, url string) (*goquery.Document, error) {
+	c.calls++
+	return goquery.NewDocumentFromReader(strings.NewReader(c.html))
+}
+
+func newCachingRevzillaClient(revzillaClient clients.RevzillaClient, ttl time.Duration, mode string) (*clients.CachingRevzillaClient, string) {
+	cacheDirectory, err := ioutil.TempDir("", "revzilla-cache")
+	Expect(err).To(BeNil())
+
+	cachingClient, err := clients.NewCachingRevzillaClient(revzillaClient, cacheDirectory, ttl, mode)
+	Expect(err).To(BeNil())
+	return cachingClient, cacheDirectory
+}
+
+func Test_CachingRevzillaClient_should_only_fetch_each_page_once_until_it_expires(t *testing.T) {
+	RegisterTestingT(t)
+	revzillaClient := &countingRevzillaClient{html: "<html><body><p>Original</p></body></html>"}
+	cachingClient, cacheDirectory := newCachingRevzillaClient(revzillaClient, time.Hour, clients.RevzillaCacheModeCache)
+	defer os.RemoveAll(cacheDirectory)
+
+	for i := 0; i < 2; i++ {
+		doc, err := cachingClient.GetDescriptionPartsHTMLByURL(context.Background(), "https://www.revzilla.com/motorcycle/jacket")
+		Expect(err).To(BeNil())
+		Expect(doc.Find("p").Text()).To(Equal("Original"))
+	}
+	Expect(revzillaClient.calls).To(Equal(1))
+
+	_, err := cachingClient.GetAllProductOverviewsHTML(context.Background(), "motorcycle-jackets-vests")
+	Expect(err).To(BeNil())
+	Expect(revzillaClient.calls).To(Equal(2))
+
+	expiredClient, err := clients.NewCachingRevzillaClient(revzillaClient, cacheDirectory, time.Nanosecond, clients.RevzillaCacheModeCache)
+	Expect(err).To(BeNil())
+	time.Sleep(time.Millisecond)
+	_, err = expiredClient.GetDescriptionPartsHTMLByURL(context.Background(), "https://www.revzilla.com/motorcycle/jacket")
+	Expect(err).To(BeNil())
+	Expect(revzillaClient.calls).To(Equal(3))
+}
+
+func Test_CachingRevzillaClient_should_expire_pages_after_the_default_TTL_when_no_TTL_is_given(t *testing.T) {
+	RegisterTestingT(t)
+	revzillaClient := &countingRevzillaClient{html: "<html><body><p>Original</p></body></html>"}
+	cachingClient, cacheDirectory := newCachingRevzillaClient(revzillaClient, 0, clients.RevzillaCacheModeCache)
+	defer os.RemoveAll(cacheDirectory)
+
+	_, err := cachingClient.GetDescriptionPartsHTMLByURL(context.Background(), "https://www.revzilla.com/motorcycle/jacket")
+	Expect(err).To(BeNil())
+	Expect(revzillaClient.calls).To(Equal(1))
+
+	cachedFiles, err := ioutil.ReadDir(cacheDirectory)
+	Expect(err).To(BeNil())
+	Expect(cachedFiles).To(HaveLen(1))
+	expiredAt := time.Now().Add(-clients.DefaultRevzillaCacheTTL - time.Minute)
+	Expect(os.Chtimes(cacheDirectory+"/"+cachedFiles[0].Name(), expiredAt, expiredAt)).To(BeNil())
+
+	_, err = cachingClient.GetDescriptionPartsHTMLByURL(context.Background(), "https://www.revzilla.com/motorcycle/jacket")
+	Expect(err).To(BeNil())
+	Expect(revzillaClient.calls).To(Equal(2))
+}
+
+func Test_CachingRevzillaClient_should_overwrite_the_cache_when_recording_and_only_serve_recorded_pages_when_replaying(t *testing.T) {
+	RegisterTestingT(t)
+	revzillaClient := &countingRevzillaClient{html: "<html><body><p>Original</p></body></html>"}
+	cachingClient, cacheDirectory := newCachingRevzillaClient(revzillaClient, 0, clients.RevzillaCacheModeCache)
+	defer os.RemoveAll(cacheDirectory)
+
+	_, err := cachingClient.GetDescriptionPartsHTMLByURL(context.Background(), "https://www.revzilla.com/motorcycle/jacket")
+	Expect(err).To(BeNil())
+
+	revzillaClient.html = "<html><body><p>Recorded</p></body></html>"
+	recordingClient, err := clients.NewCachingRevzillaClient(revzillaClient, cacheDirectory, 0, clients.RevzillaCacheModeRecord)
+	Expect(err).To(BeNil())
+	_, err = recordingClient.GetDescriptionPartsHTMLByURL(context.Background(), "https://www.revzilla.com/motorcycle/jacket")
+	Expect(err).To(BeNil())
+	Expect(revzillaClient.calls).To(Equal(2))
+
+	replayingClient, err := clients.NewCachingRevzillaClient(nil, cacheDirectory, time.Nanosecond, clients.RevzillaCacheModeReplay)
+	Expect(err).To(BeNil())
+	doc, err := replayingClient.GetDescriptionPartsHTMLByURL(context.Background(), "https://www.revzilla.com/motorcycle/jacket")
+	Expect(err).To(BeNil())
+	Expect(doc.Find("p").Text()).To(Equal("Recorded"))
+	Expect(replayingClient.GetRetailer()).To(Equal(entities.RetailerRevzilla))
+
+	_, err = replayingClient.GetDescriptionPartsHTMLByURL(context.Background(), "https://www.revzilla.com/motorcycle/never-recorded")
+	Expect(err).To(Equal(clients.ErrRevzillaPageNotRecorded))
+	Expect(revzillaClient.calls).To(Equal(2))
+}
+
+func Test_NewCachingRevzillaClient_should_return_an_error_for_an_unknown_mode(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := clients.NewCachingRevzillaClient(&countingRevzillaClient{}, os.TempDir(), 0, "sometimes")
+	Expect(err).ToNot(BeNil())
+}
@@ -0,0 +1,65 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"atgatt-backend/persistence/entities"
+)
+
+// CJRevzillaAdvertiserID is RevZilla's advertiser ID on the Commission Junction network
+const CJRevzillaAdvertiserID = "3318586"
+
+// ErrCJRateLimited is matched by errors.Is for a CJAPIError that was returned because CJ is rate limiting requests
+var ErrCJRateLimited = errors.New("The CJ API is rate limiting requests")
+
+// ErrCJAPIKeyMissing is returned instead of sending a request when no CJ API key was configured
+var ErrCJAPIKeyMissing = errors.New("The CJ API key is not set")
+
+// CJClient represents a client that can search the product feeds of advertisers on the Commission Junction network
+type CJClient interface {
+	SearchProducts(ctx context.Context, search *CJProductSearch) (*CJProductSearchResult, error)
+}
+
+// CJProductSearch describes the products to search for, where PartnerIDs are the advertisers whose product feeds are searched and products cheaper than LowPrice are left out
+type CJProductSearch struct {
+	PartnerIDs []string
+	Keywords   []string
+	LowPrice   float64
+}
+
+// CJProductSearchResult holds the products that a search returned, where TotalCount is the number of products that CJ says matched the search
+type CJProductSearchResult struct {
+	Products   []entities.CJProduct
+	TotalCount int
+}
+
+// IsTruncated returns true when more products matched the search than were returned, i.e. because the search stopped paging at MaxCJResults
+func (r *CJProductSearchResult) IsTruncated() bool {
+	return r.TotalCount > len(r.Products)
+}
+
+// CJAPIError is returned when the CJ API responds with an unexpected status code or reports errors for a query. RetryAfter is how long CJ asked us to wait before sending more requests, if it said.
+type CJAPIError struct {
+	StatusCode int
+	Messages   []string
+	RetryAfter time.Duration
+}
+
+// Error describes the status code and the messages that CJ responded with
+func (e *CJAPIError) Error() string {
+	message := fmt.Sprintf("The CJ API returned a status code of %d", e.StatusCode)
+	if len(e.Messages) > 0 {
+		message += ": " + strings.Join(e.Messages, "; ")
+	}
+	return message
+}
+
+// Is returns true for ErrCJRateLimited when CJ rejected the request because too many requests were sent
+func (e *CJAPIError) Is(target error) bool {
+	return target == ErrCJRateLimited && e.StatusCode == http.StatusTooManyRequests
+}
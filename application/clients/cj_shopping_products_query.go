@@ -0,0 +1,73 @@
+package clients
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// CJShoppingProductsQuery builds the GraphQL request for a single page of CJ's shoppingProducts search. Every value is sent as a typed GraphQL variable rather than written into the query, so that keywords containing quotes or backslashes (i.e. model names) can't break the query. Offset is the number of matching products to skip, so the first page starts at 0.
+type CJShoppingProductsQuery struct {
+	CompanyID  string
+	PartnerIDs []string
+	Keywords   []string
+	LowPrice   float64
+	PID        string
+	Offset     int
+	Limit      int
+}
+
+type cjGraphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// String returns the GraphQL document that is sent to the CJ API, whose values are given by Variables
+func (q *CJShoppingProductsQuery) String() string {
+	parameters := []string{"$companyId: ID!", "$partnerIds: [ID!]", "$keywords: [String!]", "$offset: Int", "$limit: Int"}
+	arguments := []string{"companyId: $companyId", "partnerIds: $partnerIds", "keywords: $keywords", "offset: $offset", "limit: $limit"}
+	if q.LowPrice > 0 {
+		parameters = append(parameters, "$lowPrice: Float")
+		arguments = append(arguments, "lowPrice: $lowPrice")
+	}
+	parameters = append(parameters, "$pid: ID!")
+
+	return `query shoppingProducts(` + strings.Join(parameters, ", ") + `) {
+  shoppingProducts(` + strings.Join(arguments, ", ") + `) {
+    totalCount
+    count
+    resultList {
+      linkCode(pid: $pid) {
+        clickUrl
+      }
+      title
+      price {
+        amount
+      }
+      imageLink
+      productType
+      description
+    }
+  }
+}`
+}
+
+// Variables returns the values of the variables that the query declares
+func (q *CJShoppingProductsQuery) Variables() map[string]interface{} {
+	variables := map[string]interface{}{
+		"companyId":  q.CompanyID,
+		"partnerIds": q.PartnerIDs,
+		"keywords":   q.Keywords,
+		"offset":     q.Offset,
+		"limit":      q.Limit,
+		"pid":        q.PID,
+	}
+	if q.LowPrice > 0 {
+		variables["lowPrice"] = q.LowPrice
+	}
+	return variables
+}
+
+// MarshalJSON returns the body of a GraphQL request for the query and its variables
+func (q *CJShoppingProductsQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&cjGraphQLRequest{Query: q.String(), Variables: q.Variables()})
+}
@@ -0,0 +1,143 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	httpHelpers "atgatt-backend/common/http"
+	"atgatt-backend/persistence/entities"
+)
+
+// DefaultCJAPIURL is the endpoint of CJ's GraphQL API
+const DefaultCJAPIURL = "https://ads.api.cj.com/query"
+
+// DefaultCJCompanyID is our publisher company ID on the Commission Junction network
+const DefaultCJCompanyID = "5023498"
+
+// DefaultCJPID is the ID of our website on the Commission Junction network, which click URLs are generated for
+const DefaultCJPID = "8505854"
+
+// DefaultCJPageSize is the number of products requested from CJ at a time
+const DefaultCJPageSize = 100
+
+// MaxCJResults bounds the number of products that a single search pages through, so that a search with very broad keywords doesn't use up CJ's rate limit
+const MaxCJResults = 1000
+
+// HTTPCJClientOptions configures a HTTPCJClient, where empty values use the defaults
+type HTTPCJClientOptions struct {
+	APIURL    string
+	APIKey    string
+	CompanyID string
+	PID       string
+	PageSize  int
+}
+
+// HTTPCJClient is a CJClient that communicates with CJ's GraphQL API over HTTP. Requests that CJ rate limits are retried by the HTTPFetcher (usually the shared crawler) when it supports retries, and are otherwise returned as a CJAPIError that matches ErrCJRateLimited.
+type HTTPCJClient struct {
+	httpClient httpHelpers.HTTPFetcher
+	options    HTTPCJClientOptions
+}
+
+// NewHTTPCJClient initializes a HTTPCJClient that sends its requests with the given HTTPFetcher, which is usually the shared crawler.
+func NewHTTPCJClient(httpClient httpHelpers.HTTPFetcher, options HTTPCJClientOptions) *HTTPCJClient {
+	if options.APIURL == "" {
+		options.APIURL = DefaultCJAPIURL
+	}
+	if options.CompanyID == "" {
+		options.CompanyID = DefaultCJCompanyID
+	}
+	if options.PID == "" {
+		options.PID = DefaultCJPID
+	}
+	if options.PageSize <= 0 {
+		options.PageSize = DefaultCJPageSize
+	}
+	return &HTTPCJClient{httpClient: httpClient, options: options}
+}
+
+// SearchProducts returns every product that matches the search, requesting one page at a time until CJ runs out of results or MaxCJResults have been returned. The result is truncated when more than MaxCJResults products matched.
+func (c *HTTPCJClient) SearchProducts(ctx context.Context, search *CJProductSearch) (*CJProductSearchResult, error) {
+	if c.options.APIKey == "" {
+		return nil, ErrCJAPIKeyMissing
+	}
+
+	result := &CJProductSearchResult{Products: []entities.CJProduct{}}
+	for len(result.Products) < MaxCJResults {
+		query := &CJShoppingProductsQuery{
+			CompanyID:  c.options.CompanyID,
+			PartnerIDs: search.PartnerIDs,
+			Keywords:   search.Keywords,
+			LowPrice:   search.LowPrice,
+			PID:        c.options.PID,
+			Offset:     len(result.Products),
+			Limit:      c.options.PageSize,
+		}
+
+		page, err := c.getPage(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Products = append(result.Products, page.ResultList...)
+		result.TotalCount = page.TotalCount
+		if len(page.ResultList) < query.Limit || len(result.Products) >= page.TotalCount {
+			break
+		}
+	}
+
+	if len(result.Products) > MaxCJResults {
+		result.Products = result.Products[:MaxCJResults]
+	}
+	if result.TotalCount < len(result.Products) {
+		result.TotalCount = len(result.Products)
+	}
+	return result, nil
+}
+
+func (c *HTTPCJClient) getPage(ctx context.Context, query *CJShoppingProductsQuery) (*entities.CJProductsWrapper, error) {
+	requestBodyBytes, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.options.APIURL, bytes.NewReader(requestBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.options.APIKey))
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	responseBodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// CJ reports errors in the body even when it doesn't respond with JSON, so a body that can't be parsed only matters when the request succeeded
+	cjResp := &entities.CJProductsResponseWrapper{}
+	parseErr := json.Unmarshal(responseBodyBytes, cjResp)
+	if resp.StatusCode != http.StatusOK || len(cjResp.Errors) > 0 {
+		apiErr := &CJAPIError{StatusCode: resp.StatusCode, Messages: []string{}}
+		for _, responseError := range cjResp.Errors {
+			apiErr.Messages = append(apiErr.Messages, responseError.Message)
+		}
+		if retryAfter, exists := httpHelpers.ParseRetryAfter(resp.Header.Get("Retry-After")); exists {
+			apiErr.RetryAfter = retryAfter
+		}
+		return nil, apiErr
+	}
+
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	return &cjResp.Data.ShoppingProducts, nil
+}
@@ -0,0 +1,136 @@
+package clients_test
+
+import (
+	"atgatt-backend/application/clients"
+	testHelpers "atgatt-backend/common/testing"
+	"atgatt-backend/persistence/entities"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+const testCJAPIKey string = "test-cj-api-key"
+
+func newCJProduct(name string) entities.CJProduct {
+	product := entities.CJProduct{Name: name, Categories: []string{"Motorcycle Helmets"}}
+	product.Price.Amount = "499.99"
+	return product
+}
+
+func newHTTPCJClient(server *testHelpers.FakeCJServer, apiKey string, pageSize int) *clients.HTTPCJClient {
+	return clients.NewHTTPCJClient(http.DefaultClient, clients.HTTPCJClientOptions{APIURL: server.URL, APIKey: apiKey, PageSize: pageSize})
+}
+
+func Test_HTTPCJClient_should_page_through_every_matching_product(t *testing.T) {
+	RegisterTestingT(t)
+	products := []entities.CJProduct{newCJProduct("Arai Corsair-X Helmet")}
+	for i := 0; i < 5; i++ {
+		products = append(products, newCJProduct(fmt.Sprintf("Shoei RF-1400 Helmet %d", i)))
+	}
+	server := testHelpers.StartFakeCJServer(testCJAPIKey, products)
+	defer server.Close()
+
+	searchResult, err := newHTTPCJClient(server, testCJAPIKey, 2).SearchProducts(context.Background(), &clients.CJProductSearch{
+		PartnerIDs: []string{clients.CJRevzillaAdvertiserID},
+		Keywords:   []string{"Shoei", "RF-1400", "helmet"},
+		LowPrice:   200,
+	})
+	Expect(err).To(BeNil())
+	Expect(searchResult.Products).To(HaveLen(5))
+	Expect(searchResult.Products[4].Name).To(Equal("Shoei RF-1400 Helmet 4"))
+	Expect(searchResult.IsTruncated()).To(BeFalse())
+
+	requests := server.GetRequests()
+	Expect(requests).To(HaveLen(3))
+	for i, request := range requests {
+		Expect(request.Offset).To(Equal(i * 2))
+		Expect(request.Limit).To(Equal(2))
+	}
+}
+
+func Test_HTTPCJClient_should_escape_keywords_that_contain_quotes(t *testing.T) {
+	RegisterTestingT(t)
+	model := `Pro "Race" \ Edition`
+	server := testHelpers.StartFakeCJServer(testCJAPIKey, []entities.CJProduct{newCJProduct("AGV " + model + " Helmet")})
+	defer server.Close()
+
+	searchResult, err := newHTTPCJClient(server, testCJAPIKey, 0).SearchProducts(context.Background(), &clients.CJProductSearch{Keywords: []string{"AGV", model}})
+	Expect(err).To(BeNil())
+	Expect(searchResult.Products).To(HaveLen(1))
+	Expect(server.GetRequests()[0].Keywords).To(Equal([]string{"AGV", model}))
+}
+
+func Test_HTTPCJClient_should_return_errors_from_the_CJ_API(t *testing.T) {
+	RegisterTestingT(t)
+	server := testHelpers.StartFakeCJServer(testCJAPIKey, []entities.CJProduct{newCJProduct("Bell Star Helmet")})
+	defer server.Close()
+	search := &clients.CJProductSearch{Keywords: []string{"Bell", "Star"}}
+
+	_, err := newHTTPCJClient(server, "", 0).SearchProducts(context.Background(), search)
+	Expect(err).To(Equal(clients.ErrCJAPIKeyMissing))
+
+	_, err = newHTTPCJClient(server, "wrong-cj-api-key", 0).SearchProducts(context.Background(), search)
+	apiErr := &clients.CJAPIError{}
+	Expect(errors.As(err, &apiErr)).To(BeTrue())
+	Expect(apiErr.StatusCode).To(Equal(http.StatusUnauthorized))
+	Expect(errors.Is(err, clients.ErrCJRateLimited)).To(BeFalse())
+
+	server.RateLimitNextRequests(1, 30)
+	_, err = newHTTPCJClient(server, testCJAPIKey, 0).SearchProducts(context.Background(), search)
+	Expect(errors.Is(err, clients.ErrCJRateLimited)).To(BeTrue())
+	Expect(errors.As(err, &apiErr)).To(BeTrue())
+	Expect(apiErr.RetryAfter).To(Equal(30 * time.Second))
+
+	// The rate limit has passed
+	searchResult, err := newHTTPCJClient(server, testCJAPIKey, 0).SearchProducts(context.Background(), search)
+	Expect(err).To(BeNil())
+	Expect(searchResult.Products).To(HaveLen(1))
+}
+
+func Test_HTTPCJClient_should_report_a_truncated_search_when_more_than_the_max_results_match(t *testing.T) {
+	RegisterTestingT(t)
+	products := []entities.CJProduct{}
+	for i := 0; i <= clients.MaxCJResults; i++ {
+		products = append(products, newCJProduct(fmt.Sprintf("HJC i10 Helmet %d", i)))
+	}
+	server := testHelpers.StartFakeCJServer(testCJAPIKey, products)
+	defer server.Close()
+
+	searchResult, err := newHTTPCJClient(server, testCJAPIKey, 0).SearchProducts(context.Background(), &clients.CJProductSearch{Keywords: []string{"HJC", "i10"}})
+	Expect(err).To(BeNil())
+	Expect(searchResult.Products).To(HaveLen(clients.MaxCJResults))
+	Expect(searchResult.TotalCount).To(Equal(clients.MaxCJResults + 1))
+	Expect(searchResult.IsTruncated()).To(BeTrue())
+	Expect(server.GetRequests()).To(HaveLen(clients.MaxCJResults / clients.DefaultCJPageSize))
+}
+
+func Test_CJShoppingProductsQuery_should_send_every_value_as_a_typed_variable(t *testing.T) {
+	RegisterTestingT(t)
+	query := &clients.CJShoppingProductsQuery{CompanyID: "1", PartnerIDs: []string{"2"}, Keywords: []string{`Pro "Race"`}, PID: "3", Offset: 100, Limit: 50}
+	Expect(query.String()).To(ContainSubstring(`query shoppingProducts($companyId: ID!, $partnerIds: [ID!], $keywords: [String!], $offset: Int, $limit: Int, $pid: ID!) {`))
+	Expect(query.String()).To(ContainSubstring(`shoppingProducts(companyId: $companyId, partnerIds: $partnerIds, keywords: $keywords, offset: $offset, limit: $limit) {`))
+	Expect(query.String()).To(ContainSubstring(`linkCode(pid: $pid)`))
+	Expect(query.String()).ToNot(ContainSubstring("Race"))
+
+	requestBodyBytes, err := json.Marshal(query)
+	Expect(err).To(BeNil())
+	Expect(string(requestBodyBytes)).To(ContainSubstring(`"variables":{"companyId":"1","keywords":["Pro \"Race\""],"limit":50,"offset":100,"partnerIds":["2"],"pid":"3"}`))
+}
+
+func Test_CJShoppingProductsQuery_should_only_filter_by_price_when_a_low_price_is_given(t *testing.T) {
+	RegisterTestingT(t)
+	query := &clients.CJShoppingProductsQuery{CompanyID: "1", PartnerIDs: []string{"2"}, Keywords: []string{"Bell"}, PID: "3", Offset: 100, Limit: 50}
+	Expect(query.String()).ToNot(ContainSubstring("lowPrice"))
+	Expect(query.Variables()).ToNot(HaveKey("lowPrice"))
+
+	query.LowPrice = 199.5
+	Expect(query.String()).To(ContainSubstring(`$limit: Int, $lowPrice: Float, $pid: ID!) {`))
+	Expect(query.String()).To(ContainSubstring(`limit: $limit, lowPrice: $lowPrice) {`))
+	Expect(query.Variables()).To(HaveKeyWithValue("lowPrice", 199.5))
+}
@@ -1,36 +1,45 @@
 package clients
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
 	"github.com/PuerkitoBio/goquery"
 
-	"github.com/hashicorp/go-cleanhttp"
-
 	httpHelpers "atgatt-backend/common/http"
+	"atgatt-backend/persistence/entities"
 )
 
 // HTTPRevzillaClient is a RevzillaClient that communicates with Revzilla.com over HTTP
 type HTTPRevzillaClient struct {
-	pooledClient *http.Client
+	httpClient httpHelpers.HTTPFetcher
+}
+
+// NewHTTPRevzillaClient initializes a HTTPRevzillaClient that sends its requests with the given HTTPFetcher, which is usually the shared crawler.
+func NewHTTPRevzillaClient(httpClient httpHelpers.HTTPFetcher) *HTTPRevzillaClient {
+	return &HTTPRevzillaClient{httpClient: httpClient}
+}
+
+// GetRetailer returns the name of the retailer this client communicates with
+func (c *HTTPRevzillaClient) GetRetailer() string {
+	return entities.RetailerRevzilla
 }
 
-// NewHTTPRevzillaClient initializes a HTTPRevzillaClient with a default, pooled HTTPClient.
-func NewHTTPRevzillaClient() *HTTPRevzillaClient {
-	return &HTTPRevzillaClient{pooledClient: cleanhttp.DefaultPooledClient()}
+// GetRevzillaProductOverviewsURL returns the URL of the page that lists every Revzilla product in the category with the given URL prefix
+func GetRevzillaProductOverviewsURL(productURLPrefix string) string {
+	return fmt.Sprintf("https://www.revzilla.com/%s?page=1&sort=featured&limit=10000&rating=-1&price=&price_min=3&price_max=1700&is_new=false&is_sale=false&is_made_in_usa=false&has_video=false&is_holiday=false&is_blemished=false&view_all=true", productURLPrefix)
 }
 
 // GetAllProductOverviewsHTML returns a GoQuery document representing each Revzilla Jacket - GetDescriptionPartsByProduct() can be used to further drill into the details for each of these results
-func (c *HTTPRevzillaClient) GetAllProductOverviewsHTML(productURLPrefix string) (*goquery.Document, error) {
+func (c *HTTPRevzillaClient) GetAllProductOverviewsHTML(ctx context.Context, productURLPrefix string) (*goquery.Document, error) {
 
-	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://www.revzilla.com/%s?page=1&sort=featured&limit=10000&rating=-1&price=&price_min=3&price_max=1700&is_new=false&is_sale=false&is_made_in_usa=false&has_video=false&is_holiday=false&is_blemished=false&view_all=true", productURLPrefix), nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, GetRevzillaProductOverviewsURL(productURLPrefix), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	request.Header.Set("User-Agent", httpHelpers.ChromeUserAgent)
-	resp, err := c.pooledClient.Do(request)
+	resp, err := c.httpClient.Do(request)
 	if err != nil {
 		return nil, err
 	}
@@ -43,14 +52,13 @@ func (c *HTTPRevzillaClient) GetAllProductOverviewsHTML(productURLPrefix string)
 }
 
 // GetDescriptionPartsHTMLByURL returns a GoQuery document representing each bullet point in a revzilla product description
-func (c *HTTPRevzillaClient) GetDescriptionPartsHTMLByURL(url string) (*goquery.Document, error) {
-	request, err := http.NewRequest(http.MethodGet, url, nil)
+func (c *HTTPRevzillaClient) GetDescriptionPartsHTMLByURL(ctx context.Context, url string) (*goquery.Document, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	request.Header.Set("User-Agent", httpHelpers.ChromeUserAgent)
-	resp, err := c.pooledClient.Do(request)
+	resp, err := c.httpClient.Do(request)
 	if err != nil {
 		return nil, err
 	}
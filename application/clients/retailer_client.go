@@ -0,0 +1,14 @@
+package clients
+
+import (
+	"context"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// RetailerClient represents a client that can communicate with an online retailer to get the product listings and details used to build product offers
+type RetailerClient interface {
+	GetRetailer() string
+	GetAllProductOverviewsHTML(ctx context.Context, productURLPrefix string) (*goquery.Document, error)
+	GetDescriptionPartsHTMLByURL(ctx context.Context, url string) (*goquery.Document, error)
+}
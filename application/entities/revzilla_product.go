@@ -11,10 +11,28 @@ type RevzillaProduct struct {
 	URL              string
 	Brand            string
 	Name             string
+	Category         string
 	Price            string
 	PriceCurrency    string
+	Availability     string
 	ImageURL         string
 	DescriptionParts []string
+	WeightInLbs      float64
+	Sizes            []string
+	Colors           []string
+	RatingCount      int
+	SKU              string
+	Variants         []RevzillaProductVariant
+}
+
+// RevzillaProductVariant represents a single size and colorway of a product found on its RevZilla detail page
+type RevzillaProductVariant struct {
+	Size          string
+	Color         string
+	SKU           string
+	Price         string
+	PriceCurrency string
+	Availability  string
 }
 
 // GetModel parses the model from the title by replacing the brand with an empty string i.e. "Dainese HF-D1 Jacket" becomes "HF-D1 Jacket"
@@ -22,13 +40,48 @@ func (r RevzillaProduct) GetModel() string {
 	return strings.TrimSpace(strings.Replace(r.Name, r.Brand, "", 1))
 }
 
+// IsInCategory returns true if the product's category, or its name when RevZilla doesn't give a category, contains any of the given lowercase keywords i.e. "back protector"
+func (r RevzillaProduct) IsInCategory(keywords ...string) bool {
+	category := r.Category
+	if category == "" {
+		category = r.Name
+	}
+
+	lowerCategory := strings.ToLower(category)
+	for _, keyword := range keywords {
+		if strings.Contains(lowerCategory, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetPriceCents converts the Price, represented as a float-string, to an integer number of cents.
 func (r RevzillaProduct) GetPriceCents() int {
-	priceFloat, _ := strconv.ParseFloat(r.Price, 64)
+	return getPriceCents(r.Price)
+}
+
+// IsInStock returns true unless the listing's schema.org availability says that the product can't currently be bought
+func (r RevzillaProduct) IsInStock() bool {
+	return isAvailabilityInStock(r.Availability)
+}
+
+// GetPriceCents converts the Price, represented as a float-string, to an integer number of cents.
+func (v RevzillaProductVariant) GetPriceCents() int {
+	return getPriceCents(v.Price)
+}
+
+// IsInStock returns true unless the variant's schema.org availability says that it can't currently be bought
+func (v RevzillaProductVariant) IsInStock() bool {
+	return isAvailabilityInStock(v.Availability)
+}
+
+func getPriceCents(price string) int {
+	priceFloat, _ := strconv.ParseFloat(price, 64)
 	return int(priceFloat * float64(100))
 }
 
-// IsDiscontinued returns true if the product doesn't have the expected summary section that indicates that it is a real product
-func (r RevzillaProduct) IsDiscontinued(buyURLContents string) bool {
-	return !strings.Contains(strings.ToLower(buyURLContents), "product-show-summary")
+func isAvailabilityInStock(availability string) bool {
+	lowerAvailability := strings.ToLower(availability)
+	return !strings.Contains(lowerAvailability, "outofstock") && !strings.Contains(lowerAvailability, "discontinued") && !strings.Contains(lowerAvailability, "soldout")
 }
@@ -0,0 +1,38 @@
+package parsers
+
+import (
+	"atgatt-backend/persistence/entities"
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// ExchangeRatesParser contains functions used to retrieve currency exchange rates. Source can either be a URL or a path to a local JSON file in the form {"base": "USD", "rates": {"GBP": 0.79}}.
+type ExchangeRatesParser struct {
+	Source string
+}
+
+// GetAll returns all of the exchange rates from the configured source
+func (r *ExchangeRatesParser) GetAll(ctx context.Context) (*entities.ExchangeRates, error) {
+	if r.Source == "" {
+		return nil, errors.New("The exchange rates source cannot be empty")
+	}
+
+	sourceBytes, err := readSource(ctx, r.Source, "the exchange rates")
+	if err != nil {
+		return nil, err
+	}
+
+	exchangeRates := &entities.ExchangeRates{}
+	err = json.Unmarshal(sourceBytes, exchangeRates)
+	if err != nil {
+		return nil, err
+	}
+
+	if exchangeRates.BaseCurrency == "" || len(exchangeRates.Rates) == 0 {
+		return nil, errors.New("The exchange rates are missing a base currency or rates")
+	}
+
+	exchangeRates.BaseCurrency = entities.NormalizeCurrency(exchangeRates.BaseCurrency)
+	return exchangeRates, nil
+}
@@ -0,0 +1,96 @@
+package parsers
+
+import (
+	"atgatt-backend/persistence/entities"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FIMHelmetParser contains functions used to retrieve the FIM's list of homologated racing helmets. Source can either be a URL or a path to a local CSV file with manufacturer, model, standard, and homologation number columns.
+type FIMHelmetParser struct {
+	Source string
+}
+
+// GetAll returns the list of all FIM homologated helmets from the configured source
+func (r *FIMHelmetParser) GetAll(ctx context.Context) ([]*entities.FIMHelmet, error) {
+	if r.Source == "" {
+		return nil, errors.New("The FIM helmet list source cannot be empty")
+	}
+
+	sourceBytes, err := readSource(ctx, r.Source, "the FIM helmet list")
+	if err != nil {
+		return nil, err
+	}
+
+	csvReader := csv.NewReader(bytes.NewReader(sourceBytes))
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	manufacturerIndex, modelIndex, standardIndex, homologationNumberIndex := getFIMColumnIndexes(header)
+	if manufacturerIndex < 0 || modelIndex < 0 || standardIndex < 0 {
+		return nil, fmt.Errorf("The FIM helmet list is missing a manufacturer, model, or standard column: %v", header)
+	}
+
+	helmets := []*entities.FIMHelmet{}
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		helmet := &entities.FIMHelmet{
+			Manufacturer:       getCSVValue(record, manufacturerIndex),
+			Model:              getCSVValue(record, modelIndex),
+			Standard:           entities.NormalizeFIMStandard(getCSVValue(record, standardIndex)),
+			HomologationNumber: getCSVValue(record, homologationNumberIndex),
+		}
+
+		if helmet.Manufacturer == "" || helmet.Model == "" || helmet.Standard == "" {
+			logrus.WithField("record", record).Warn("Skipping an FIM helmet with a missing manufacturer, model, or unrecognized standard")
+			continue
+		}
+
+		helmets = append(helmets, helmet)
+	}
+
+	return helmets, nil
+}
+
+func getFIMColumnIndexes(header []string) (int, int, int, int) {
+	manufacturerIndex, modelIndex, standardIndex, homologationNumberIndex := -1, -1, -1, -1
+	for i, column := range header {
+		lowerColumn := strings.ToLower(strings.TrimSpace(column))
+		if strings.Contains(lowerColumn, "manufacturer") || lowerColumn == "brand" {
+			manufacturerIndex = i
+		} else if strings.Contains(lowerColumn, "model") {
+			modelIndex = i
+		} else if strings.Contains(lowerColumn, "standard") {
+			standardIndex = i
+		} else if strings.Contains(lowerColumn, "homologation") || strings.Contains(lowerColumn, "number") {
+			homologationNumberIndex = i
+		}
+	}
+	return manufacturerIndex, modelIndex, standardIndex, homologationNumberIndex
+}
+
+func getCSVValue(record []string, index int) string {
+	if index < 0 || index >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[index])
+}
@@ -0,0 +1,49 @@
+package parsers_test
+
+import (
+	"atgatt-backend/application/parsers"
+	testHelpers "atgatt-backend/common/testing"
+	"atgatt-backend/persistence/entities"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_FIMHelmetParser_GetAll_should_return_the_homologated_helmets_from_a_local_csv(t *testing.T) {
+	RegisterTestingT(t)
+	parser := &parsers.FIMHelmetParser{Source: "../../seeds/fixtures/fim/homologated-helmets.csv"}
+	helmets, err := parser.GetAll(context.Background())
+
+	Expect(err).To(BeNil())
+	Expect(helmets).To(HaveLen(3))
+	Expect(helmets[0]).To(Equal(&entities.FIMHelmet{Manufacturer: "Arai", Model: "RX-7V Racing", Standard: entities.FIMStandardFRHPhe01, HomologationNumber: "FIM-0105"}))
+	Expect(helmets[1].Standard).To(Equal(entities.FIMStandardFRHPhe02))
+	Expect(helmets[2].Model).To(Equal("X-SPR Pro"))
+}
+
+func Test_FIMHelmetParser_GetAll_should_return_the_homologated_helmets_from_a_url(t *testing.T) {
+	RegisterTestingT(t)
+	server := testHelpers.StartFixturesServer("../../seeds/fixtures/fim")
+	defer server.Close()
+
+	parser := &parsers.FIMHelmetParser{Source: server.URL + "/homologated-helmets.csv"}
+	helmets, err := parser.GetAll(context.Background())
+
+	Expect(err).To(BeNil())
+	Expect(helmets).To(HaveLen(3))
+	Expect(helmets[1]).To(Equal(&entities.FIMHelmet{Manufacturer: "HJC", Model: "RPHA 1", Standard: entities.FIMStandardFRHPhe02, HomologationNumber: "FIM-0201"}))
+}
+
+func Test_FIMHelmetParser_GetAll_should_return_an_error_when_a_column_is_missing(t *testing.T) {
+	RegisterTestingT(t)
+	parser := &parsers.FIMHelmetParser{Source: "../../seeds/fixtures/fim/missing-standard-column.csv"}
+	helmets, err := parser.GetAll(context.Background())
+
+	Expect(err).ToNot(BeNil())
+	Expect(helmets).To(BeNil())
+
+	parser.Source = ""
+	_, err = parser.GetAll(context.Background())
+	Expect(err).ToNot(BeNil())
+}
@@ -0,0 +1,350 @@
+package parsers
+
+import (
+	appEntities "atgatt-backend/application/entities"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const lbsPerKg float64 = 2.20462
+const lbsPerOz float64 = 0.0625
+
+var detailsWeightRegexp = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)*)\s*(lbs?|pounds?|kgs?|kilograms?|g|grams?|oz|ounces?)\b`)
+var listSeparatorRegexp = regexp.MustCompile(`\s*(?:,|\||;)\s*`)
+
+// thousandsSeparatorRegexp matches a number where commas or periods separate thousands, i.e. "1,600"
+var thousandsSeparatorRegexp = regexp.MustCompile(`^\d{1,3}(?:[,.]\d{3})+$`)
+
+// revzillaSpecificationsSelector finds the specifications table on a RevZilla detail page, so that other tables on the page (i.e. shipping details or size charts) aren't mistaken for it
+const revzillaSpecificationsSelector = "[class*='specification'], [id*='specification'], [class*='product-specs'], [id*='product-specs']"
+
+var weightSpecificationKeys = map[string]bool{"weight": true, "product weight": true, "item weight": true}
+var sizesSpecificationKeys = map[string]bool{"size": true, "sizes": true, "available sizes": true}
+var colorsSpecificationKeys = map[string]bool{"color": true, "colors": true, "colour": true, "colours": true}
+var skuSpecificationKeys = map[string]bool{"sku": true, "item": true, "part number": true, "item number": true}
+
+// ParseRevzillaProductDetails fills in the category, weight, sizes, colors, rating count, SKU, and variants of the given product using the structured data on its RevZilla detail page. JSON-LD is preferred, then microdata, then the specification tables.
+func ParseRevzillaProductDetails(doc *goquery.Document, revzillaProduct *appEntities.RevzillaProduct) {
+	if doc == nil || revzillaProduct == nil {
+		return
+	}
+
+	doc.Find("script[type='application/ld+json']").Each(func(index int, item *goquery.Selection) {
+		for _, jsonLDProduct := range findJSONLDProducts(item.Text()) {
+			applyJSONLDProduct(jsonLDProduct, revzillaProduct)
+		}
+	})
+
+	applyMicrodata(doc, revzillaProduct)
+	applySpecifications(doc, revzillaProduct)
+}
+
+// ParseWeightInLbs converts a weight such as "3.5 lbs", "1,600 g", or "1,6 kg" to pounds, returning 0 if the weight could not be parsed
+func ParseWeightInLbs(rawWeight string) float64 {
+	matches := detailsWeightRegexp.FindStringSubmatch(rawWeight)
+	if len(matches) < 3 {
+		return 0
+	}
+
+	// Grams and ounces are written without decimals, so "1,600 g" is 1600 grams, while "1,600 kg" is 1.6 kilograms with a decimal comma
+	unit := strings.ToLower(matches[2])
+	number := matches[1]
+	if (strings.HasPrefix(unit, "g") || strings.HasPrefix(unit, "o")) && thousandsSeparatorRegexp.MatchString(number) {
+		number = strings.NewReplacer(",", "", ".", "").Replace(number)
+	} else {
+		number = strings.Replace(number, ",", ".", 1)
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0
+	}
+
+	switch {
+	case strings.HasPrefix(unit, "k"):
+		return value * lbsPerKg
+	case strings.HasPrefix(unit, "g"):
+		return value / 1000 * lbsPerKg
+	case strings.HasPrefix(unit, "o"):
+		return value * lbsPerOz
+	default:
+		return value
+	}
+}
+
+// findJSONLDProducts returns every object with a Product or ProductGroup type in the given JSON-LD script, including ones nested in arrays or an @graph
+func findJSONLDProducts(rawJSONLD string) []map[string]interface{} {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(rawJSONLD)), &parsed); err != nil {
+		return nil
+	}
+
+	products := []map[string]interface{}{}
+	var visit func(node interface{})
+	visit = func(node interface{}) {
+		switch typedNode := node.(type) {
+		case []interface{}:
+			for _, child := range typedNode {
+				visit(child)
+			}
+		case map[string]interface{}:
+			nodeType := getJSONLDString(typedNode["@type"])
+			if strings.EqualFold(nodeType, "Product") || strings.EqualFold(nodeType, "ProductGroup") {
+				products = append(products, typedNode)
+			}
+			visit(typedNode["@graph"])
+		}
+	}
+	visit(parsed)
+
+	return products
+}
+
+func applyJSONLDProduct(jsonLDProduct map[string]interface{}, revzillaProduct *appEntities.RevzillaProduct) {
+	if revzillaProduct.SKU == "" {
+		revzillaProduct.SKU = getJSONLDString(jsonLDProduct["sku"])
+	}
+
+	if revzillaProduct.Category == "" {
+		revzillaProduct.Category = getJSONLDString(jsonLDProduct["category"])
+	}
+
+	if revzillaProduct.WeightInLbs <= 0 {
+		switch weight := jsonLDProduct["weight"].(type) {
+		case map[string]interface{}:
+			revzillaProduct.WeightInLbs = ParseWeightInLbs(getJSONLDString(weight["value"]) + " " + getJSONLDUnit(getJSONLDString(weight["unitCode"]), getJSONLDString(weight["unitText"])))
+		default:
+			revzillaProduct.WeightInLbs = ParseWeightInLbs(getJSONLDString(weight))
+		}
+	}
+
+	if len(revzillaProduct.Colors) == 0 {
+		revzillaProduct.Colors = getJSONLDStrings(jsonLDProduct["color"])
+	}
+
+	if len(revzillaProduct.Sizes) == 0 {
+		revzillaProduct.Sizes = getJSONLDStrings(jsonLDProduct["size"])
+	}
+
+	if len(revzillaProduct.Variants) == 0 {
+		revzillaProduct.Variants = getJSONLDVariants(jsonLDProduct)
+	}
+
+	if revzillaProduct.RatingCount <= 0 {
+		if aggregateRating, ok := jsonLDProduct["aggregateRating"].(map[string]interface{}); ok {
+			revzillaProduct.RatingCount = getJSONLDInt(aggregateRating["reviewCount"])
+			if revzillaProduct.RatingCount <= 0 {
+				revzillaProduct.RatingCount = getJSONLDInt(aggregateRating["ratingCount"])
+			}
+		}
+	}
+}
+
+// getJSONLDVariants returns the variants listed by a ProductGroup's hasVariant, or otherwise by a Product's offers when each offer is for a specific SKU, size, or color
+func getJSONLDVariants(jsonLDProduct map[string]interface{}) []appEntities.RevzillaProductVariant {
+	variants := []appEntities.RevzillaProductVariant{}
+	if jsonLDVariants, ok := jsonLDProduct["hasVariant"].([]interface{}); ok {
+		for _, jsonLDVariant := range jsonLDVariants {
+			variantProduct, ok := jsonLDVariant.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			variant := appEntities.RevzillaProductVariant{
+				Size:  getJSONLDString(variantProduct["size"]),
+				Color: getJSONLDString(variantProduct["color"]),
+				SKU:   getJSONLDString(variantProduct["sku"]),
+			}
+			if offers := getJSONLDObjects(variantProduct["offers"]); len(offers) > 0 {
+				applyJSONLDOffer(offers[0], &variant)
+			}
+			variants = append(variants, variant)
+		}
+	} else {
+		for _, offer := range getJSONLDObjects(jsonLDProduct["offers"]) {
+			itemOffered, _ := offer["itemOffered"].(map[string]interface{})
+			variant := appEntities.RevzillaProductVariant{
+				Size:  getFirstJSONLDString(offer["size"], itemOffered["size"]),
+				Color: getFirstJSONLDString(offer["color"], itemOffered["color"]),
+				SKU:   getFirstJSONLDString(offer["sku"], itemOffered["sku"]),
+			}
+			if variant.Size == "" && variant.Color == "" && variant.SKU == "" {
+				continue
+			}
+
+			applyJSONLDOffer(offer, &variant)
+			variants = append(variants, variant)
+		}
+	}
+
+	if len(variants) == 0 {
+		return nil
+	}
+	return variants
+}
+
+func applyJSONLDOffer(offer map[string]interface{}, variant *appEntities.RevzillaProductVariant) {
+	variant.Price = getFirstJSONLDString(offer["price"], offer["lowPrice"])
+	variant.PriceCurrency = getJSONLDString(offer["priceCurrency"])
+	variant.Availability = getJSONLDString(offer["availability"])
+}
+
+func applyMicrodata(doc *goquery.Document, revzillaProduct *appEntities.RevzillaProduct) {
+	if revzillaProduct.SKU == "" {
+		revzillaProduct.SKU = getMicrodataValue(doc, "sku")
+	}
+
+	if revzillaProduct.Category == "" {
+		revzillaProduct.Category = getMicrodataValue(doc, "category")
+	}
+
+	if revzillaProduct.WeightInLbs <= 0 {
+		revzillaProduct.WeightInLbs = ParseWeightInLbs(getMicrodataValue(doc, "weight"))
+	}
+
+	if len(revzillaProduct.Colors) == 0 {
+		revzillaProduct.Colors = splitList(getMicrodataValue(doc, "color"))
+	}
+
+	if revzillaProduct.RatingCount <= 0 {
+		revzillaProduct.RatingCount, _ = strconv.Atoi(getMicrodataValue(doc, "reviewCount"))
+	}
+
+	if revzillaProduct.RatingCount <= 0 {
+		revzillaProduct.RatingCount, _ = strconv.Atoi(getMicrodataValue(doc, "ratingCount"))
+	}
+}
+
+// applySpecifications reads the rows of the specifications table in document order, so that the first row with a known label wins. Labels have to match exactly, since i.e. "Shipping Weight" isn't the weight of the product.
+func applySpecifications(doc *goquery.Document, revzillaProduct *appEntities.RevzillaProduct) {
+	doc.Find(revzillaSpecificationsSelector).Find("tr, dt").Each(func(index int, row *goquery.Selection) {
+		var key, value string
+		if goquery.NodeName(row) == "dt" {
+			key = normalizeSpecificationKey(row.Text())
+			value = strings.TrimSpace(row.NextFiltered("dd").Text())
+		} else {
+			cells := row.Find("th, td")
+			if cells.Length() < 2 {
+				return
+			}
+			key = normalizeSpecificationKey(cells.First().Text())
+			value = strings.TrimSpace(cells.Eq(1).Text())
+		}
+
+		switch {
+		case weightSpecificationKeys[key] && revzillaProduct.WeightInLbs <= 0:
+			revzillaProduct.WeightInLbs = ParseWeightInLbs(value)
+		case sizesSpecificationKeys[key] && len(revzillaProduct.Sizes) == 0:
+			revzillaProduct.Sizes = splitList(value)
+		case colorsSpecificationKeys[key] && len(revzillaProduct.Colors) == 0:
+			revzillaProduct.Colors = splitList(value)
+		case skuSpecificationKeys[key] && revzillaProduct.SKU == "":
+			revzillaProduct.SKU = value
+		}
+	})
+}
+
+func normalizeSpecificationKey(rawKey string) string {
+	return strings.Trim(strings.ToLower(strings.TrimSpace(rawKey)), ":#")
+}
+
+func getMicrodataValue(doc *goquery.Document, key string) string {
+	selection := doc.Find("[itemprop='" + key + "']").First()
+	if content, exists := selection.Attr("content"); exists {
+		return strings.TrimSpace(content)
+	}
+	return strings.TrimSpace(selection.Text())
+}
+
+func splitList(rawList string) []string {
+	values := []string{}
+	for _, value := range listSeparatorRegexp.Split(strings.TrimSpace(rawList), -1) {
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// getJSONLDUnit converts UN/CEFACT unit codes (used by schema.org) into the unit names that ParseWeightInLbs understands
+func getJSONLDUnit(unitCode string, unitText string) string {
+	switch strings.ToUpper(unitCode) {
+	case "LBR":
+		return "lbs"
+	case "KGM":
+		return "kg"
+	case "GRM":
+		return "g"
+	case "ONZ":
+		return "oz"
+	}
+	return unitText
+}
+
+func getJSONLDString(value interface{}) string {
+	switch typedValue := value.(type) {
+	case string:
+		return strings.TrimSpace(typedValue)
+	case float64:
+		return strconv.FormatFloat(typedValue, 'f', -1, 64)
+	case []interface{}:
+		if len(typedValue) > 0 {
+			return getJSONLDString(typedValue[0])
+		}
+	}
+	return ""
+}
+
+func getJSONLDStrings(value interface{}) []string {
+	if values, ok := value.([]interface{}); ok {
+		strs := []string{}
+		for _, item := range values {
+			if str := getJSONLDString(item); str != "" {
+				strs = append(strs, str)
+			}
+		}
+		if len(strs) == 0 {
+			return nil
+		}
+		return strs
+	}
+	return splitList(getJSONLDString(value))
+}
+
+func getFirstJSONLDString(values ...interface{}) string {
+	for _, value := range values {
+		if str := getJSONLDString(value); str != "" {
+			return str
+		}
+	}
+	return ""
+}
+
+func getJSONLDObjects(value interface{}) []map[string]interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{typedValue}
+	case []interface{}:
+		objects := []map[string]interface{}{}
+		for _, item := range typedValue {
+			if object, ok := item.(map[string]interface{}); ok {
+				objects = append(objects, object)
+			}
+		}
+		return objects
+	}
+	return nil
+}
+
+func getJSONLDInt(value interface{}) int {
+	parsedValue, _ := strconv.ParseFloat(getJSONLDString(value), 64)
+	return int(parsedValue)
+}
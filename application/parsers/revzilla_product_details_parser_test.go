@@ -0,0 +1,163 @@
+package parsers_test
+
+import (
+	appEntities "atgatt-backend/application/entities"
+	"atgatt-backend/application/parsers"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	. "github.com/onsi/gomega"
+)
+
+func parseRevzillaProductDetailsHTML(html string) *appEntities.RevzillaProduct {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	Expect(err).To(BeNil())
+
+	revzillaProduct := &appEntities.RevzillaProduct{}
+	parsers.ParseRevzillaProductDetails(doc, revzillaProduct)
+	return revzillaProduct
+}
+
+func Test_ParseRevzillaProductDetails_should_read_json_ld_products(t *testing.T) {
+	RegisterTestingT(t)
+
+	revzillaProduct := parseRevzillaProductDetailsHTML(`<html><head><script type="application/ld+json">
+		{"@context": "https://schema.org", "@graph": [
+			{"@type": "BreadcrumbList"},
+			{"@type": "Product", "sku": "ABC-123", "color": ["Black", "White"], "size": "S, M, L",
+			 "weight": {"@type": "QuantitativeValue", "value": 1600, "unitCode": "GRM"},
+			 "aggregateRating": {"@type": "AggregateRating", "ratingValue": 4.5, "reviewCount": 42}}
+		]}
+	</script></head><body></body></html>`)
+
+	Expect(revzillaProduct.SKU).To(Equal("ABC-123"))
+	Expect(revzillaProduct.Colors).To(Equal([]string{"Black", "White"}))
+	Expect(revzillaProduct.Sizes).To(Equal([]string{"S", "M", "L"}))
+	Expect(revzillaProduct.WeightInLbs).To(BeNumerically("~", 3.527, 0.001))
+	Expect(revzillaProduct.RatingCount).To(Equal(42))
+}
+
+func Test_ParseRevzillaProductDetails_should_fall_back_to_microdata_and_specification_tables(t *testing.T) {
+	RegisterTestingT(t)
+
+	revzillaProduct := parseRevzillaProductDetailsHTML(`<html><body>
+		<div itemscope itemtype="https://schema.org/Product">
+			<meta itemprop="sku" content="XYZ-9" />
+			<span itemprop="color">Red | Blue</span>
+			<div itemprop="aggregateRating" itemscope><meta itemprop="ratingCount" content="7" /></div>
+		</div>
+		<table class="product-specifications">
+			<tr><th>Weight:</th><td>3.5 lbs</td></tr>
+			<tr><th>Sizes</th><td>XS; S; M</td></tr>
+			<tr><th>Color</th><td>Green</td></tr>
+		</table>
+	</body></html>`)
+
+	Expect(revzillaProduct.SKU).To(Equal("XYZ-9"))
+	Expect(revzillaProduct.Colors).To(Equal([]string{"Red", "Blue"}))
+	Expect(revzillaProduct.RatingCount).To(Equal(7))
+	Expect(revzillaProduct.WeightInLbs).To(Equal(3.5))
+	Expect(revzillaProduct.Sizes).To(Equal([]string{"XS", "S", "M"}))
+}
+
+func Test_ParseRevzillaProductDetails_should_leave_the_product_unchanged_when_no_structured_data_exists(t *testing.T) {
+	RegisterTestingT(t)
+
+	revzillaProduct := parseRevzillaProductDetailsHTML(`<html><body><p>Just a description</p></body></html>`)
+
+	Expect(revzillaProduct.SKU).To(BeEmpty())
+	Expect(revzillaProduct.Colors).To(BeNil())
+	Expect(revzillaProduct.Sizes).To(BeNil())
+	Expect(revzillaProduct.WeightInLbs).To(BeZero())
+	Expect(revzillaProduct.RatingCount).To(BeZero())
+}
+
+func Test_ParseRevzillaProductDetails_should_read_the_category_so_that_jobs_can_skip_products_of_other_types(t *testing.T) {
+	RegisterTestingT(t)
+
+	backProtector := parseRevzillaProductDetailsHTML(`<html><head><script type="application/ld+json">
+		{"@context": "https://schema.org", "@type": "Product", "name": "Knox Aegis Back Protector", "category": "Motorcycle Back Protectors"}
+	</script></head><body></body></html>`)
+	kneeArmor := parseRevzillaProductDetailsHTML(`<html><body>
+		<div itemscope itemtype="https://schema.org/Product"><meta itemprop="category" content="Motorcycle Knee Armor" /></div>
+	</body></html>`)
+	kneeArmor.Name = "Forcefield Back Country Knee Armor"
+
+	Expect(backProtector.Category).To(Equal("Motorcycle Back Protectors"))
+	Expect(backProtector.IsInCategory("back protector", "chest protector")).To(BeTrue())
+	Expect(kneeArmor.Category).To(Equal("Motorcycle Knee Armor"))
+	Expect(kneeArmor.IsInCategory("back protector", "chest protector")).To(BeFalse())
+	Expect(appEntities.RevzillaProduct{Name: "Alpinestars Tech-Air 5 Airbag System"}.IsInCategory("airbag vest", "airbag system")).To(BeTrue())
+	Expect(appEntities.RevzillaProduct{Name: "Alpinestars Tech-Air Charger"}.IsInCategory("airbag vest", "airbag system")).To(BeFalse())
+}
+
+func Test_ParseRevzillaProductDetails_should_only_read_exact_labels_from_the_specifications_table(t *testing.T) {
+	RegisterTestingT(t)
+
+	revzillaProduct := parseRevzillaProductDetailsHTML(`<html><body>
+		<table class="shipping"><tr><th>Weight</th><td>9 lbs</td></tr></table>
+		<div id="product-specifications">
+			<dl>
+				<dt>Shipping Weight</dt><dd>6 lbs</dd>
+				<dt>Weight</dt><dd>1,600 g</dd>
+			</dl>
+			<table>
+				<tr><th>Weight</th><td>2 lbs</td></tr>
+				<tr><th>Size Chart</th><td>See chart</td></tr>
+				<tr><th>Sizes</th><td>S, M</td></tr>
+			</table>
+		</div>
+	</body></html>`)
+
+	Expect(revzillaProduct.WeightInLbs).To(BeNumerically("~", 3.527, 0.001))
+	Expect(revzillaProduct.Sizes).To(Equal([]string{"S", "M"}))
+}
+
+func Test_ParseWeightInLbs_should_convert_common_units(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(parsers.ParseWeightInLbs("3.5 lbs")).To(Equal(3.5))
+	Expect(parsers.ParseWeightInLbs("1,5 kg")).To(BeNumerically("~", 3.307, 0.001))
+	Expect(parsers.ParseWeightInLbs("1,600 g")).To(BeNumerically("~", 3.527, 0.001))
+	Expect(parsers.ParseWeightInLbs("1.600 g")).To(BeNumerically("~", 3.527, 0.001))
+	Expect(parsers.ParseWeightInLbs("1,600 kg")).To(BeNumerically("~", 3.527, 0.001))
+	Expect(parsers.ParseWeightInLbs("1000 grams")).To(BeNumerically("~", 2.205, 0.001))
+	Expect(parsers.ParseWeightInLbs("8 oz")).To(Equal(0.5))
+	Expect(parsers.ParseWeightInLbs("unknown")).To(BeZero())
+}
+
+func Test_ParseRevzillaProductDetails_should_read_variants_from_a_json_ld_product_group(t *testing.T) {
+	RegisterTestingT(t)
+
+	revzillaProduct := parseRevzillaProductDetailsHTML(`<html><head><script type="application/ld+json">
+		{"@context": "https://schema.org", "@type": "ProductGroup", "productGroupID": "123", "hasVariant": [
+			{"@type": "Product", "sku": "123-S-BLK", "size": "S", "color": "Black",
+			 "offers": {"@type": "Offer", "price": "599.99", "priceCurrency": "USD", "availability": "https://schema.org/InStock"}},
+			{"@type": "Product", "sku": "123-S-GFX", "size": "S", "color": "Graphic",
+			 "offers": {"@type": "Offer", "price": 699.99, "priceCurrency": "USD", "availability": "https://schema.org/OutOfStock"}}
+		]}
+	</script></head><body></body></html>`)
+
+	Expect(revzillaProduct.Variants).To(HaveLen(2))
+	Expect(revzillaProduct.Variants[0]).To(Equal(appEntities.RevzillaProductVariant{Size: "S", Color: "Black", SKU: "123-S-BLK", Price: "599.99", PriceCurrency: "USD", Availability: "https://schema.org/InStock"}))
+	Expect(revzillaProduct.Variants[1].IsInStock()).To(BeFalse())
+	Expect(revzillaProduct.Variants[1].GetPriceCents()).To(BeNumerically("~", 69999, 1))
+}
+
+func Test_ParseRevzillaProductDetails_should_read_variants_from_per_sku_json_ld_offers(t *testing.T) {
+	RegisterTestingT(t)
+
+	revzillaProduct := parseRevzillaProductDetailsHTML(`<html><head><script type="application/ld+json">
+		{"@context": "https://schema.org", "@type": "Product", "sku": "456", "offers": [
+			{"@type": "Offer", "sku": "456-M", "price": "249.99", "availability": "InStock", "itemOffered": {"size": "M", "color": "Blue"}},
+			{"@type": "Offer", "sku": "456-L", "price": "259.99", "availability": "InStock", "itemOffered": {"size": "L", "color": "Blue"}}
+		]}
+	</script></head><body></body></html>`)
+
+	Expect(revzillaProduct.SKU).To(Equal("456"))
+	Expect(revzillaProduct.Variants).To(HaveLen(2))
+	Expect(revzillaProduct.Variants[1].Size).To(Equal("L"))
+	Expect(revzillaProduct.Variants[1].Color).To(Equal("Blue"))
+	Expect(revzillaProduct.Variants[1].Price).To(Equal("259.99"))
+}
@@ -3,8 +3,10 @@ package parsers
 import (
 	httpHelpers "atgatt-backend/common/http"
 	"atgatt-backend/persistence/entities"
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"math"
 	"net/http"
 	"net/url"
@@ -14,17 +16,25 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	"github.com/sirupsen/logrus"
 )
 
-// SHARPHelmetParser contains functions used to scrape helmet data from SHARP's website
+// DefaultSHARPBaseURL is the address of SHARP's website
+const DefaultSHARPBaseURL = "https://sharp.dft.gov.uk"
+
+// DefaultSHARPMinHelmets is the fewest helmets SHARP's website should list before we assume its layout changed
+const DefaultSHARPMinHelmets = 400
+
+// SHARPHelmetParser contains functions used to scrape helmet data from SHARP's website. BaseURL, HTTPClient, and MinHelmets default to the live website, the shared crawler, and DefaultSHARPMinHelmets when they are empty.
 type SHARPHelmetParser struct {
-	Limit int
+	Limit      int
+	BaseURL    string
+	HTTPClient httpHelpers.HTTPFetcher
+	MinHelmets int
 }
 
 // GetAll scrapes and returns all helmet data from SHARP's website, or an error if there was a problem fetching/scraping the HTML
-func (r *SHARPHelmetParser) GetAll() ([]*entities.SHARPHelmet, error) {
+func (r *SHARPHelmetParser) GetAll(ctx context.Context) ([]*entities.SHARPHelmet, error) {
 	logrus.Info("Started getting all SHARP helmets")
 	helmets := []*entities.SHARPHelmet{}
 	starsRegexp := regexp.MustCompile(`rating-star-(\d)`)
@@ -37,20 +47,19 @@ func (r *SHARPHelmetParser) GetAll() ([]*entities.SHARPHelmet, error) {
 
 	startTime := time.Now()
 	helmetResultsChannel := make(chan *parseHelmetResult)
-	httpRequestSemaphore := make(chan struct{}, 4) // maximum of 4 concurrent http requests
-	helmetUrlsMap, err := r.GetHelmetUrls()
+	helmetUrlsMap, err := r.GetHelmetUrls(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	numHelmetUrls := len(helmetUrlsMap)
-	if numHelmetUrls < 400 {
+	if numHelmetUrls < r.getMinHelmets() {
 		return nil, errors.New("Too few helmets were found; check to see if the SHARP website changed its layout")
 	}
 
-	pooledHTTPClient := cleanhttp.DefaultPooledClient() // use a pooled http client so that the SSL session is reused between connections
+	httpClient := r.getHTTPClient()
 	for helmetURL := range helmetUrlsMap {
-		go parseSHARPHelmetByURL(pooledHTTPClient, httpRequestSemaphore, helmetURL, helmetResultsChannel, weightRegexp, starsRegexp, topImpactZoneRegexp, leftImpactZoneRegexp, rightImpactZoneRegexp, rearImpactZoneRegexp, latchPercentageRegexp)
+		go parseSHARPHelmetByURL(ctx, httpClient, helmetURL, helmetResultsChannel, weightRegexp, starsRegexp, topImpactZoneRegexp, leftImpactZoneRegexp, rightImpactZoneRegexp, rearImpactZoneRegexp, latchPercentageRegexp)
 	}
 
 	for index := 0; index < numHelmetUrls; index++ {
@@ -76,7 +85,7 @@ func (r *SHARPHelmetParser) GetAll() ([]*entities.SHARPHelmet, error) {
 }
 
 // GetHelmetUrls calls an undocumented SHARP endpoint to retrieve a hash set of all the helmet urls on the SHARP website
-func (r *SHARPHelmetParser) GetHelmetUrls() (map[string]bool, error) {
+func (r *SHARPHelmetParser) GetHelmetUrls(ctx context.Context) (map[string]bool, error) {
 	limitToUse := strconv.Itoa(r.Limit)
 	if r.Limit < 0 {
 		limitToUse = "500000"
@@ -90,60 +99,113 @@ func (r *SHARPHelmetParser) GetHelmetUrls() (map[string]bool, error) {
 	form.Add("pageNumber", "1")
 	form.Add("type", "1")
 
-	resp, err := httpHelpers.MakeFormPOSTRequest("https://sharp.dft.gov.uk/wp-admin/admin-ajax.php", form)
+	baseURL, err := url.Parse(r.getBaseURL())
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, r.getBaseURL()+"/wp-admin/admin-ajax.php", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := r.getHTTPClient().Do(request)
 	if err != nil {
 		return nil, err
 	}
-	resp = "<html><table>" + resp + "</table></html>" // SHARP's undocumented API returns invalid HTML with no root node, so we have to add the root nodes ourselves
-	responseReader := strings.NewReader(resp)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Received an unexpected status code while fetching the SHARP helmet list: %d", resp.StatusCode)
+	}
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	html := "<html><table>" + string(respBytes) + "</table></html>" // SHARP's undocumented API returns invalid HTML with no root node, so we have to add the root nodes ourselves
+	responseReader := strings.NewReader(html)
 
 	doc, err := goquery.NewDocumentFromReader(responseReader)
 	if err != nil {
 		return nil, err
 	}
-	rows := doc.Find("a[href*='sharp.dft.gov.uk/helmets/']")
+	rows := doc.Find("a[href*='/helmets/']")
 	helmetUrlsMap := make(map[string]bool)
 
 	numLinks := len(rows.Nodes)
 	for linkIndex := 0; linkIndex < numLinks; linkIndex++ {
 		linkSelection := rows.Eq(linkIndex)
-		url, linkExists := linkSelection.Attr("href")
+		href, linkExists := linkSelection.Attr("href")
 		if !linkExists {
 			logrus.WithField("linkIndex", linkIndex).Warn("Encountered an empty link while parsing SHARP data")
 			continue
 		}
 
-		if _, exists := helmetUrlsMap[url]; !exists {
-			helmetUrlsMap[url] = true
+		// links can be relative, and only links to helmet pages on the SHARP website itself are kept
+		helmetURL, err := baseURL.Parse(href)
+		if err != nil || helmetURL.Host != baseURL.Host || !strings.HasPrefix(helmetURL.Path, baseURL.Path+"/helmets/") {
+			continue
+		}
+
+		if _, exists := helmetUrlsMap[helmetURL.String()]; !exists {
+			helmetUrlsMap[helmetURL.String()] = true
 		}
 	}
 	return helmetUrlsMap, nil
 }
 
+func (r *SHARPHelmetParser) getBaseURL() string {
+	if r.BaseURL == "" {
+		return DefaultSHARPBaseURL
+	}
+
+	return strings.TrimSuffix(r.BaseURL, "/")
+}
+
+func (r *SHARPHelmetParser) getHTTPClient() httpHelpers.HTTPFetcher {
+	if r.HTTPClient == nil {
+		return httpHelpers.DefaultCrawler
+	}
+
+	return r.HTTPClient
+}
+
+func (r *SHARPHelmetParser) getMinHelmets() int {
+	if r.MinHelmets <= 0 {
+		return DefaultSHARPMinHelmets
+	}
+
+	return r.MinHelmets
+}
+
 type parseHelmetResult struct {
 	helmet    *entities.SHARPHelmet
 	helmetURL string
 	err       error
 }
 
-func parseSHARPHelmetByURL(pooledHTTPClient *http.Client, httpRequestsSemaphore chan struct{}, helmetURL string, helmetResultsChannel chan *parseHelmetResult, weightRegexp *regexp.Regexp, starsRegexp *regexp.Regexp, topImpactZoneRegexp *regexp.Regexp, leftImpactZoneRegexp *regexp.Regexp, rightImpactZoneRegexp *regexp.Regexp, rearImpactZoneRegexp *regexp.Regexp, latchPercentageRegexp *regexp.Regexp) {
+func parseSHARPHelmetByURL(ctx context.Context, httpClient httpHelpers.HTTPFetcher, helmetURL string, helmetResultsChannel chan *parseHelmetResult, weightRegexp *regexp.Regexp, starsRegexp *regexp.Regexp, topImpactZoneRegexp *regexp.Regexp, leftImpactZoneRegexp *regexp.Regexp, rightImpactZoneRegexp *regexp.Regexp, rearImpactZoneRegexp *regexp.Regexp, latchPercentageRegexp *regexp.Regexp) {
 	helmetLogger := logrus.WithField("helmetUrl", helmetURL)
 	helmetLogger.Info("Starting to parse helmet data")
 
-	// increment while we're waiting for the request to finish
-	var emptyItem struct{}
-	httpRequestsSemaphore <- emptyItem
-	resp, err := pooledHTTPClient.Get(helmetURL)
+	// the crawler rate limits these requests, so every helmet can be requested at once
 	result := &parseHelmetResult{helmetURL: helmetURL}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, helmetURL, nil)
 	if err != nil {
 		result.err = err
 		helmetResultsChannel <- result
 		return
 	}
-	helmetDetailsDoc, err := goquery.NewDocumentFromResponse(resp)
-	<-httpRequestsSemaphore
-	// ^ decrement after the request is done
 
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		result.err = err
+		helmetResultsChannel <- result
+		return
+	}
+	helmetDetailsDoc, err := goquery.NewDocumentFromResponse(resp)
 	if err != nil {
 		result.err = err
 		helmetResultsChannel <- result
@@ -238,21 +300,24 @@ func parseSHARPHelmetByURL(pooledHTTPClient *http.Client, httpRequestsSemaphore
 	retentionSystem := findDetailsTextByHeader(helmetDetailsDoc, "retention system")
 	materials := findDetailsTextByHeader(helmetDetailsDoc, "materials")
 	otherStandardsText := findDetailsTextByHeader(helmetDetailsDoc, "other standards")
-	isECERated := strings.Contains(otherStandardsText, "ECE")
+	eceVersion := entities.ParseECEVersion(otherStandardsText)
+	isECERated := strings.Contains(otherStandardsText, "ECE") || eceVersion != ""
 
 	helmet := &entities.SHARPHelmet{
-		Subtype:              subtype,
-		Model:                model,
-		Manufacturer:         manufacturer,
-		ImageURL:             productImageURL,
-		LatchPercentage:      latchPercentage,
-		WeightInLbs:          weightInLbs,
-		Sizes:                sizes,
-		RetentionSystem:      retentionSystem,
-		Materials:            materials,
-		IsECECertified:       isECERated,
-		Certifications:       &entities.SHARPCertification{Stars: starsValue, ImpactZoneRatings: impactZoneRatings},
-		ApproximateMSRPCents: approximateMSRPCents,
+		Subtype:                 subtype,
+		Model:                   model,
+		Manufacturer:            manufacturer,
+		ImageURL:                productImageURL,
+		LatchPercentage:         latchPercentage,
+		WeightInLbs:             weightInLbs,
+		Sizes:                   sizes,
+		RetentionSystem:         retentionSystem,
+		Materials:               materials,
+		IsECECertified:          isECERated,
+		ECEVersion:              eceVersion,
+		Certifications:          &entities.SHARPCertification{Stars: starsValue, ImpactZoneRatings: impactZoneRatings},
+		ApproximateMSRPCents:    approximateMSRPCents,
+		ApproximateMSRPCurrency: entities.CurrencyGBP,
 	}
 
 	result.helmet = helmet
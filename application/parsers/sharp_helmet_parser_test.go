@@ -0,0 +1,90 @@
+package parsers_test
+
+import (
+	"atgatt-backend/application/parsers"
+	testHelpers "atgatt-backend/common/testing"
+	"atgatt-backend/persistence/entities"
+	"context"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_SHARPHelmetParser_GetHelmetUrls_should_only_return_each_helmet_page_on_the_sharp_website_once(t *testing.T) {
+	RegisterTestingT(t)
+	server := testHelpers.StartFixturesServer("../../seeds/fixtures/sharp")
+	defer server.Close()
+
+	parser := &parsers.SHARPHelmetParser{Limit: -1, BaseURL: server.URL, HTTPClient: server.Client()}
+	helmetUrls, err := parser.GetHelmetUrls(context.Background())
+
+	Expect(err).To(BeNil())
+	Expect(helmetUrls).To(Equal(map[string]bool{
+		server.URL + "/helmets/hjc-rpha-11/":      true,
+		server.URL + "/helmets/schuberth-c4-pro/": true,
+		server.URL + "/helmets/caberg-drift/":     true,
+	}))
+}
+
+func Test_SHARPHelmetParser_GetAll_should_parse_every_helmet_page(t *testing.T) {
+	RegisterTestingT(t)
+	server := testHelpers.StartFixturesServer("../../seeds/fixtures/sharp")
+	defer server.Close()
+
+	parser := &parsers.SHARPHelmetParser{Limit: -1, BaseURL: server.URL, HTTPClient: server.Client(), MinHelmets: 3}
+	helmets, err := parser.GetAll(context.Background())
+
+	Expect(err).To(BeNil())
+	Expect(helmets).To(HaveLen(3))
+	helmetsByModel := map[string]*entities.SHARPHelmet{}
+	for _, helmet := range helmets {
+		helmetsByModel[helmet.Model] = helmet
+	}
+
+	modularHelmet := helmetsByModel["C4 Pro"]
+	Expect(modularHelmet).ToNot(BeNil())
+	Expect(modularHelmet.Manufacturer).To(Equal("Schuberth"))
+	Expect(modularHelmet.Subtype).To(Equal("modular"))
+	Expect(modularHelmet.ImageURL).To(Equal("https://sharp.dft.gov.uk/wp-content/uploads/2019/06/schuberth-c4-pro.jpg"))
+	Expect(modularHelmet.LatchPercentage).To(Equal(95))
+	Expect(modularHelmet.WeightInLbs).To(BeNumerically("~", 3.682, 0.001))
+	Expect(modularHelmet.ApproximateMSRPCents).To(Equal(59900))
+	Expect(modularHelmet.IsECECertified).To(BeTrue())
+	Expect(modularHelmet.Certifications.Stars).To(Equal(4))
+	Expect(modularHelmet.Certifications.ImpactZoneRatings.Right).To(Equal(1))
+	Expect(modularHelmet.Certifications.ImpactZoneRatings.Top.Rear).To(Equal(1))
+
+	fullFaceHelmet := helmetsByModel["Drift"]
+	Expect(fullFaceHelmet).ToNot(BeNil())
+	Expect(fullFaceHelmet.Subtype).To(Equal("full"))
+	Expect(fullFaceHelmet.LatchPercentage).To(Equal(-1))
+	Expect(fullFaceHelmet.WeightInLbs).To(BeNumerically("~", 3.263, 0.001))
+	Expect(fullFaceHelmet.Sizes).To(Equal([]string{"XS", "S", "M", "L", "XL"}))
+	Expect(fullFaceHelmet.IsECECertified).To(BeFalse())
+	Expect(fullFaceHelmet.Certifications.ImpactZoneRatings.Top.Front).To(Equal(2))
+	Expect(fullFaceHelmet.Certifications.ImpactZoneRatings.Rear).To(Equal(2))
+}
+
+func Test_SHARPHelmetParser_GetAll_should_return_an_error_if_too_few_helmets_were_found(t *testing.T) {
+	RegisterTestingT(t)
+	server := testHelpers.StartFixturesServer("../../seeds/fixtures/sharp")
+	defer server.Close()
+
+	parser := &parsers.SHARPHelmetParser{Limit: -1, BaseURL: server.URL, HTTPClient: server.Client()}
+	helmets, err := parser.GetAll(context.Background())
+
+	Expect(err).ToNot(BeNil())
+	Expect(helmets).To(BeNil())
+}
+
+func Test_SHARPHelmetParser_GetHelmetUrls_should_return_an_error_if_the_helmet_list_is_missing(t *testing.T) {
+	RegisterTestingT(t)
+	server := testHelpers.StartFixturesServer("../../seeds/fixtures/snell")
+	defer server.Close()
+
+	parser := &parsers.SHARPHelmetParser{Limit: -1, BaseURL: server.URL, HTTPClient: http.DefaultClient}
+	_, err := parser.GetHelmetUrls(context.Background())
+
+	Expect(err).ToNot(BeNil())
+}
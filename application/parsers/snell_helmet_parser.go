@@ -1,16 +1,28 @@
 package parsers
 
 import (
+	httpHelpers "atgatt-backend/common/http"
 	"atgatt-backend/persistence/entities"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
 )
 
-// SNELLHelmetParser contains functions used to retrieve SNELL's helmet data via their JSON API
+// DefaultSNELLBaseURL is the address of SNELL's website
+const DefaultSNELLBaseURL = "http://snell.us.com"
+
+// DefaultSNELLMinHelmets is the fewest helmets SNELL's JSON API should return before we assume it changed
+const DefaultSNELLMinHelmets = 100
+
+// SNELLHelmetParser contains functions used to retrieve SNELL's helmet data via their JSON API. BaseURL, HTTPClient, and MinHelmets default to the live website, the shared crawler, and DefaultSNELLMinHelmets when they are empty.
 type SNELLHelmetParser struct {
+	BaseURL    string
+	HTTPClient httpHelpers.HTTPFetcher
+	MinHelmets int
 }
 
 // SNELLHelmetsResponse represents the data returned from SNELL's JSON API
@@ -19,21 +31,48 @@ type SNELLHelmetsResponse struct {
 }
 
 // GetAllByCertification returns the list of SNELL helmets that are certified to the given standard.
-func (r *SNELLHelmetParser) GetAllByCertification(standard string) ([]*entities.SNELLHelmet, error) {
+func (r *SNELLHelmetParser) GetAllByCertification(ctx context.Context, standard string) ([]*entities.SNELLHelmet, error) {
 	if standard == "" {
 		return nil, errors.New("The standard cannot be empty")
 	}
 
-	resp, err := http.Get("http://snell.us.com/codefolder/datatable.php")
+	return r.GetAllByCertifications(ctx, []string{standard})
+}
+
+// GetAllByCertifications returns the list of SNELL helmets that are certified to any of the given standards. Each helmet's Standard is normalized so that it can be compared against the SNELLStandard constants.
+func (r *SNELLHelmetParser) GetAllByCertifications(ctx context.Context, standards []string) ([]*entities.SNELLHelmet, error) {
+	if len(standards) == 0 {
+		return nil, errors.New("The standards cannot be empty")
+	}
+
+	standardsToFind := make(map[string]bool)
+	for _, standard := range standards {
+		if standard == "" {
+			return nil, errors.New("The standard cannot be empty")
+		}
+		standardsToFind[entities.NormalizeSNELLStandard(standard)] = true
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, r.getBaseURL()+"/codefolder/datatable.php", nil)
 	if err != nil {
 		return nil, err
 	}
-	respBytes, err := ioutil.ReadAll(resp.Body)
+
+	resp, err := r.getHTTPClient().Do(request)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Received an unexpected status code while fetching SNELL helmets: %d", resp.StatusCode)
+	}
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
 	snellHelmetsResponse := &SNELLHelmetsResponse{}
 	err = json.Unmarshal(respBytes, snellHelmetsResponse)
 	if err != nil {
@@ -41,13 +80,14 @@ func (r *SNELLHelmetParser) GetAllByCertification(standard string) ([]*entities.
 	}
 
 	numHelmets := len(snellHelmetsResponse.Data)
-	if numHelmets < 100 {
+	if numHelmets < r.getMinHelmets() {
 		return nil, errors.New("Did not receive enough SNELL helmets")
 	}
 
 	filteredHelmets := []*entities.SNELLHelmet{}
 	for _, rawHelmet := range snellHelmetsResponse.Data {
-		if strings.EqualFold(strings.TrimSpace(rawHelmet.Standard), standard) {
+		normalizedStandard := entities.NormalizeSNELLStandard(rawHelmet.Standard)
+		if standardsToFind[normalizedStandard] {
 			subtype := ""
 			if strings.EqualFold(rawHelmet.FaceConfig, "modular") {
 				subtype = "modular"
@@ -58,8 +98,33 @@ func (r *SNELLHelmetParser) GetAllByCertification(standard string) ([]*entities.
 			}
 
 			rawHelmet.FaceConfig = subtype
+			rawHelmet.Standard = normalizedStandard
 			filteredHelmets = append(filteredHelmets, rawHelmet)
 		}
 	}
 	return filteredHelmets, nil
 }
+
+func (r *SNELLHelmetParser) getBaseURL() string {
+	if r.BaseURL == "" {
+		return DefaultSNELLBaseURL
+	}
+
+	return strings.TrimSuffix(r.BaseURL, "/")
+}
+
+func (r *SNELLHelmetParser) getHTTPClient() httpHelpers.HTTPFetcher {
+	if r.HTTPClient == nil {
+		return httpHelpers.DefaultCrawler
+	}
+
+	return r.HTTPClient
+}
+
+func (r *SNELLHelmetParser) getMinHelmets() int {
+	if r.MinHelmets <= 0 {
+		return DefaultSNELLMinHelmets
+	}
+
+	return r.MinHelmets
+}
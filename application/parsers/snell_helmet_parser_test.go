@@ -0,0 +1,39 @@
+package parsers_test
+
+import (
+	"atgatt-backend/application/parsers"
+	testHelpers "atgatt-backend/common/testing"
+	"atgatt-backend/persistence/entities"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_SNELLHelmetParser_GetAllByCertifications_should_only_return_helmets_certified_to_the_given_standards(t *testing.T) {
+	RegisterTestingT(t)
+	server := testHelpers.StartFixturesServer("../../seeds/fixtures/snell")
+	defer server.Close()
+
+	parser := &parsers.SNELLHelmetParser{BaseURL: server.URL, HTTPClient: server.Client(), MinHelmets: 1}
+	helmets, err := parser.GetAllByCertifications(context.Background(), []string{entities.SNELLStandardM2015, entities.SNELLStandardM2020D})
+
+	Expect(err).To(BeNil())
+	Expect(helmets).To(HaveLen(4))
+	Expect(helmets[0]).To(Equal(&entities.SNELLHelmet{Manufacturer: "HJC Helmets", Model: "RPHA 11", Size: "XS,S,M,L,XL,XXL", Standard: entities.SNELLStandardM2020D, HelmetType: "Motorcycle", FaceConfig: "full"}))
+	Expect(helmets[3].Model).To(Equal("Neotec II"))
+	Expect(helmets[3].Standard).To(Equal(entities.SNELLStandardM2015))
+	Expect(helmets[3].FaceConfig).To(Equal("modular"))
+}
+
+func Test_SNELLHelmetParser_GetAllByCertification_should_return_an_error_if_too_few_helmets_were_returned(t *testing.T) {
+	RegisterTestingT(t)
+	server := testHelpers.StartFixturesServer("../../seeds/fixtures/snell")
+	defer server.Close()
+
+	parser := &parsers.SNELLHelmetParser{BaseURL: server.URL, HTTPClient: server.Client()}
+	helmets, err := parser.GetAllByCertification(context.Background(), entities.SNELLStandardM2020D)
+
+	Expect(err).ToNot(BeNil())
+	Expect(helmets).To(BeNil())
+}
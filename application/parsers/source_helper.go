@@ -0,0 +1,34 @@
+package parsers
+
+import (
+	httpHelpers "atgatt-backend/common/http"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// readSource returns the contents of the given source, which can either be a URL or a path to a local file. The description is used in error messages.
+func readSource(ctx context.Context, source string, description string) ([]byte, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := httpHelpers.DefaultCrawler.Do(request)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("Received an unexpected status code while fetching %s: %d", description, resp.StatusCode)
+		}
+
+		return ioutil.ReadAll(resp.Body)
+	}
+
+	return ioutil.ReadFile(source)
+}
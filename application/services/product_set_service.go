@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+
 	"atgatt-backend/persistence/entities"
 	"atgatt-backend/persistence/repositories"
 
@@ -14,10 +16,10 @@ type ProductSetService struct {
 }
 
 // UpsertProductSet either creates a new product set or gets an existing one if an exact match is found in the DB
-func (s *ProductSetService) UpsertProductSet(sourceProductSetID *uuid.UUID, productID uuid.UUID) (uuid.UUID, error) {
+func (s *ProductSetService) UpsertProductSet(ctx context.Context, sourceProductSetID *uuid.UUID, productID uuid.UUID) (uuid.UUID, error) {
 	var productSet *entities.ProductSet
 
-	product, err := s.ProductRepository.GetByUUID(productID.String())
+	product, err := s.ProductRepository.GetByUUID(ctx, productID.String())
 	if err != nil {
 		return uuid.Nil, err
 	}
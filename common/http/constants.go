@@ -1,4 +1,7 @@
 package helpers
 
-// ChromeUserAgent represents a fake useragent representing a Chrome browser
-const ChromeUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_14_5) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/74.0.3729.157 Safari/537.36"
+// CrawlerUserAgent identifies our crawler to the sites it sends requests to. It starts with the same product token that robots.txt groups are matched against, so sites can tell who we are and control what we crawl.
+const CrawlerUserAgent = "atgatt/1.0 (+https://www.atgatt.co)"
+
+// CrawlerRobotsUserAgent is the product token in CrawlerUserAgent that robots.txt groups are matched against
+const CrawlerRobotsUserAgent = "atgatt"
@@ -0,0 +1,370 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/sirupsen/logrus"
+)
+
+// HTTPFetcher sends HTTP requests. *http.Client and *Crawler both implement it, so scrapers can be pointed at a crawler in production and at a local stand-in server in tests.
+type HTTPFetcher interface {
+	Do(request *http.Request) (*http.Response, error)
+}
+
+// ErrDisallowedByRobotsTxt is returned instead of sending a request when the host's robots.txt does not allow the requested path to be crawled
+var ErrDisallowedByRobotsTxt = errors.New("The request was not sent because the host's robots.txt disallows it")
+
+const maxRobotsTxtBytes = 512 * 1024
+
+const maxBytesToDrain = 64 * 1024
+
+// CrawlerOptions configures how politely a Crawler treats the hosts it sends requests to. Options are used as-is, so start from DefaultCrawlerOptions() and override what you need.
+type CrawlerOptions struct {
+	// DefaultHostLimit rate limits every host that isn't listed in HostLimits
+	DefaultHostLimit HostLimit
+	// HostLimits rate limits specific hosts, keyed by host name i.e. www.revzilla.com
+	HostLimits map[string]HostLimit
+	// Timeout is the maximum amount of time a single attempt can take, including reading the response body
+	Timeout time.Duration
+	// MaxRetries is the number of times a request is retried after a network error, a 429, or a 5xx response
+	MaxRetries int
+	// MinRetryDelay and MaxRetryDelay bound the jittered exponential backoff between retries
+	MinRetryDelay time.Duration
+	MaxRetryDelay time.Duration
+	// MaxRetryAfter is the longest Retry-After that will be waited out, and responses asking us to wait longer are returned as-is
+	MaxRetryAfter time.Duration
+	// IgnoreRobotsTxt sends every request without checking the host's robots.txt first
+	IgnoreRobotsTxt bool
+	// UserAgent is sent with every request, replacing any User-Agent that the request already has
+	UserAgent string
+	// RobotsUserAgent is the product token that robots.txt groups are matched against, which should match UserAgent
+	RobotsUserAgent string
+	// RobotsTxtTTL is how long a host's robots.txt is cached for
+	RobotsTxtTTL time.Duration
+}
+
+// CrawlerHostMetrics counts the requests that a Crawler has sent to a single host
+type CrawlerHostMetrics struct {
+	Requests                   int         `json:"requests"`
+	Retries                    int         `json:"retries"`
+	Errors                     int         `json:"errors"`
+	ResponsesByStatusCode      map[int]int `json:"responsesByStatusCode"`
+	RobotsTxtDisallowed        int         `json:"robotsTxtDisallowed"`
+	TotalLatencyMilliseconds   int64       `json:"totalLatencyMilliseconds"`
+	TotalThrottledMilliseconds int64       `json:"totalThrottledMilliseconds"`
+}
+
+// Crawler is an HTTPFetcher that is polite to the hosts it sends requests to. It rate limits requests with a token bucket per host, times out slow requests, retries failed requests with jittered exponential backoff while honoring Retry-After, skips paths that the host's robots.txt disallows, and keeps metrics for every host. It is safe to use from multiple goroutines at once.
+type Crawler struct {
+	options CrawlerOptions
+	client  *http.Client
+	mutex   sync.Mutex
+	hosts   map[string]*crawlerHost
+}
+
+type crawlerHost struct {
+	bucket             *tokenBucket
+	metrics            *CrawlerHostMetrics
+	robotsTxtMutex     sync.Mutex
+	robotsTxt          *robotsTxt
+	robotsTxtFetchedAt time.Time
+}
+
+// DefaultCrawlerOptions returns the options used by DefaultCrawler
+func DefaultCrawlerOptions() CrawlerOptions {
+	return CrawlerOptions{
+		DefaultHostLimit: HostLimit{RequestsPerSecond: 2, Burst: 4},
+		HostLimits: map[string]HostLimit{
+			"ads.api.cj.com": {RequestsPerSecond: 1.0 / 3, Burst: 1}, // CJ has an absurdly low threshold for requests per minute
+		},
+		Timeout:         60 * time.Second,
+		MaxRetries:      3,
+		MinRetryDelay:   time.Second,
+		MaxRetryDelay:   30 * time.Second,
+		MaxRetryAfter:   2 * time.Minute,
+		UserAgent:       CrawlerUserAgent,
+		RobotsUserAgent: CrawlerRobotsUserAgent,
+		RobotsTxtTTL:    24 * time.Hour,
+	}
+}
+
+// DefaultCrawler is shared by every scraper that isn't given another HTTPFetcher. The worker replaces it at startup with a crawler configured from the environment.
+var DefaultCrawler = NewCrawler(DefaultCrawlerOptions())
+
+// NewCrawler initializes a Crawler with a pooled HTTP client so that connections are reused between requests to the same host
+func NewCrawler(options CrawlerOptions) *Crawler {
+	client := cleanhttp.DefaultPooledClient()
+	client.Timeout = options.Timeout
+	return &Crawler{options: options, client: client, hosts: map[string]*crawlerHost{}}
+}
+
+// Do sends the request once the host's rate limit allows it, retrying it if it fails in a way that is likely to be temporary. Requests with a body are only retried if the body can be re-read via GetBody.
+func (c *Crawler) Do(request *http.Request) (*http.Response, error) {
+	host := c.getHost(request.URL)
+	if !c.options.IgnoreRobotsTxt && !c.isAllowedByRobotsTxt(request, host) {
+		c.updateMetrics(host, func(metrics *CrawlerHostMetrics) {
+			metrics.RobotsTxtDisallowed++
+		})
+		return nil, ErrDisallowedByRobotsTxt
+	}
+
+	ctx := request.Context()
+	attemptRequest := request
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			attemptRequest = request.Clone(ctx)
+			if request.GetBody != nil {
+				body, err := request.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptRequest.Body = body
+			}
+		}
+
+		resp, err := c.send(host, attemptRequest)
+		if attempt >= c.options.MaxRetries || !isRetryable(request, resp, err) {
+			return resp, err
+		}
+
+		retryDelay := c.getRetryDelay(attempt)
+		if resp != nil {
+			if retryAfter, exists := ParseRetryAfter(resp.Header.Get("Retry-After")); exists {
+				if retryAfter > c.options.MaxRetryAfter {
+					return resp, nil
+				}
+
+				// Retry-After applies to the whole host, so hold back every other request to it as well
+				host.bucket.blockFor(retryAfter)
+				if retryAfter > retryDelay {
+					retryDelay = retryAfter
+				}
+			}
+			drainAndClose(resp.Body)
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"url":        request.URL.String(),
+			"attempt":    attempt + 1,
+			"retryDelay": retryDelay.String(),
+		}).WithError(err).Warning("Request failed, retrying after a delay")
+		c.updateMetrics(host, func(metrics *CrawlerHostMetrics) {
+			metrics.Retries++
+		})
+
+		if err := sleep(ctx, retryDelay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// GetMetrics returns a snapshot of the metrics for every host that the crawler has sent requests to, keyed by host
+func (c *Crawler) GetMetrics() map[string]*CrawlerHostMetrics {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	metricsByHost := make(map[string]*CrawlerHostMetrics, len(c.hosts))
+	for hostName, host := range c.hosts {
+		metrics := *host.metrics
+		metrics.ResponsesByStatusCode = make(map[int]int, len(host.metrics.ResponsesByStatusCode))
+		for statusCode, count := range host.metrics.ResponsesByStatusCode {
+			metrics.ResponsesByStatusCode[statusCode] = count
+		}
+		metricsByHost[hostName] = &metrics
+	}
+	return metricsByHost
+}
+
+// send waits for the host's rate limit and sends a single attempt of the request
+func (c *Crawler) send(host *crawlerHost, request *http.Request) (*http.Response, error) {
+	throttledDuration, err := host.bucket.wait(request.Context())
+	c.updateMetrics(host, func(metrics *CrawlerHostMetrics) {
+		metrics.TotalThrottledMilliseconds += throttledDuration.Milliseconds()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if c.options.UserAgent != "" {
+		request.Header.Set("User-Agent", c.options.UserAgent)
+	}
+
+	startTime := time.Now()
+	resp, err := c.client.Do(request)
+	latency := time.Since(startTime)
+	c.updateMetrics(host, func(metrics *CrawlerHostMetrics) {
+		metrics.Requests++
+		metrics.TotalLatencyMilliseconds += latency.Milliseconds()
+		if err != nil {
+			metrics.Errors++
+		} else {
+			metrics.ResponsesByStatusCode[resp.StatusCode]++
+		}
+	})
+	return resp, err
+}
+
+func (c *Crawler) getHost(requestURL *url.URL) *crawlerHost {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	host, exists := c.hosts[requestURL.Host]
+	if exists {
+		return host
+	}
+
+	limit, exists := c.options.HostLimits[requestURL.Hostname()]
+	if !exists {
+		limit = c.options.DefaultHostLimit
+	}
+
+	host = &crawlerHost{bucket: newTokenBucket(limit), metrics: &CrawlerHostMetrics{ResponsesByStatusCode: map[int]int{}}}
+	c.hosts[requestURL.Host] = host
+	return host
+}
+
+func (c *Crawler) updateMetrics(host *crawlerHost, update func(metrics *CrawlerHostMetrics)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	update(host.metrics)
+}
+
+func (c *Crawler) isAllowedByRobotsTxt(request *http.Request, host *crawlerHost) bool {
+	path := request.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if request.URL.RawQuery != "" {
+		path += "?" + request.URL.RawQuery
+	}
+
+	return c.getRobotsTxt(request, host).isAllowed(path)
+}
+
+// getRobotsTxt returns the host's cached robots.txt, fetching it first if it was never fetched or has expired
+func (c *Crawler) getRobotsTxt(request *http.Request, host *crawlerHost) *robotsTxt {
+	host.robotsTxtMutex.Lock()
+	defer host.robotsTxtMutex.Unlock()
+
+	if host.robotsTxt != nil && time.Since(host.robotsTxtFetchedAt) < c.options.RobotsTxtTTL {
+		return host.robotsTxt
+	}
+
+	robotsTxt, isCacheable := c.fetchRobotsTxt(request, host)
+	if isCacheable {
+		host.robotsTxt = robotsTxt
+		host.robotsTxtFetchedAt = time.Now()
+		host.bucket.slowDownTo(robotsTxt.crawlDelay)
+	}
+	return robotsTxt
+}
+
+// fetchRobotsTxt downloads and parses the host's robots.txt. Hosts without one allow everything, and hosts that fail to return one are allowed everything until the next request tries again.
+func (c *Crawler) fetchRobotsTxt(request *http.Request, host *crawlerHost) (*robotsTxt, bool) {
+	robotsTxtURL := &url.URL{Scheme: request.URL.Scheme, Host: request.URL.Host, Path: "/robots.txt"}
+	robotsTxtLogger := logrus.WithField("robotsTxtURL", robotsTxtURL.String())
+	robotsTxtRequest, err := http.NewRequestWithContext(request.Context(), http.MethodGet, robotsTxtURL.String(), nil)
+	if err != nil {
+		return &robotsTxt{}, false
+	}
+
+	resp, err := c.send(host, robotsTxtRequest)
+	if err != nil {
+		robotsTxtLogger.WithError(err).Warning("Could not fetch robots.txt, allowing the request")
+		return &robotsTxt{}, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return &robotsTxt{}, true
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		robotsTxtLogger.WithField("statusCode", resp.StatusCode).Warning("Received an unexpected status code while fetching robots.txt, allowing the request")
+		return &robotsTxt{}, false
+	}
+
+	contents, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxRobotsTxtBytes))
+	if err != nil {
+		robotsTxtLogger.WithError(err).Warning("Could not read robots.txt, allowing the request")
+		return &robotsTxt{}, false
+	}
+
+	return parseRobotsTxt(string(contents), c.options.RobotsUserAgent), true
+}
+
+// getRetryDelay returns an exponential backoff with equal jitter, so that concurrent requests that failed together don't all retry at the same moment
+func (c *Crawler) getRetryDelay(attempt int) time.Duration {
+	delay := c.options.MinRetryDelay << uint(attempt)
+	if delay <= 0 || delay > c.options.MaxRetryDelay {
+		delay = c.options.MaxRetryDelay
+	}
+
+	halfDelay := delay / 2
+	if halfDelay <= 0 {
+		return delay
+	}
+	return halfDelay + time.Duration(rand.Int63n(int64(halfDelay)+1))
+}
+
+func isRetryable(request *http.Request, resp *http.Response, err error) bool {
+	if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+		return false
+	}
+
+	if err != nil {
+		return request.Context().Err() == nil && err != ErrDisallowedByRobotsTxt
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented)
+}
+
+// ParseRetryAfter reads a Retry-After header, which is either a number of seconds or an HTTP date
+func ParseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if retryAt, err := http.ParseTime(value); err == nil {
+		retryAfter := time.Until(retryAt)
+		if retryAfter < 0 {
+			retryAfter = 0
+		}
+		return retryAfter, true
+	}
+
+	return 0, false
+}
+
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(body, maxBytesToDrain))
+	body.Close()
+}
+
+func sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
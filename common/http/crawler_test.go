@@ -0,0 +1,249 @@
+package helpers_test
+
+import (
+	httpHelpers "atgatt-backend/common/http"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+type recordingHandler struct {
+	mutex      sync.Mutex
+	responses  []func(w http.ResponseWriter)
+	robotsTxt  string
+	bodies     []string
+	userAgents []string
+}
+
+func (h *recordingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mutex.Lock()
+	h.userAgents = append(h.userAgents, r.UserAgent())
+	h.mutex.Unlock()
+
+	if r.URL.Path == "/robots.txt" {
+		if h.robotsTxt == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, h.robotsTxt)
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	body, _ := ioutil.ReadAll(r.Body)
+	h.bodies = append(h.bodies, string(body))
+	if len(h.responses) == 0 {
+		fmt.Fprint(w, "ok")
+		return
+	}
+
+	respond := h.responses[0]
+	h.responses = h.responses[1:]
+	respond(w)
+}
+
+func respondWithStatus(statusCode int, headers map[string]string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for key, value := range headers {
+			w.Header().Set(key, value)
+		}
+		w.WriteHeader(statusCode)
+	}
+}
+
+func newTestCrawler() *httpHelpers.Crawler {
+	options := httpHelpers.DefaultCrawlerOptions()
+	options.DefaultHostLimit = httpHelpers.HostLimit{}
+	options.MinRetryDelay = time.Millisecond
+	options.MaxRetryDelay = 5 * time.Millisecond
+	return httpHelpers.NewCrawler(options)
+}
+
+func getHost(server *httptest.Server) string {
+	serverURL, _ := url.Parse(server.URL)
+	return serverURL.Host
+}
+
+func Test_Crawler_should_retry_server_errors_and_resend_the_request_body(t *testing.T) {
+	RegisterTestingT(t)
+	handler := &recordingHandler{responses: []func(w http.ResponseWriter){
+		respondWithStatus(http.StatusServiceUnavailable, nil),
+		respondWithStatus(http.StatusTooManyRequests, nil),
+	}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	crawler := newTestCrawler()
+	request, _ := http.NewRequest(http.MethodPost, server.URL+"/query", strings.NewReader("the body"))
+	resp, err := crawler.Do(request)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	Expect(handler.bodies).To(Equal([]string{"the body", "the body", "the body"}))
+
+	metrics := crawler.GetMetrics()[getHost(server)]
+	Expect(metrics.Retries).To(Equal(2))
+	Expect(metrics.ResponsesByStatusCode).To(Equal(map[int]int{http.StatusNotFound: 1, http.StatusServiceUnavailable: 1, http.StatusTooManyRequests: 1, http.StatusOK: 1}))
+}
+
+func Test_Crawler_should_identify_itself_with_the_same_user_agent_that_it_matches_robots_txt_against(t *testing.T) {
+	RegisterTestingT(t)
+	handler := &recordingHandler{responses: []func(w http.ResponseWriter){
+		respondWithStatus(http.StatusServiceUnavailable, nil),
+	}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/products", nil)
+	request.Header.Set("User-Agent", "Mozilla/5.0")
+	resp, err := newTestCrawler().Do(request)
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	Expect(handler.userAgents).To(Equal([]string{httpHelpers.CrawlerUserAgent, httpHelpers.CrawlerUserAgent, httpHelpers.CrawlerUserAgent}))
+	Expect(strings.HasPrefix(httpHelpers.CrawlerUserAgent, httpHelpers.DefaultCrawlerOptions().RobotsUserAgent+"/")).To(BeTrue())
+}
+
+func Test_Crawler_should_give_up_after_the_max_retries_and_never_retry_client_errors(t *testing.T) {
+	RegisterTestingT(t)
+	handler := &recordingHandler{responses: []func(w http.ResponseWriter){
+		respondWithStatus(http.StatusInternalServerError, nil),
+		respondWithStatus(http.StatusInternalServerError, nil),
+		respondWithStatus(http.StatusInternalServerError, nil),
+		respondWithStatus(http.StatusInternalServerError, nil),
+		respondWithStatus(http.StatusBadRequest, nil),
+	}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	crawler := newTestCrawler()
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/broken", nil)
+	resp, err := crawler.Do(request)
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+
+	request, _ = http.NewRequest(http.MethodGet, server.URL+"/bad", nil)
+	resp, err = crawler.Do(request)
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+	Expect(crawler.GetMetrics()[getHost(server)].Retries).To(Equal(3))
+}
+
+func Test_Crawler_should_wait_for_retry_after_unless_it_is_too_long(t *testing.T) {
+	RegisterTestingT(t)
+	handler := &recordingHandler{responses: []func(w http.ResponseWriter){
+		respondWithStatus(http.StatusTooManyRequests, map[string]string{"Retry-After": "1"}),
+		respondWithStatus(http.StatusTooManyRequests, map[string]string{"Retry-After": "3600"}),
+	}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	crawler := newTestCrawler()
+	startTime := time.Now()
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/slow-down", nil)
+	resp, err := crawler.Do(request)
+
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests))
+	Expect(resp.Header.Get("Retry-After")).To(Equal("3600"))
+	Expect(time.Since(startTime)).To(BeNumerically(">=", time.Second))
+	Expect(crawler.GetMetrics()[getHost(server)].Retries).To(Equal(1))
+}
+
+func Test_Crawler_should_rate_limit_requests_to_each_host(t *testing.T) {
+	RegisterTestingT(t)
+	server := httptest.NewServer(&recordingHandler{})
+	defer server.Close()
+
+	options := httpHelpers.DefaultCrawlerOptions()
+	options.HostLimits = map[string]httpHelpers.HostLimit{"127.0.0.1": {RequestsPerSecond: 20, Burst: 1}}
+	crawler := httpHelpers.NewCrawler(options)
+
+	startTime := time.Now()
+	for i := 0; i < 4; i++ {
+		request, _ := http.NewRequest(http.MethodGet, server.URL+"/page", nil)
+		resp, err := crawler.Do(request)
+		Expect(err).To(BeNil())
+		resp.Body.Close()
+	}
+
+	// the robots.txt request and the 4 page requests share the bucket, so at least 4 of them had to wait 50ms
+	Expect(time.Since(startTime)).To(BeNumerically(">=", 200*time.Millisecond))
+	Expect(crawler.GetMetrics()[getHost(server)].TotalThrottledMilliseconds).To(BeNumerically(">", 0))
+}
+
+func Test_Crawler_should_honor_robots_txt(t *testing.T) {
+	RegisterTestingT(t)
+	handler := &recordingHandler{robotsTxt: `
+# comments are ignored
+User-agent: *
+Disallow: /
+
+User-agent: googlebot
+User-agent: atgatt
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+Disallow: /search?
+`}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	crawler := newTestCrawler()
+	isAllowed := func(path string) bool {
+		request, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		resp, err := crawler.Do(request)
+		if err == httpHelpers.ErrDisallowedByRobotsTxt {
+			return false
+		}
+		Expect(err).To(BeNil())
+		resp.Body.Close()
+		return true
+	}
+
+	Expect(isAllowed("/products")).To(BeTrue())
+	Expect(isAllowed("/private/secret")).To(BeFalse())
+	Expect(isAllowed("/private/public/page")).To(BeTrue())
+	Expect(isAllowed("/files/manual.pdf")).To(BeFalse())
+	Expect(isAllowed("/files/manual.pdf.html")).To(BeTrue())
+	Expect(isAllowed("/search?q=helmet")).To(BeFalse())
+	Expect(isAllowed("/search")).To(BeTrue())
+
+	metrics := crawler.GetMetrics()[getHost(server)]
+	Expect(metrics.RobotsTxtDisallowed).To(Equal(3))
+	Expect(metrics.ResponsesByStatusCode[http.StatusOK]).To(Equal(5)) // robots.txt is only fetched once
+
+	options := httpHelpers.DefaultCrawlerOptions()
+	options.IgnoreRobotsTxt = true
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/private/secret", nil)
+	resp, err := httpHelpers.NewCrawler(options).Do(request)
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+}
+
+func Test_ParseHostLimits_should_parse_a_list_of_hosts_and_limits(t *testing.T) {
+	RegisterTestingT(t)
+
+	hostLimits, err := httpHelpers.ParseHostLimits("www.RevZilla.com=2:4, ads.api.cj.com=0.5")
+	Expect(err).To(BeNil())
+	Expect(hostLimits).To(Equal(map[string]httpHelpers.HostLimit{
+		"www.revzilla.com": {RequestsPerSecond: 2, Burst: 4},
+		"ads.api.cj.com":   {RequestsPerSecond: 0.5, Burst: 1},
+	}))
+
+	_, err = httpHelpers.ParseHostLimits("www.revzilla.com")
+	Expect(err).ToNot(BeNil())
+	_, err = httpHelpers.ParseHostLimits("www.revzilla.com=fast")
+	Expect(err).ToNot(BeNil())
+	_, err = httpHelpers.ParseHostLimits("www.revzilla.com=2:0")
+	Expect(err).ToNot(BeNil())
+}
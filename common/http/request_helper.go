@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
@@ -9,14 +10,13 @@ import (
 )
 
 // GetContentsAtURL makes a request to url and returns the response content as a string
-func GetContentsAtURL(url string) (string, error) {
-	request, err := http.NewRequest(http.MethodGet, url, nil)
+func GetContentsAtURL(ctx context.Context, url string) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return "", err
 	}
 
-	request.Header.Set("User-Agent", ChromeUserAgent)
-	resp, err := http.DefaultClient.Do(request)
+	resp, err := DefaultCrawler.Do(request)
 	if err != nil {
 		return "", err
 	}
@@ -62,8 +62,14 @@ func MakeJSONGETRequest(url string, response interface{}) (*http.Response, error
 }
 
 // MakeFormPOSTRequest makes a request to the given url with a supplied set of urlencoded form values and returns the response body as a string.
-func MakeFormPOSTRequest(url string, formValues url.Values) (string, error) {
-	resp, postErr := http.PostForm(url, formValues)
+func MakeFormPOSTRequest(ctx context.Context, url string, formValues url.Values) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(formValues.Encode()))
+	if err != nil {
+		return "", err
+	}
+
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, postErr := DefaultCrawler.Do(request)
 	if postErr != nil {
 		return "", postErr
 	}
@@ -0,0 +1,133 @@
+package helpers
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsTxt contains the rules from a host's robots.txt that apply to our user agent
+type robotsTxt struct {
+	rules      []*robotsTxtRule
+	crawlDelay time.Duration
+}
+
+type robotsTxtRule struct {
+	isAllowed bool
+	pattern   string
+	regexp    *regexp.Regexp
+}
+
+// robotsTxtGroup contains the rules listed under one or more User-agent lines
+type robotsTxtGroup struct {
+	userAgents []string
+	rules      []*robotsTxtRule
+	crawlDelay time.Duration
+}
+
+// parseRobotsTxt returns the rules from the group that most specifically matches the given user agent, falling back to the rules for every user agent (*)
+func parseRobotsTxt(contents string, userAgent string) *robotsTxt {
+	groups := []*robotsTxtGroup{}
+	var currentGroup *robotsTxtGroup
+	lastLineWasUserAgent := false
+
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if commentIndex := strings.Index(line, "#"); commentIndex >= 0 {
+			line = line[:commentIndex]
+		}
+
+		separatorIndex := strings.Index(line, ":")
+		if separatorIndex < 0 {
+			continue
+		}
+
+		field := strings.ToLower(strings.TrimSpace(line[:separatorIndex]))
+		value := strings.TrimSpace(line[separatorIndex+1:])
+		if field == "user-agent" {
+			if currentGroup == nil || !lastLineWasUserAgent {
+				currentGroup = &robotsTxtGroup{}
+				groups = append(groups, currentGroup)
+			}
+			currentGroup.userAgents = append(currentGroup.userAgents, strings.ToLower(value))
+			lastLineWasUserAgent = true
+			continue
+		}
+
+		lastLineWasUserAgent = false
+		if currentGroup == nil {
+			continue
+		}
+
+		switch field {
+		case "allow", "disallow":
+			// an empty Disallow allows everything, so it doesn't need a rule
+			if value != "" {
+				currentGroup.rules = append(currentGroup.rules, newRobotsTxtRule(field == "allow", value))
+			}
+		case "crawl-delay":
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				currentGroup.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	matchingGroup := findRobotsTxtGroup(groups, strings.ToLower(userAgent))
+	if matchingGroup == nil {
+		return &robotsTxt{}
+	}
+
+	return &robotsTxt{rules: matchingGroup.rules, crawlDelay: matchingGroup.crawlDelay}
+}
+
+func findRobotsTxtGroup(groups []*robotsTxtGroup, userAgent string) *robotsTxtGroup {
+	var bestGroup *robotsTxtGroup
+	bestMatchLength := -1
+	for _, group := range groups {
+		for _, groupUserAgent := range group.userAgents {
+			matchLength := -1
+			if groupUserAgent == "*" {
+				matchLength = 0
+			} else if userAgent != "" && strings.Contains(userAgent, groupUserAgent) {
+				matchLength = len(groupUserAgent)
+			}
+
+			if matchLength > bestMatchLength {
+				bestGroup = group
+				bestMatchLength = matchLength
+			}
+		}
+	}
+	return bestGroup
+}
+
+// newRobotsTxtRule converts a robots.txt path pattern into a regexp, where * matches any characters and a trailing $ anchors the pattern to the end of the path
+func newRobotsTxtRule(isAllowed bool, pattern string) *robotsTxtRule {
+	isAnchored := strings.HasSuffix(pattern, "$")
+	expression := regexp.QuoteMeta(strings.TrimSuffix(pattern, "$"))
+	expression = "^" + strings.Replace(expression, `\*`, ".*", -1)
+	if isAnchored {
+		expression += "$"
+	}
+
+	return &robotsTxtRule{isAllowed: isAllowed, pattern: pattern, regexp: regexp.MustCompile(expression)}
+}
+
+// isAllowed returns true if the given path (including its query string) can be crawled. The longest matching rule wins, and Allow wins ties.
+func (r *robotsTxt) isAllowed(path string) bool {
+	var bestRule *robotsTxtRule
+	for _, rule := range r.rules {
+		if !rule.regexp.MatchString(path) {
+			continue
+		}
+
+		if bestRule == nil || len(rule.pattern) > len(bestRule.pattern) || (len(rule.pattern) == len(bestRule.pattern) && rule.isAllowed) {
+			bestRule = rule
+		}
+	}
+
+	return bestRule == nil || bestRule.isAllowed
+}
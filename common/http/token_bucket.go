@@ -0,0 +1,143 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostLimit configures the token bucket used to rate limit requests to a single host. Up to Burst requests can be sent at once, after which requests are sent at RequestsPerSecond. A RequestsPerSecond of zero or less disables rate limiting for the host.
+type HostLimit struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+// tokenBucket rate limits the requests sent to a single host. It is safe to use from multiple goroutines at once.
+type tokenBucket struct {
+	mutex        sync.Mutex
+	limit        HostLimit
+	tokens       float64
+	lastRefill   time.Time
+	blockedUntil time.Time
+}
+
+func newTokenBucket(limit HostLimit) *tokenBucket {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+
+	return &tokenBucket{limit: limit, tokens: float64(limit.Burst), lastRefill: time.Now()}
+}
+
+// wait blocks until a request can be sent, or returns an error if the context is done first. It returns how long it waited.
+func (b *tokenBucket) wait(ctx context.Context) (time.Duration, error) {
+	startTime := time.Now()
+	for {
+		delay := b.reserve()
+		if delay <= 0 {
+			return time.Since(startTime), nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return time.Since(startTime), ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token and returns zero if one is available, or returns how long to wait before trying again otherwise
+func (b *tokenBucket) reserve() time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	if now.Before(b.blockedUntil) {
+		return b.blockedUntil.Sub(now)
+	}
+
+	if b.limit.RequestsPerSecond <= 0 {
+		return 0
+	}
+
+	b.tokens += now.Sub(b.lastRefill).Seconds() * b.limit.RequestsPerSecond
+	if b.tokens > float64(b.limit.Burst) {
+		b.tokens = float64(b.limit.Burst)
+	}
+	b.lastRefill = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / b.limit.RequestsPerSecond * float64(time.Second))
+}
+
+// blockFor stops any requests from being sent until the given duration has passed, which is used when a host asks us to back off
+func (b *tokenBucket) blockFor(duration time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	blockedUntil := time.Now().Add(duration)
+	if blockedUntil.After(b.blockedUntil) {
+		b.blockedUntil = blockedUntil
+	}
+}
+
+// slowDownTo lowers the rate of the bucket to at most one request per the given interval, which is used to honor a robots.txt Crawl-delay
+func (b *tokenBucket) slowDownTo(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	requestsPerSecond := 1 / interval.Seconds()
+	if b.limit.RequestsPerSecond <= 0 || requestsPerSecond < b.limit.RequestsPerSecond {
+		b.limit.RequestsPerSecond = requestsPerSecond
+		b.limit.Burst = 1
+		if b.tokens > 1 {
+			b.tokens = 1
+		}
+	}
+}
+
+// ParseHostLimits parses a comma separated list of host limits in the form host=requestsPerSecond:burst, i.e. www.revzilla.com=2:4,ads.api.cj.com=0.33:1. The burst is optional and defaults to 1.
+func ParseHostLimits(value string) (map[string]HostLimit, error) {
+	hostLimits := map[string]HostLimit{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		hostAndLimit := strings.SplitN(entry, "=", 2)
+		if len(hostAndLimit) != 2 || strings.TrimSpace(hostAndLimit[0]) == "" {
+			return nil, fmt.Errorf("The host limit %s must be in the form host=requestsPerSecond:burst", entry)
+		}
+
+		limitParts := strings.SplitN(hostAndLimit[1], ":", 2)
+		requestsPerSecond, err := strconv.ParseFloat(strings.TrimSpace(limitParts[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("The host limit %s has an invalid number of requests per second", entry)
+		}
+
+		burst := 1
+		if len(limitParts) == 2 {
+			burst, err = strconv.Atoi(strings.TrimSpace(limitParts[1]))
+			if err != nil || burst < 1 {
+				return nil, fmt.Errorf("The host limit %s has an invalid burst", entry)
+			}
+		}
+
+		hostLimits[strings.ToLower(strings.TrimSpace(hostAndLimit[0]))] = HostLimit{RequestsPerSecond: requestsPerSecond, Burst: burst}
+	}
+	return hostLimits, nil
+}
@@ -1,6 +1,7 @@
 package s3
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -9,12 +10,16 @@ import (
 	httpHelpers "atgatt-backend/common/http"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
-	"github.com/cenkalti/backoff"
 	"github.com/sirupsen/logrus"
 )
 
+// GetImageKeyForURL returns the S3 key that CopyImageToS3FromURL uploads the image at the given URL to
+func GetImageKeyForURL(sourceURL string) string {
+	return fmt.Sprintf("img/products/%s", path.Base(sourceURL))
+}
+
 // CopyImageToS3FromURL takes an image at some original location and re-uploads it to S3 in the given bucket
-func CopyImageToS3FromURL(productLogger *logrus.Entry, uploader s3manageriface.UploaderAPI, sourceURL string, destBucket string) (string, error) {
+func CopyImageToS3FromURL(ctx context.Context, productLogger *logrus.Entry, uploader s3manageriface.UploaderAPI, sourceURL string, destBucket string) (string, error) {
 	if sourceURL == "" {
 		return "", errors.New("url cannot be empty")
 	}
@@ -23,31 +28,28 @@ func CopyImageToS3FromURL(productLogger *logrus.Entry, uploader s3manageriface.U
 		return "", errors.New("uploader cannot be nil")
 	}
 
-	var resp *http.Response
-	err := backoff.Retry(func() error {
-		request, err := http.NewRequest(http.MethodGet, sourceURL, nil)
-		if err != nil {
-			return err
-		}
-
-		request.Header.Set("User-Agent", httpHelpers.ChromeUserAgent)
-		resp, err = http.DefaultClient.Do(request)
-		if err != nil || (resp != nil && resp.StatusCode > 299) {
-			productLogger.WithField("originalImageURL", sourceURL).WithError(err).Warning("Could not download the product image from the image URL specified, retrying...")
-			return err
-		}
-		return nil
-	}, backoff.NewExponentialBackOff())
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL, nil)
+	if err != nil {
+		return "", err
+	}
 
+	// the crawler retries temporary failures with backoff, so any error here is final
+	resp, err := httpHelpers.DefaultCrawler.Do(request)
 	if err != nil {
-		productLogger.WithField("originalImageURL", sourceURL).WithError(err).Error("Could not download the product image from the image URL specified after exhausting the exponential backoff policy")
+		productLogger.WithField("originalImageURL", sourceURL).WithError(err).Error("Could not download the product image from the image URL specified")
 		return "", err
 	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		productLogger.WithFields(logrus.Fields{"originalImageURL": sourceURL, "statusCode": resp.StatusCode}).Error("Could not download the product image from the image URL specified")
+		return "", fmt.Errorf("Received an unexpected status code while downloading the product image: %d", resp.StatusCode)
+	}
 
-	s3Key := fmt.Sprintf("img/products/%s", path.Base(sourceURL))
+	s3Key := GetImageKeyForURL(sourceURL)
 	s3Logger := productLogger.WithField("s3Key", s3Key)
 	s3Logger.Info("Uploading product image to S3")
-	s3Resp, err := uploader.Upload(&s3manager.UploadInput{
+	s3Resp, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
 		Bucket: &destBucket,
 		Key:    &s3Key,
 		Body:   resp.Body,
@@ -57,7 +59,6 @@ func CopyImageToS3FromURL(productLogger *logrus.Entry, uploader s3manageriface.U
 	}
 
 	s3Logger.WithField("s3UploadLocation", s3Resp.Location).Info("Finished uploading product image to S3")
-	resp.Body.Close()
 
 	return s3Key, nil
 }
@@ -0,0 +1,175 @@
+package testing
+
+import (
+	"atgatt-backend/persistence/entities"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fakeCJVariableDeclarations are the variables that a shoppingProducts query must declare, with the types that CJ's schema gives their arguments
+var fakeCJVariableDeclarations = []string{"$keywords: [String!]", "$offset: Int", "$limit: Int"}
+
+type fakeCJGraphQLRequest struct {
+	Query     string `json:"query"`
+	Variables struct {
+		Keywords []string `json:"keywords"`
+		Offset   *int     `json:"offset"`
+		Limit    *int     `json:"limit"`
+	} `json:"variables"`
+}
+
+// FakeCJRequest is a shoppingProducts search that a FakeCJServer answered
+type FakeCJRequest struct {
+	Keywords []string
+	Offset   int
+	Limit    int
+}
+
+// FakeCJServer is a local stand-in for CJ's GraphQL API, so that the CJ client and the jobs that use it can be tested without a real CJ API key. It answers shoppingProducts searches from a fixed list of products, where a product matches when its title or product type contains every keyword, and pages through them with the offset and limit variables of the GraphQL request. Requests without the expected API key get a 401, and RateLimitNextRequests makes it respond with 429s. Callers must close the returned server.
+type FakeCJServer struct {
+	*httptest.Server
+	APIKey   string
+	Products []entities.CJProduct
+
+	mutex                  sync.Mutex
+	requests               []*FakeCJRequest
+	numRateLimitedRequests int
+	retryAfterSeconds      int
+}
+
+// StartFakeCJServer starts a FakeCJServer that accepts the given API key and searches the given products
+func StartFakeCJServer(apiKey string, products []entities.CJProduct) *FakeCJServer {
+	server := &FakeCJServer{APIKey: apiKey, Products: products, requests: []*FakeCJRequest{}}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	return server
+}
+
+// RateLimitNextRequests makes the server respond to the next numRequests requests with a 429 that asks the client to wait retryAfterSeconds
+func (s *FakeCJServer) RateLimitNextRequests(numRequests int, retryAfterSeconds int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.numRateLimitedRequests = numRequests
+	s.retryAfterSeconds = retryAfterSeconds
+}
+
+// GetRequests returns every search that the server has answered
+func (s *FakeCJServer) GetRequests() []*FakeCJRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*FakeCJRequest{}, s.requests...)
+}
+
+func (s *FakeCJServer) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+s.APIKey {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if s.shouldRateLimit() {
+		w.Header().Set("Retry-After", strconv.Itoa(s.retryAfterSeconds))
+		http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		return
+	}
+
+	requestBodyBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	request, err := parseFakeCJRequest(requestBodyBytes)
+	if err != nil {
+		writeFakeCJResponse(w, map[string]interface{}{
+			"data":   nil,
+			"errors": []*entities.CJResponseError{{Message: err.Error()}},
+		})
+		return
+	}
+
+	s.mutex.Lock()
+	s.requests = append(s.requests, request)
+	s.mutex.Unlock()
+
+	matchingProducts := s.getMatchingProducts(request.Keywords)
+	page := []entities.CJProduct{}
+	if request.Offset < len(matchingProducts) {
+		end := request.Offset + request.Limit
+		if end > len(matchingProducts) {
+			end = len(matchingProducts)
+		}
+		page = matchingProducts[request.Offset:end]
+	}
+
+	response := &entities.CJProductsResponseWrapper{}
+	response.Data.ShoppingProducts = entities.CJProductsWrapper{TotalCount: len(matchingProducts), Count: len(page), ResultList: page}
+	writeFakeCJResponse(w, response)
+}
+
+func (s *FakeCJServer) shouldRateLimit() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.numRateLimitedRequests <= 0 {
+		return false
+	}
+	s.numRateLimitedRequests--
+	return true
+}
+
+func (s *FakeCJServer) getMatchingProducts(keywords []string) []entities.CJProduct {
+	matchingProducts := []entities.CJProduct{}
+	for _, product := range s.Products {
+		searchableText := strings.ToLower(product.Name + " " + strings.Join(product.Categories, " "))
+		isMatch := true
+		for _, keyword := range keywords {
+			if !strings.Contains(searchableText, strings.ToLower(keyword)) {
+				isMatch = false
+				break
+			}
+		}
+
+		if isMatch {
+			matchingProducts = append(matchingProducts, product)
+		}
+	}
+	return matchingProducts
+}
+
+func parseFakeCJRequest(requestBodyBytes []byte) (*FakeCJRequest, error) {
+	graphQLRequest := &fakeCJGraphQLRequest{}
+	if err := json.Unmarshal(requestBodyBytes, graphQLRequest); err != nil {
+		return nil, fmt.Errorf("The request body is not a GraphQL request: %s", err.Error())
+	}
+
+	if !strings.Contains(graphQLRequest.Query, "shoppingProducts(") {
+		return nil, fmt.Errorf("The query does not search shoppingProducts")
+	}
+
+	for _, variableDeclaration := range fakeCJVariableDeclarations {
+		if !strings.Contains(graphQLRequest.Query, variableDeclaration) {
+			return nil, fmt.Errorf("The query does not declare %s", variableDeclaration)
+		}
+	}
+
+	variables := graphQLRequest.Variables
+	if variables.Keywords == nil || variables.Offset == nil || variables.Limit == nil {
+		return nil, fmt.Errorf("The request must have keywords, an offset and a limit")
+	}
+	return &FakeCJRequest{Keywords: variables.Keywords, Offset: *variables.Offset, Limit: *variables.Limit}, nil
+}
+
+func writeFakeCJResponse(w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
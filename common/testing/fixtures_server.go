@@ -0,0 +1,14 @@
+package testing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+)
+
+// StartFixturesServer starts a local stand-in for a third party website that serves the recorded responses in fixturesDirectory, where each request path maps to a file path and paths ending in / map to the index.html in that directory. Every method is served the same file so that recorded form POSTs can be replayed as well. Callers must close the returned server.
+func StartFixturesServer(fixturesDirectory string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join(fixturesDirectory, filepath.FromSlash(r.URL.Path)))
+	}))
+}
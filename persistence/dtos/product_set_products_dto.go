@@ -8,10 +8,12 @@ import (
 
 // ProductSetProductsDTO represents a collection of products associated with a product set
 type ProductSetProductsDTO struct {
-	UUID          uuid.UUID
-	HelmetProduct *entities.Product
-	JacketProduct *entities.Product
-	PantsProduct  *entities.Product
-	BootsProduct  *entities.Product
-	GlovesProduct *entities.Product
+	UUID             uuid.UUID
+	HelmetProduct    *entities.Product
+	JacketProduct    *entities.Product
+	PantsProduct     *entities.Product
+	BootsProduct     *entities.Product
+	GlovesProduct    *entities.Product
+	AirbagProduct    *entities.Product
+	ProtectorProduct *entities.Product
 }
@@ -0,0 +1,82 @@
+package entities
+
+import (
+	"regexp"
+	"strings"
+)
+
+// AirbagCertification represents the certification and coverage of a standalone airbag vest
+type AirbagCertification struct {
+	IsCertified   bool `json:"isCertified"` // if the airbag is certified to EN 1621-4
+	IsLevel2      bool `json:"isLevel2"`
+	IsElectronic  bool `json:"isElectronic"` // if the airbag is triggered by sensors instead of a lanyard tethered to the motorcycle
+	ProtectsBack  bool `json:"protectsBack"`
+	ProtectsChest bool `json:"protectsChest"`
+	ProtectsNeck  bool `json:"protectsNeck"`
+}
+
+var en16214StandardRegexp = regexp.MustCompile(`1621\s*[-./]?\s*4\b`)
+
+// GetScore returns the component of an overall safety score associated with this airbag
+func (a AirbagCertification) GetScore() float64 {
+	totalScore := float64(0)
+
+	// An uncertified airbag may never have been tested to inflate fast enough, so it gets no base credit
+	if a.IsCertified {
+		totalScore += 0.4
+		if a.IsLevel2 {
+			totalScore += 0.1
+		}
+	}
+
+	// Electronic airbags can deploy in crashes where the rider doesn't separate from the motorcycle i.e. lowsides
+	if a.IsElectronic {
+		totalScore += 0.2
+	}
+
+	if a.ProtectsBack {
+		totalScore += 0.1
+	}
+
+	if a.ProtectsChest {
+		totalScore += 0.1
+	}
+
+	if a.ProtectsNeck {
+		totalScore += 0.1
+	}
+
+	return totalScore
+}
+
+// updateByDescriptionPart updates this airbag using the keywords in the given description part and returns true if an update occurred
+func (a *AirbagCertification) updateByDescriptionPart(part string) bool {
+	lowerPart := strings.ToLower(part)
+	original := *a
+
+	if en16214StandardRegexp.MatchString(part) {
+		a.IsCertified = true
+		if strings.Contains(lowerPart, "level 2") || strings.Contains(lowerPart, "level ii") {
+			a.IsLevel2 = true
+		}
+	}
+
+	if strings.Contains(lowerPart, "electronic") || strings.Contains(lowerPart, "sensor") || strings.Contains(lowerPart, "algorithm") ||
+		strings.Contains(lowerPart, "tech-air") || strings.Contains(lowerPart, "tech air") || strings.Contains(lowerPart, "d-air") || strings.Contains(lowerPart, "in&motion") {
+		a.IsElectronic = true
+	}
+
+	if strings.Contains(lowerPart, "back") || strings.Contains(lowerPart, "spine") {
+		a.ProtectsBack = true
+	}
+
+	if strings.Contains(lowerPart, "chest") || strings.Contains(lowerPart, "thorax") {
+		a.ProtectsChest = true
+	}
+
+	if strings.Contains(lowerPart, "neck") || strings.Contains(lowerPart, "cervical") {
+		a.ProtectsNeck = true
+	}
+
+	return *a != original
+}
@@ -1,10 +1,17 @@
 package entities
 
+import (
+	"fmt"
+	"regexp"
+)
+
 // CEImpactZone represents a zone of a particular product (or an entire product) that has a level 1 or 2 CE certification
 type CEImpactZone struct {
-	IsLevel2   bool `json:"isLevel2"`
-	IsApproved bool `json:"isApproved"`
-	IsEmpty    bool `json:"isEmpty"` // if this product can fit CE-certified/approved armor in this zone but doesn't include one, it's an "empty" zone
+	IsLevel2     bool   `json:"isLevel2"`
+	IsApproved   bool   `json:"isApproved"`
+	IsEmpty      bool   `json:"isEmpty"`      // if this product can fit CE-certified/approved armor in this zone but doesn't include one, it's an "empty" zone
+	Standard     string `json:"standard"`     // the EN 1621 standard that the armor in this zone was certified to, if known
+	IsMismatched bool   `json:"isMismatched"` // if the armor in this zone was certified to a standard meant for a different zone i.e. limb armor in a back protector slot
 }
 
 // GetScore returns the component of an overall safety score associated with this zone
@@ -14,6 +21,11 @@ func (c CEImpactZone) GetScore() float64 {
 		return 0.50
 	}
 
+	// Armor certified for a different part of the body hasn't been tested against the impacts this zone takes, so only give it the same credit as an empty slot
+	if c.IsMismatched {
+		return 0.50
+	}
+
 	// start off with 75% for CE level 1 and no approval
 	totalScore := 0.75
 
@@ -34,3 +46,46 @@ func (c CEImpactZone) GetScore() float64 {
 func (c CEImpactZone) IsSaferThan(otherZone *CEImpactZone) bool {
 	return otherZone == nil || (c.GetScore() > otherZone.GetScore())
 }
+
+// Matches the various ways the part number is written i.e. "EN 1621-2", "EN1621.1", "EN 1621/3:2014"
+var en1621StandardRegexp = regexp.MustCompile(`1621\s*[-./]?\s*([1-3])\b`)
+
+// ParseEN1621Standards returns every EN 1621 standard mentioned in the given description part, in the order they were mentioned
+func ParseEN1621Standards(part string) []string {
+	standards := []string{}
+	for _, matches := range en1621StandardRegexp.FindAllStringSubmatch(part, -1) {
+		standard := fmt.Sprintf("EN 1621-%s", matches[1])
+		if !containsString(standards, standard) {
+			standards = append(standards, standard)
+		}
+	}
+	return standards
+}
+
+// forSlot returns a copy of this zone for a slot that expects armor certified to one of the expectedStandards, flagging it as mismatched if none of the mentioned standards are expected
+func (c CEImpactZone) forSlot(mentionedStandards []string, expectedStandards ...string) *CEImpactZone {
+	zone := c
+	if len(mentionedStandards) == 0 || zone.IsEmpty {
+		return &zone
+	}
+
+	for _, standard := range mentionedStandards {
+		if containsString(expectedStandards, standard) {
+			zone.Standard = standard
+			return &zone
+		}
+	}
+
+	zone.Standard = mentionedStandards[0]
+	zone.IsMismatched = true
+	return &zone
+}
+
+func containsString(values []string, value string) bool {
+	for _, currValue := range values {
+		if currValue == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,225 @@
+package entities
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChangeReport describes the changes that a dry run of a job would have made to the database and S3. It is safe to record changes from multiple goroutines at once.
+type ChangeReport struct {
+	UUID                 uuid.UUID            `json:"uuid"`
+	JobName              string               `json:"jobName"`
+	StartedAtUTC         time.Time            `json:"startedAtUTC"`
+	FinishedAtUTC        time.Time            `json:"finishedAtUTC"`
+	Error                string               `json:"error"`
+	CreatedProducts      []*ProductChange     `json:"createdProducts"`
+	UpdatedProducts      []*ProductChange     `json:"updatedProducts"`
+	DiscontinuedProducts []*ProductChange     `json:"discontinuedProducts"`
+	UploadedImages       []*ImageUploadChange `json:"uploadedImages"`
+	mutex                sync.Mutex
+}
+
+// ProductChange describes a single product that a dry run would have created, updated, or discontinued. Created products include the whole product, and updated products include a diff of every field that would have changed.
+type ProductChange struct {
+	UUID         uuid.UUID    `json:"uuid"`
+	ExternalID   string       `json:"externalID"`
+	Type         string       `json:"type"`
+	Manufacturer string       `json:"manufacturer"`
+	Model        string       `json:"model"`
+	Product      *Product     `json:"product,omitempty"`
+	Diffs        []*FieldDiff `json:"diffs,omitempty"`
+}
+
+// FieldDiff describes the value of a single product field before and after a change. The field is the dotted path to the value in the product's JSON document i.e. helmetCertifications.ECEVersion
+type FieldDiff struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// ImageUploadChange describes a product image that a dry run would have copied to S3
+type ImageUploadChange struct {
+	ProductUUID      uuid.UUID `json:"productUUID"`
+	Manufacturer     string    `json:"manufacturer"`
+	Model            string    `json:"model"`
+	OriginalImageURL string    `json:"originalImageURL"`
+	Bucket           string    `json:"bucket"`
+	Key              string    `json:"key"`
+}
+
+// NewChangeReport returns an empty change report for a dry run of the given job that is starting now
+func NewChangeReport(jobName string) *ChangeReport {
+	return &ChangeReport{
+		UUID:                 uuid.New(),
+		JobName:              jobName,
+		StartedAtUTC:         time.Now().UTC(),
+		CreatedProducts:      []*ProductChange{},
+		UpdatedProducts:      []*ProductChange{},
+		DiscontinuedProducts: []*ProductChange{},
+		UploadedImages:       []*ImageUploadChange{},
+	}
+}
+
+// Finish records when the dry run finished along with the error that it failed with, if any
+func (r *ChangeReport) Finish(err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.FinishedAtUTC = time.Now().UTC()
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// AddCreatedProduct records a product that would have been created
+func (r *ChangeReport) AddCreatedProduct(product *Product) {
+	change := newProductChange(product)
+	change.Product = product
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.CreatedProducts = append(r.CreatedProducts, change)
+}
+
+// AddUpdatedProduct records a product that would have been updated along with the fields that would have changed, ignoring products where nothing would have changed. It returns true if the product was recorded.
+func (r *ChangeReport) AddUpdatedProduct(before *Product, after *Product) (bool, error) {
+	diffs, err := DiffProducts(before, after)
+	if err != nil {
+		return false, err
+	}
+
+	if len(diffs) == 0 {
+		return false, nil
+	}
+
+	change := newProductChange(after)
+	change.Diffs = diffs
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.UpdatedProducts = append(r.UpdatedProducts, change)
+	return true, nil
+}
+
+// AddDiscontinuedProduct records a product that would have been marked as discontinued
+func (r *ChangeReport) AddDiscontinuedProduct(product *Product) {
+	change := newProductChange(product)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.DiscontinuedProducts = append(r.DiscontinuedProducts, change)
+}
+
+// AddUploadedImage records a product image that would have been copied to the given S3 bucket and key
+func (r *ChangeReport) AddUploadedImage(product *Product, bucket string, key string) {
+	change := &ImageUploadChange{
+		ProductUUID:      product.UUID,
+		Manufacturer:     product.Manufacturer,
+		Model:            product.Model,
+		OriginalImageURL: product.OriginalImageURL,
+		Bucket:           bucket,
+		Key:              key,
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.UploadedImages = append(r.UploadedImages, change)
+}
+
+func newProductChange(product *Product) *ProductChange {
+	return &ProductChange{
+		UUID:         product.UUID,
+		ExternalID:   product.ExternalID,
+		Type:         product.Type,
+		Manufacturer: product.Manufacturer,
+		Model:        product.Model,
+	}
+}
+
+// volatileProductFields are the product fields that change on every sync without the product itself changing, which DiffProducts leaves out
+var volatileProductFields = map[string]bool{
+	"lastSeenAtUTC": true,
+}
+
+// DiffProducts returns every field whose value differs between the two products, ordered by field. Nested objects are compared field by field and offers are compared retailer by retailer (i.e. offers.revzilla.priceCents), while other arrays are compared as a whole. Fields that change on every sync, like when a source or retailer last listed the product, are left out.
+func DiffProducts(before *Product, after *Product) ([]*FieldDiff, error) {
+	beforeDocument, err := getProductDocument(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterDocument, err := getProductDocument(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := []*FieldDiff{}
+	diffDocuments("", beforeDocument, afterDocument, &diffs)
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Field < diffs[j].Field
+	})
+	return diffs, nil
+}
+
+func getProductDocument(product *Product) (map[string]interface{}, error) {
+	if product == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	productBytes, err := json.Marshal(product)
+	if err != nil {
+		return nil, err
+	}
+
+	document := map[string]interface{}{}
+	if err := json.Unmarshal(productBytes, &document); err != nil {
+		return nil, err
+	}
+
+	// Key the offers by retailer so that a change to one offer is diffed field by field instead of replacing the whole array
+	if offers, isArray := document["offers"].([]interface{}); isArray {
+		offersByRetailer := map[string]interface{}{}
+		for _, offer := range offers {
+			if offerObject, isObject := offer.(map[string]interface{}); isObject {
+				retailer, _ := offerObject["retailer"].(string)
+				offersByRetailer[retailer] = offerObject
+			}
+		}
+		document["offers"] = offersByRetailer
+	}
+	return document, nil
+}
+
+func diffDocuments(prefix string, before map[string]interface{}, after map[string]interface{}, diffs *[]*FieldDiff) {
+	fields := map[string]bool{}
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+
+	for field := range fields {
+		if volatileProductFields[field] {
+			continue
+		}
+
+		beforeValue := before[field]
+		afterValue := after[field]
+		beforeObject, beforeIsObject := beforeValue.(map[string]interface{})
+		afterObject, afterIsObject := afterValue.(map[string]interface{})
+		if beforeIsObject && afterIsObject {
+			diffDocuments(prefix+field+".", beforeObject, afterObject, diffs)
+			continue
+		}
+
+		if !reflect.DeepEqual(beforeValue, afterValue) {
+			*diffs = append(*diffs, &FieldDiff{Field: prefix + field, Before: beforeValue, After: afterValue})
+		}
+	}
+}
@@ -1,8 +1,14 @@
 package entities
 
-// CJProductsResponseWrapper represents a wrapper around the list of all the products returned from the Commission Junction API
+// CJProductsResponseWrapper represents a wrapper around the list of all the products returned from the Commission Junction API, along with any errors that the API reported for the query
 type CJProductsResponseWrapper struct {
 	Data struct {
 		ShoppingProducts CJProductsWrapper `json:"shoppingProducts"`
 	} `json:"data"`
+	Errors []CJResponseError `json:"errors"`
+}
+
+// CJResponseError represents an error reported by the Commission Junction API, i.e. for a query that it couldn't parse
+type CJResponseError struct {
+	Message string `json:"message"`
 }
@@ -1,6 +1,8 @@
 package entities
 
-// CJProductsWrapper represents a product returned from the Commission Junction API
+// CJProductsWrapper represents a page of products returned from the Commission Junction API, where TotalCount is the number of products that match the search across every page
 type CJProductsWrapper struct {
-	ResultList    []CJProduct `json:"resultList"`
+	TotalCount int         `json:"totalCount"`
+	Count      int         `json:"count"`
+	ResultList []CJProduct `json:"resultList"`
 }
@@ -14,3 +14,84 @@ const ProductTypeBoots = "boots"
 
 // ProductTypeGloves represents the gloves product type
 const ProductTypeGloves = "gloves"
+
+// ProductTypeAirbag represents the airbag vest product type i.e. standalone airbag systems worn over or under a jacket
+const ProductTypeAirbag = "airbag"
+
+// ProductTypeProtector represents the standalone back and/or chest protector product type
+const ProductTypeProtector = "protector"
+
+// RetailerRevzilla represents the RevZilla.com retailer
+const RetailerRevzilla = "revzilla"
+
+// SourceRevzilla represents products found on RevZilla.com's listing pages
+const SourceRevzilla = "revzilla"
+
+// SourceCJ represents products found on RevZilla.com through the CJ Affiliate product search API
+const SourceCJ = "cj"
+
+// CurrencyUSD represents the US dollar currency code
+const CurrencyUSD = "USD"
+
+// CurrencyGBP represents the pound sterling currency code, which SHARP lists its prices in
+const CurrencyGBP = "GBP"
+
+// RegionUS represents products sold in the United States
+const RegionUS = "US"
+
+// RegionUK represents products sold in the United Kingdom
+const RegionUK = "UK"
+
+// RegionEU represents products sold in the European Union
+const RegionEU = "EU"
+
+// SNELLStandardM2010 represents the SNELL M2010 motorcycle helmet standard, which was superseded by M2015
+const SNELLStandardM2010 = "M2010"
+
+// SNELLStandardM2015 represents the SNELL M2015 motorcycle helmet standard, which was superseded by M2020
+const SNELLStandardM2015 = "M2015"
+
+// SNELLStandardM2020D represents the SNELL M2020 motorcycle helmet standard for helmets sold alongside a DOT certification
+const SNELLStandardM2020D = "M2020D"
+
+// SNELLStandardM2020R represents the SNELL M2020 motorcycle helmet standard for helmets sold alongside an ECE certification
+const SNELLStandardM2020R = "M2020R"
+
+// ECEVersion2205 represents the ECE 22.05 motorcycle helmet standard, which was superseded by 22.06
+const ECEVersion2205 = "22.05"
+
+// ECEVersion2206 represents the ECE 22.06 motorcycle helmet standard, which adds rotational impact and additional impact point testing
+const ECEVersion2206 = "22.06"
+
+// FIMStandardFRHPhe01 represents the first FIM racing helmet homologation standard, which requires an ECE 22.05 certification
+const FIMStandardFRHPhe01 = "FRHPhe-01"
+
+// FIMStandardFRHPhe02 represents the second FIM racing helmet homologation standard, which requires an ECE 22.06 certification
+const FIMStandardFRHPhe02 = "FRHPhe-02"
+
+// GarmentClassAAA represents the EN 17092 class for garments with the highest abrasion, seam strength, and impact protector requirements
+const GarmentClassAAA = "AAA"
+
+// GarmentClassAA represents the EN 17092 class for touring garments with moderate abrasion and impact protector requirements
+const GarmentClassAA = "AA"
+
+// GarmentClassA represents the EN 17092 class for urban garments with the lowest abrasion and impact protector requirements
+const GarmentClassA = "A"
+
+// GarmentClassB represents the EN 17092 class for garments that meet class A abrasion requirements but don't include impact protectors
+const GarmentClassB = "B"
+
+// GarmentClassC represents the EN 17092 class for garments that only hold impact protectors in place, i.e. armored base layers
+const GarmentClassC = "C"
+
+// CEStandardEN16211 represents the EN 1621-1 standard for limb and joint impact protectors i.e. shoulder, elbow, hip, and knee armor
+const CEStandardEN16211 = "EN 1621-1"
+
+// CEStandardEN16212 represents the EN 1621-2 standard for back protectors
+const CEStandardEN16212 = "EN 1621-2"
+
+// CEStandardEN16213 represents the EN 1621-3 standard for chest protectors
+const CEStandardEN16213 = "EN 1621-3"
+
+// CEStandardEN16214 represents the EN 1621-4 standard for inflatable (airbag) protectors
+const CEStandardEN16214 = "EN 1621-4"
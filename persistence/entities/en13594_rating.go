@@ -0,0 +1,89 @@
+package entities
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// EN13594Rating represents the EN 13594 certification of a pair of motorcycle gloves, where Level is 1 or 2, or 0 if the level is unknown
+type EN13594Rating struct {
+	Level                int  `json:"level"`
+	HasKnuckleProtection bool `json:"hasKnuckleProtection"`
+	HasPalmSlider        bool `json:"hasPalmSlider"`
+}
+
+var en13594LevelKeywordRegexp = regexp.MustCompile(`(?i)level\s*([12])\b`)
+
+// The KP marking is usually printed right after the level, i.e. "EN 13594:2015 1KP"
+var en13594LevelKPRegexp = regexp.MustCompile(`\b([12])\s*KP\b`)
+var en13594LevelAfterStandardRegexp = regexp.MustCompile(`13594(?::\d{4})?\s*[-:,]?\s*([12])\b`)
+var en13594KPRegexp = regexp.MustCompile(`^13594(?::\d{4})?\s*[-:,]?\s*(?:(?i:level)\s*)?[12]?\s*KP\b`)
+
+// en13594OtherStandardRegexp finds where the EN 13594 text ends when a part goes on to mention another standard, i.e. the EN 1621 level of the knuckle armor
+var en13594OtherStandardRegexp = regexp.MustCompile(`(?i)\bEN\s*\d{4}|\b1621\b|[;.]\s`)
+
+// getEN13594Text returns the part of the given description part that starts at the EN 13594 standard and ends before the next standard it mentions, or "" if it doesn't mention EN 13594
+func getEN13594Text(part string) string {
+	start := strings.Index(part, "13594")
+	if start < 0 {
+		return ""
+	}
+
+	text := part[start:]
+	if location := en13594OtherStandardRegexp.FindStringIndex(text[len("13594"):]); location != nil {
+		text = text[:len("13594")+location[0]]
+	}
+	return text
+}
+
+// ParseEN13594Level returns the EN 13594 level mentioned in the given description part, or 0 if the part doesn't mention a level
+func ParseEN13594Level(part string) int {
+	text := getEN13594Text(part)
+	if text == "" {
+		return 0
+	}
+
+	for _, levelRegexp := range []*regexp.Regexp{en13594LevelKeywordRegexp, en13594LevelKPRegexp, en13594LevelAfterStandardRegexp} {
+		matches := levelRegexp.FindStringSubmatch(text)
+		if len(matches) >= 2 {
+			// the regexps only match a 1 or a 2, so this can't fail
+			level, _ := strconv.Atoi(matches[1])
+			return level
+		}
+	}
+	return 0
+}
+
+// HasEN13594KnuckleProtection returns true if the given description part mentions that the gloves passed the EN 13594 knuckle protection test
+func HasEN13594KnuckleProtection(part string) bool {
+	text := getEN13594Text(part)
+	return en13594KPRegexp.MatchString(text) || strings.Contains(strings.ToLower(text), "knuckle")
+}
+
+// HasPalmSlider returns true if the given description part mentions palm sliders
+func HasPalmSlider(part string) bool {
+	lowerPart := strings.ToLower(part)
+	return strings.Contains(lowerPart, "palm slider") || strings.Contains(lowerPart, "palm-slider") || strings.Contains(lowerPart, "sliders on the palm")
+}
+
+// GetScore returns the component of an overall safety score associated with this rating
+func (r EN13594Rating) GetScore() float64 {
+	totalScore := float64(0)
+	if r.Level == 1 {
+		totalScore += 0.6
+	} else if r.Level == 2 {
+		totalScore += 0.8
+	}
+
+	// KP gloves passed an additional impact test across the knuckles
+	if r.HasKnuckleProtection {
+		totalScore += 0.15
+	}
+
+	// Palm sliders reduce the chance of the hand grabbing the pavement and breaking the scaphoid bone
+	if r.HasPalmSlider {
+		totalScore += 0.05
+	}
+	return totalScore
+}
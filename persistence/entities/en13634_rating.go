@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// EN13634Rating represents the level 1 or 2 ratings a pair of motorcycle boots receives for each attribute tested by EN 13634, or 0 if an attribute was not rated
+type EN13634Rating struct {
+	Height             int `json:"height"`
+	Abrasion           int `json:"abrasion"`
+	ImpactCut          int `json:"impactCut"`
+	TransverseRigidity int `json:"transverseRigidity"`
+}
+
+const en13634MaxAttributeRating float64 = 2
+
+// Ratings are usually written in the order height/abrasion/impact cut/transverse rigidity, i.e. "EN 13634:2017 2/2/2/2", where the colon before the year is sometimes left out
+var en13634RatingRegexp = regexp.MustCompile(`13634(?:\s*:?\s*\d{4})?[^0-9]*?([0-2])\s*/\s*([0-2])\s*/\s*([0-2])\s*/\s*([0-2])`)
+
+// ParseEN13634Rating returns the EN 13634 ratings mentioned in the given description part, or nil if the part doesn't mention any ratings
+func ParseEN13634Rating(part string) *EN13634Rating {
+	matches := en13634RatingRegexp.FindStringSubmatch(part)
+	if len(matches) < 5 {
+		return nil
+	}
+
+	ratings := make([]int, 4)
+	for i := range ratings {
+		// the regexp only matches single digits, so this can't fail
+		ratings[i], _ = strconv.Atoi(matches[i+1])
+	}
+
+	return &EN13634Rating{Height: ratings[0], Abrasion: ratings[1], ImpactCut: ratings[2], TransverseRigidity: ratings[3]}
+}
+
+// GetScore returns the component of an overall safety score associated with these ratings, where each attribute is weighted equally
+func (r EN13634Rating) GetScore() float64 {
+	totalRating := float64(r.Height + r.Abrasion + r.ImpactCut + r.TransverseRigidity)
+	return totalRating / (en13634MaxAttributeRating * 4)
+}
+
+// IsSaferThan returns true if these ratings have a higher safety score than the otherRating
+func (r EN13634Rating) IsSaferThan(otherRating *EN13634Rating) bool {
+	return otherRating == nil || (r.GetScore() > otherRating.GetScore())
+}
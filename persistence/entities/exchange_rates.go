@@ -0,0 +1,92 @@
+package entities
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ExchangeRates represents a set of currency exchange rates relative to a base currency, where each rate is the amount of that currency that one unit of the base currency buys
+type ExchangeRates struct {
+	BaseCurrency string             `json:"base"`
+	Rates        map[string]float64 `json:"rates"`
+}
+
+// NormalizeCurrency upper-cases and trims the given currency code, defaulting to USD as all prices were stored in US cents before currencies were tracked
+func NormalizeCurrency(currency string) string {
+	normalizedCurrency := strings.ToUpper(strings.TrimSpace(currency))
+	if normalizedCurrency == "" {
+		return CurrencyUSD
+	}
+
+	return normalizedCurrency
+}
+
+// GetBaseCurrency returns the normalized base currency, or USD if these exchange rates are undefined
+func (e *ExchangeRates) GetBaseCurrency() string {
+	if e == nil {
+		return CurrencyUSD
+	}
+
+	return NormalizeCurrency(e.BaseCurrency)
+}
+
+// GetRate returns the amount of the given currency that one unit of the base currency buys, and false if the rate is unknown
+func (e *ExchangeRates) GetRate(currency string) (float64, bool) {
+	normalizedCurrency := NormalizeCurrency(currency)
+	if normalizedCurrency == e.GetBaseCurrency() {
+		return 1, true
+	}
+
+	if e == nil {
+		return 0, false
+	}
+
+	rate, exists := e.Rates[normalizedCurrency]
+	if !exists || rate <= 0 {
+		return 0, false
+	}
+
+	return rate, true
+}
+
+// ConvertCents converts an amount of cents from one currency to another, returning an error if either rate is unknown
+func (e *ExchangeRates) ConvertCents(amountCents int, fromCurrency string, toCurrency string) (int, error) {
+	if NormalizeCurrency(fromCurrency) == NormalizeCurrency(toCurrency) {
+		return amountCents, nil
+	}
+
+	fromRate, exists := e.GetRate(fromCurrency)
+	if !exists {
+		return 0, fmt.Errorf("No exchange rate is defined for %s", NormalizeCurrency(fromCurrency))
+	}
+
+	toRate, exists := e.GetRate(toCurrency)
+	if !exists {
+		return 0, fmt.Errorf("No exchange rate is defined for %s", NormalizeCurrency(toCurrency))
+	}
+
+	return int(math.Round(float64(amountCents) / fromRate * toRate)), nil
+}
+
+// Rebase returns a copy of these exchange rates relative to the given base currency
+func (e *ExchangeRates) Rebase(baseCurrency string) (*ExchangeRates, error) {
+	normalizedBaseCurrency := NormalizeCurrency(baseCurrency)
+	newBaseRate, exists := e.GetRate(normalizedBaseCurrency)
+	if !exists {
+		return nil, fmt.Errorf("Cannot rebase the exchange rates to %s because no rate is defined for it", normalizedBaseCurrency)
+	}
+
+	rebasedRates := &ExchangeRates{BaseCurrency: normalizedBaseCurrency, Rates: map[string]float64{}}
+	rebasedRates.Rates[e.GetBaseCurrency()] = 1 / newBaseRate
+	if e != nil {
+		for currency, rate := range e.Rates {
+			if rate > 0 {
+				rebasedRates.Rates[NormalizeCurrency(currency)] = rate / newBaseRate
+			}
+		}
+	}
+	delete(rebasedRates.Rates, normalizedBaseCurrency)
+
+	return rebasedRates, nil
+}
@@ -0,0 +1,7 @@
+package entities
+
+// FIMCertification represents a helmet's FIM racing homologation
+type FIMCertification struct {
+	Standard           string `json:"standard"`
+	HomologationNumber string `json:"homologationNumber"`
+}
@@ -0,0 +1,9 @@
+package entities
+
+// FIMHelmet represents one motorcycle helmet from the FIM's list of homologated racing helmets
+type FIMHelmet struct {
+	Manufacturer       string
+	Model              string
+	Standard           string
+	HomologationNumber string
+}
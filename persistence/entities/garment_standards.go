@@ -0,0 +1,61 @@
+package entities
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var garmentClassRanks = map[string]int{
+	GarmentClassC:   1,
+	GarmentClassB:   2,
+	GarmentClassA:   3,
+	GarmentClassAA:  4,
+	GarmentClassAAA: 5,
+}
+
+const maxGarmentClassRank = 5
+
+// Class letters are matched case-sensitively so that words like "a" in the description aren't mistaken for a class
+var garmentClassAfterKeywordRegexp = regexp.MustCompile(`(?i:class|level|rated|rating)\s*:?\s*(AAA|AA|A|B|C)\b`)
+var garmentClassAfterStandardRegexp = regexp.MustCompile(`17092(?:-\d)?(?::\d{4})?\s*[-:,(]?\s*(AAA|AA|A|B|C)\b`)
+
+// ParseGarmentClass returns the EN 17092 garment class mentioned in the given description part, or an empty string if the part doesn't mention a class
+func ParseGarmentClass(part string) string {
+	if !strings.Contains(part, "17092") {
+		return ""
+	}
+
+	matches := garmentClassAfterStandardRegexp.FindStringSubmatch(part)
+	if len(matches) < 2 {
+		matches = garmentClassAfterKeywordRegexp.FindStringSubmatch(part)
+	}
+
+	if len(matches) < 2 {
+		return ""
+	}
+	return matches[1]
+}
+
+// GetGarmentClassRank returns how protective the given EN 17092 garment class is, where higher numbers are more protective, or 0 if the class is not recognized
+func GetGarmentClassRank(garmentClass string) int {
+	return garmentClassRanks[strings.ToUpper(strings.TrimSpace(garmentClass))]
+}
+
+// GetGarmentClassScore returns a score between 0 and 1 for the given EN 17092 garment class, or 0 if the garment is not rated
+func GetGarmentClassScore(garmentClass string) float64 {
+	return float64(GetGarmentClassRank(garmentClass)) / float64(maxGarmentClassRank)
+}
+
+// GetGarmentClassesAtLeast returns all of the known EN 17092 garment classes that are at least as protective as minimumGarmentClass
+func GetGarmentClassesAtLeast(minimumGarmentClass string) []string {
+	minimumRank := GetGarmentClassRank(minimumGarmentClass)
+	garmentClasses := []string{}
+	for garmentClass, rank := range garmentClassRanks {
+		if rank >= minimumRank {
+			garmentClasses = append(garmentClasses, garmentClass)
+		}
+	}
+	sort.Strings(garmentClasses)
+	return garmentClasses
+}
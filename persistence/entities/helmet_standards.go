@@ -0,0 +1,162 @@
+package entities
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LegacySNELLStandard is the standard assumed when filtering SNELL certified products that were imported before the standard was tracked (only M2015 helmets were imported at the time). It doesn't affect their scores.
+const LegacySNELLStandard = SNELLStandardM2015
+
+// M2020D and M2020R only differ in which government standard they are harmonized with, not in how strict they are, so they share a rank
+var snellStandardRanks = map[string]int{
+	SNELLStandardM2010:  1,
+	SNELLStandardM2015:  2,
+	SNELLStandardM2020D: 3,
+	SNELLStandardM2020R: 3,
+}
+
+const currentSNELLStandardRank = 3
+
+// NormalizeSNELLStandard trims and uppercases a raw SNELL standard i.e. " m2020d" becomes "M2020D"
+func NormalizeSNELLStandard(standard string) string {
+	return strings.ToUpper(strings.TrimSpace(standard))
+}
+
+// GetSNELLStandardRank returns how recent the given SNELL standard is, where higher numbers are more recent, or 0 if the standard is not recognized
+func GetSNELLStandardRank(standard string) int {
+	return snellStandardRanks[NormalizeSNELLStandard(standard)]
+}
+
+// IsCurrentSNELLStandard returns true if the given SNELL standard has not been superseded by a newer one
+func IsCurrentSNELLStandard(standard string) bool {
+	return GetSNELLStandardRank(standard) >= currentSNELLStandardRank
+}
+
+// GetSNELLStandardsAtLeast returns all of the known SNELL standards that are at least as recent as minimumStandard
+func GetSNELLStandardsAtLeast(minimumStandard string) []string {
+	minimumRank := GetSNELLStandardRank(minimumStandard)
+	standards := []string{}
+	for standard, rank := range snellStandardRanks {
+		if rank >= minimumRank {
+			standards = append(standards, standard)
+		}
+	}
+	sort.Strings(standards)
+	return standards
+}
+
+// eceVersionAfterECEPattern matches a version that follows ECE, with an optional UN prefix and R before the regulation number
+var eceVersionAfterECEPattern = regexp.MustCompile(`(?i)\b(?:UN[\s-]*)?ECE[\s-]*(?:R[\s-]*)?22[./-](0[56])\b`)
+
+// standaloneECEVersionPattern matches a dotted or slashed version that isn't part of a longer number, date or part number
+var standaloneECEVersionPattern = regexp.MustCompile(`(?:^|[^\w./-])22[./](0[56])(?:$|[^\w./-])`)
+
+// LegacyECEVersion is the version assumed when filtering ECE certified products that were imported before the version was tracked. It doesn't affect their scores.
+const LegacyECEVersion = ECEVersion2205
+
+var eceVersionRanks = map[string]int{
+	ECEVersion2205: 1,
+	ECEVersion2206: 2,
+}
+
+const currentECEVersionRank = 2
+
+// NormalizeECEVersion converts the various ways an ECE version is written (i.e. "22/06", "2206", "22-06") into one of the ECEVersion constants, or returns an empty string if the version is not recognized
+func NormalizeECEVersion(version string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, version)
+
+	switch digits {
+	case "2205":
+		return ECEVersion2205
+	case "2206":
+		return ECEVersion2206
+	}
+	return ""
+}
+
+// ParseECEVersion returns the most recent ECE version mentioned in the given text, or an empty string if no version is mentioned. A version counts when it follows "ECE" (i.e. "ECE 22.05", "ECE R22-06", "UN ECE 22/05"), or when it is written on its own with a dot or slash (i.e. "22.06 approved"), so that part numbers and dates that happen to contain 22-05 don't match.
+func ParseECEVersion(text string) string {
+	version := ""
+	for _, pattern := range []*regexp.Regexp{eceVersionAfterECEPattern, standaloneECEVersionPattern} {
+		for _, match := range pattern.FindAllStringSubmatch(text, -1) {
+			matchedVersion := NormalizeECEVersion("22" + match[1])
+			if GetECEVersionRank(matchedVersion) > GetECEVersionRank(version) {
+				version = matchedVersion
+			}
+		}
+	}
+	return version
+}
+
+// GetECEVersionRank returns how recent the given ECE version is, where higher numbers are more recent, or 0 if the version is not recognized
+func GetECEVersionRank(version string) int {
+	return eceVersionRanks[NormalizeECEVersion(version)]
+}
+
+// IsCurrentECEVersion returns true if the given ECE version has not been superseded by a newer one
+func IsCurrentECEVersion(version string) bool {
+	return GetECEVersionRank(version) >= currentECEVersionRank
+}
+
+// GetECEVersionsAtLeast returns all of the known ECE versions that are at least as recent as minimumVersion
+func GetECEVersionsAtLeast(minimumVersion string) []string {
+	minimumRank := GetECEVersionRank(minimumVersion)
+	versions := []string{}
+	for version, rank := range eceVersionRanks {
+		if rank >= minimumRank {
+			versions = append(versions, version)
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+var fimStandardRanks = map[string]int{
+	FIMStandardFRHPhe01: 1,
+	FIMStandardFRHPhe02: 2,
+}
+
+// NormalizeFIMStandard converts the various ways an FIM standard is written (i.e. "frhphe 02", "FRHPHE-02") into one of the FIMStandard constants, or returns an empty string if the standard is not recognized
+func NormalizeFIMStandard(standard string) string {
+	compactStandard := strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(standard))
+	switch compactStandard {
+	case "frhphe01":
+		return FIMStandardFRHPhe01
+	case "frhphe02":
+		return FIMStandardFRHPhe02
+	}
+	return ""
+}
+
+// GetFIMStandardRank returns how recent the given FIM standard is, where higher numbers are more recent, or 0 if the standard is not recognized
+func GetFIMStandardRank(standard string) int {
+	return fimStandardRanks[NormalizeFIMStandard(standard)]
+}
+
+// GetFIMStandardsAtLeast returns all of the known FIM standards that are at least as recent as minimumStandard
+func GetFIMStandardsAtLeast(minimumStandard string) []string {
+	minimumRank := GetFIMStandardRank(minimumStandard)
+	standards := []string{}
+	for standard, rank := range fimStandardRanks {
+		if rank >= minimumRank {
+			standards = append(standards, standard)
+		}
+	}
+	sort.Strings(standards)
+	return standards
+}
+
+// GetECEVersionRequiredByFIMStandard returns the ECE version that a helmet must be certified to before it can be homologated to the given FIM standard
+func GetECEVersionRequiredByFIMStandard(standard string) string {
+	if NormalizeFIMStandard(standard) == FIMStandardFRHPhe02 {
+		return ECEVersion2206
+	}
+	return ECEVersion2205
+}
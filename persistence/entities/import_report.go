@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// ImportStageDetails is the stage where an imported product's detail page is fetched
+const ImportStageDetails = "details"
+
+// ImportStageLookup is the stage where an imported product is matched to an existing product
+const ImportStageLookup = "lookup"
+
+// ImportStageImage is the stage where an imported product's image is copied to S3
+const ImportStageImage = "image"
+
+// ImportStageUpsert is the stage where an imported product is created or updated
+const ImportStageUpsert = "upsert"
+
+// MaxImportReportIssues is the most failures and the most warnings that are kept for a single import, so that an import where everything fails doesn't produce a huge report. Every failure and warning is still counted.
+const MaxImportReportIssues = 100
+
+// ImportReport records the problems with the products in an import from a retailer. Products that couldn't be created or updated are failures, while products that were saved despite a problem (i.e. a missing description or image) are warnings. The products that were created, updated, skipped, or failed are counted in the job run that the import belongs to, which the report reads them from rather than keeping counts of its own. It is safe to record problems from multiple goroutines at once.
+type ImportReport struct {
+	ProductType     string               `json:"productType"`
+	NumListed       int                  `json:"numListed"`
+	MaxFailureRatio float64              `json:"maxFailureRatio"`
+	NumWarnings     int                  `json:"numWarnings"`
+	Failures        []*ImportReportIssue `json:"failures"`
+	Warnings        []*ImportReportIssue `json:"warnings"`
+	jobRun          *JobRun
+	mutex           sync.Mutex
+}
+
+// ImportReportIssue describes a problem with a single product, along with the stage of the import that it happened in
+type ImportReportIssue struct {
+	ExternalID string `json:"externalID"`
+	Name       string `json:"name"`
+	Stage      string `json:"stage"`
+	Error      string `json:"error"`
+}
+
+// NewImportReport returns an empty report for an import of the given number of listed products that counts its products in jobRun, and attaches it to jobRun, which must not be nil. The import fails once more than maxFailureRatio of the listed products that weren't skipped failed.
+func NewImportReport(productType string, numListed int, maxFailureRatio float64, jobRun *JobRun) *ImportReport {
+	importReport := &ImportReport{
+		ProductType:     productType,
+		NumListed:       numListed,
+		MaxFailureRatio: maxFailureRatio,
+		Failures:        []*ImportReportIssue{},
+		Warnings:        []*ImportReportIssue{},
+		jobRun:          jobRun,
+	}
+	jobRun.SetImportReport(importReport)
+	return importReport
+}
+
+// AddFailed counts a product that couldn't be created or updated in the job run, keeping the issue unless MaxImportReportIssues failures have already been kept
+func (r *ImportReport) AddFailed(externalID string, name string, stage string, err error) {
+	// The job run is updated without holding the report's lock, since the run holds its own lock while it serializes the report
+	r.jobRun.AddFailed(externalID, err)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.Failures) < MaxImportReportIssues {
+		r.Failures = append(r.Failures, &ImportReportIssue{ExternalID: externalID, Name: name, Stage: stage, Error: err.Error()})
+	}
+}
+
+// AddWarning counts a problem that didn't stop a product from being saved, keeping the issue unless MaxImportReportIssues warnings have already been kept
+func (r *ImportReport) AddWarning(externalID string, name string, stage string, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.NumWarnings++
+	if len(r.Warnings) < MaxImportReportIssues {
+		r.Warnings = append(r.Warnings, &ImportReportIssue{ExternalID: externalID, Name: name, Stage: stage, Error: err.Error()})
+	}
+}
+
+// GetNumWarnings returns the number of problems that didn't stop a product from being saved
+func (r *ImportReport) GetNumWarnings() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.NumWarnings
+}
+
+// GetFailureRatio returns the share of the listed products that weren't skipped that failed, which is 0 if every product was skipped
+func (r *ImportReport) GetFailureRatio() float64 {
+	counts := r.jobRun.GetCounts()
+	numProcessed := r.getNumProcessed(counts)
+	if numProcessed == 0 {
+		return 0
+	}
+	return float64(counts.Failed) / float64(numProcessed)
+}
+
+// Err returns an error summarizing the failures if more than MaxFailureRatio of the listed products that weren't skipped failed, or nil otherwise
+func (r *ImportReport) Err() error {
+	counts := r.jobRun.GetCounts()
+	failureRatio := r.GetFailureRatio()
+	if failureRatio <= r.MaxFailureRatio {
+		return nil
+	}
+
+	return fmt.Errorf("%d of %d %s products (%.1f%%) failed to import, which is more than the maximum of %.1f%%",
+		counts.Failed, r.getNumProcessed(counts), r.ProductType, failureRatio*100, r.MaxFailureRatio*100)
+}
+
+// MarshalJSON locks the report while it is serialized, since problems can still be recorded while the run that it belongs to is being saved
+func (r *ImportReport) MarshalJSON() ([]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	type importReportJSON ImportReport
+	return json.Marshal((*importReportJSON)(r))
+}
+
+func (r *ImportReport) getNumProcessed(counts JobRunCounts) int {
+	return r.NumListed - counts.Skipped
+}
@@ -0,0 +1,89 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_ImportReport_should_count_failures_in_the_job_run_from_multiple_goroutines_and_cap_the_issues(t *testing.T) {
+	RegisterTestingT(t)
+	jobRun := NewJobRun("sync_revzilla_jackets", JobRunTriggerAPI)
+	importReport := NewImportReport("jacket", 4*(MaxImportReportIssues+50), 0.05, jobRun)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < MaxImportReportIssues+50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			externalID := fmt.Sprintf("%d", i)
+			importReport.AddWarning(externalID, "Jacket", ImportStageImage, errors.New("Failed to upload the image"))
+			importReport.AddFailed(externalID, "Jacket", ImportStageUpsert, errors.New("Failed to upsert"))
+		}(i)
+	}
+	wg.Wait()
+
+	expectedCount := MaxImportReportIssues + 50
+	Expect(jobRun.GetCounts()).To(Equal(JobRunCounts{Failed: expectedCount}))
+	Expect(importReport.GetNumWarnings()).To(Equal(expectedCount))
+	Expect(importReport.Failures).To(HaveLen(MaxImportReportIssues))
+	Expect(importReport.Warnings).To(HaveLen(MaxImportReportIssues))
+	Expect(importReport.Failures[0].Stage).To(Equal(ImportStageUpsert))
+}
+
+func Test_ImportReport_should_only_fail_once_the_failure_ratio_is_more_than_the_maximum(t *testing.T) {
+	RegisterTestingT(t)
+	jobRun := NewJobRun("sync_revzilla_boots", JobRunTriggerAPI)
+	importReport := NewImportReport("boots", 101, 0.05, jobRun)
+	Expect(importReport.GetFailureRatio()).To(Equal(0.0))
+	Expect(importReport.Err()).To(BeNil())
+
+	jobRun.AddSkipped()
+	for i := 0; i < 5; i++ {
+		importReport.AddFailed(fmt.Sprintf("%d", i), "Boot", ImportStageLookup, errors.New("connection refused"))
+	}
+	Expect(importReport.GetFailureRatio()).To(Equal(0.05))
+	Expect(importReport.Err()).To(BeNil())
+
+	importReport.AddFailed("100", "Boot", ImportStageUpsert, errors.New("connection refused"))
+	err := importReport.Err()
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("6 of 100 boots products (6.0%) failed to import, which is more than the maximum of 5.0%"))
+}
+
+func Test_ImportReport_should_fail_on_any_failure_when_the_maximum_failure_ratio_is_zero(t *testing.T) {
+	RegisterTestingT(t)
+	importReport := NewImportReport("gloves", 100, 0, NewJobRun("sync_revzilla_gloves", JobRunTriggerAPI))
+	Expect(importReport.Err()).To(BeNil())
+
+	importReport.AddFailed("1", "Glove", ImportStageLookup, errors.New("connection refused"))
+	Expect(importReport.Err()).ToNot(BeNil())
+}
+
+func Test_ImportReport_should_be_saved_with_the_job_run(t *testing.T) {
+	RegisterTestingT(t)
+	jobRun := NewJobRun("sync_revzilla_gloves", JobRunTriggerAPI)
+	importReport := NewImportReport("gloves", 2, 0.05, jobRun)
+	jobRun.AddCreated()
+	importReport.AddWarning("123", "Glove", ImportStageDetails, errors.New("Received a 404"))
+
+	jobRunJSONBytes, err := json.Marshal(jobRun)
+	Expect(err).To(BeNil())
+
+	savedJobRun := &JobRun{}
+	Expect(json.Unmarshal(jobRunJSONBytes, savedJobRun)).To(BeNil())
+	Expect(savedJobRun.Counts).To(Equal(JobRunCounts{Created: 1}))
+	Expect(savedJobRun.ImportReport).ToNot(BeNil())
+	Expect(savedJobRun.ImportReport.ProductType).To(Equal("gloves"))
+	Expect(savedJobRun.ImportReport.NumListed).To(Equal(2))
+	Expect(savedJobRun.ImportReport.NumWarnings).To(Equal(1))
+	Expect(savedJobRun.ImportReport.Warnings).To(Equal([]*ImportReportIssue{{ExternalID: "123", Name: "Glove", Stage: ImportStageDetails, Error: "Received a 404"}}))
+	Expect(savedJobRun.ImportReport.Failures).To(BeEmpty())
+
+	var nilJobRun *JobRun
+	nilJobRun.SetImportReport(importReport)
+}
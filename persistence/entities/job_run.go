@@ -0,0 +1,298 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobRunStatusQueued means that the job was triggered but hasn't started yet
+const JobRunStatusQueued = "queued"
+
+// JobRunStatusRunning means that the job is currently running
+const JobRunStatusRunning = "running"
+
+// JobRunStatusSucceeded means that the job finished without returning an error, although individual items may still have failed
+const JobRunStatusSucceeded = "succeeded"
+
+// JobRunStatusFailed means that the job returned an error
+const JobRunStatusFailed = "failed"
+
+// JobRunStatusSkipped means that the job didn't run because another run of it was already in progress
+const JobRunStatusSkipped = "skipped"
+
+// JobRunStatusCancelled means that the job stopped early because it was asked to
+const JobRunStatusCancelled = "cancelled"
+
+// ErrJobRunCancelled finishes a run that stopped early because it was asked to
+var ErrJobRunCancelled = errors.New("The job run was cancelled")
+
+// JobRunTriggerAPI means that the job was triggered by a POST to its endpoint
+const JobRunTriggerAPI = "api"
+
+// JobRunTriggerSchedule means that the job was triggered by the worker's scheduler
+const JobRunTriggerSchedule = "schedule"
+
+// MaxJobRunItemErrors is the most per-item errors that are kept for a single run, so that a run where everything fails doesn't produce a huge document. Every failure is still counted.
+const MaxJobRunItemErrors = 100
+
+// JobRun records a single run of a background job, including what triggered it, when it ran, how it finished, and how many items it created, updated, skipped, or failed to process. It is safe to record items from multiple goroutines at once, and a nil JobRun ignores everything that is recorded so that jobs can be run without one.
+type JobRun struct {
+	UUID                 uuid.UUID          `json:"uuid"`
+	JobName              string             `json:"jobName"`
+	Trigger              string             `json:"trigger"`
+	IsDryRun             bool               `json:"isDryRun"`
+	ChangeReportUUID     *uuid.UUID         `json:"changeReportUUID,omitempty"`
+	ResumedFromUUID      *uuid.UUID         `json:"resumedFromUUID,omitempty"`
+	Status               string             `json:"status"`
+	CancelRequestedAtUTC *time.Time         `json:"cancelRequestedAtUTC,omitempty"`
+	QueuedAtUTC          time.Time          `json:"queuedAtUTC"`
+	StartedAtUTC         *time.Time         `json:"startedAtUTC"`
+	FinishedAtUTC        *time.Time         `json:"finishedAtUTC"`
+	Error                string             `json:"error"`
+	Counts               JobRunCounts       `json:"counts"`
+	ItemErrors           []*JobRunItemError `json:"itemErrors"`
+	ImportReport         *ImportReport      `json:"importReport,omitempty"`
+	Checkpoint           *JobRunCheckpoint  `json:"checkpoint,omitempty"`
+	mutex                sync.Mutex
+}
+
+// JobRunCounts counts the items that a job run processed
+type JobRunCounts struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// JobRunItemError describes why a single item failed to be processed, where the item is whatever identifies it best i.e. an external ID or a manufacturer and model
+type JobRunItemError struct {
+	Item  string `json:"item"`
+	Error string `json:"error"`
+}
+
+// JobRunCheckpoint records how far through the products a run got, so that a later run can resume from there if this one fails or is cancelled
+type JobRunCheckpoint struct {
+	LastProductID int       `json:"lastProductID"`
+	SavedAtUTC    time.Time `json:"savedAtUTC"`
+}
+
+// NewJobRun returns a queued run of the given job
+func NewJobRun(jobName string, trigger string) *JobRun {
+	return &JobRun{
+		UUID:        uuid.New(),
+		JobName:     jobName,
+		Trigger:     trigger,
+		Status:      JobRunStatusQueued,
+		QueuedAtUTC: time.Now().UTC(),
+		ItemErrors:  []*JobRunItemError{},
+	}
+}
+
+// Start records that the run has started
+func (r *JobRun) Start() {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	startedAtUTC := time.Now().UTC()
+	r.StartedAtUTC = &startedAtUTC
+	r.Status = JobRunStatusRunning
+}
+
+// Finish records that the run has finished along with the error that it failed with, if any. Runs that stopped with ErrJobRunCancelled are recorded as cancelled rather than failed.
+func (r *JobRun) Finish(err error) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	finishedAtUTC := time.Now().UTC()
+	r.FinishedAtUTC = &finishedAtUTC
+	if err == ErrJobRunCancelled {
+		r.Status = JobRunStatusCancelled
+	} else if err != nil {
+		r.Status = JobRunStatusFailed
+		r.Error = err.Error()
+	} else {
+		r.Status = JobRunStatusSucceeded
+	}
+}
+
+// Skip records that the run finished without running the job, with the reason why
+func (r *JobRun) Skip(reason string) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	finishedAtUTC := time.Now().UTC()
+	r.FinishedAtUTC = &finishedAtUTC
+	r.Status = JobRunStatusSkipped
+	r.Error = reason
+}
+
+// RequestCancel records that the run has been asked to stop
+func (r *JobRun) RequestCancel() {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.CancelRequestedAtUTC == nil {
+		cancelRequestedAtUTC := time.Now().UTC()
+		r.CancelRequestedAtUTC = &cancelRequestedAtUTC
+	}
+}
+
+// IsCancelRequested returns true if the run has been asked to stop
+func (r *JobRun) IsCancelRequested() bool {
+	if r == nil {
+		return false
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.CancelRequestedAtUTC != nil
+}
+
+// AddCreated counts an item that was created
+func (r *JobRun) AddCreated() {
+	r.updateCounts(func(counts *JobRunCounts) {
+		counts.Created++
+	})
+}
+
+// AddUpdated counts an item that was updated
+func (r *JobRun) AddUpdated() {
+	r.updateCounts(func(counts *JobRunCounts) {
+		counts.Updated++
+	})
+}
+
+// AddSkipped counts an item that was intentionally left alone
+func (r *JobRun) AddSkipped() {
+	r.updateCounts(func(counts *JobRunCounts) {
+		counts.Skipped++
+	})
+}
+
+// AddFailed counts an item that failed to be processed, keeping its error unless MaxJobRunItemErrors have already been kept
+func (r *JobRun) AddFailed(item string, err error) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.Counts.Failed++
+	if len(r.ItemErrors) < MaxJobRunItemErrors {
+		r.ItemErrors = append(r.ItemErrors, &JobRunItemError{Item: item, Error: err.Error()})
+	}
+}
+
+// SetImportReport attaches the report for an import to the run, so that it is saved along with the run
+func (r *JobRun) SetImportReport(importReport *ImportReport) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.ImportReport = importReport
+}
+
+// ResumeFrom makes the run start from the checkpoint of the given earlier run of the same job
+func (r *JobRun) ResumeFrom(previousJobRun *JobRun) {
+	if r == nil || previousJobRun == nil {
+		return
+	}
+
+	checkpoint := previousJobRun.GetCheckpoint()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.ResumedFromUUID = &previousJobRun.UUID
+	r.Checkpoint = checkpoint
+}
+
+// SetCheckpoint records that every product up to and including the one with the given ID has been processed
+func (r *JobRun) SetCheckpoint(lastProductID int) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.Checkpoint = &JobRunCheckpoint{LastProductID: lastProductID, SavedAtUTC: time.Now().UTC()}
+}
+
+// GetCheckpoint returns a copy of the run's checkpoint, or nil if it doesn't have one
+func (r *JobRun) GetCheckpoint() *JobRunCheckpoint {
+	if r == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.Checkpoint == nil {
+		return nil
+	}
+
+	checkpoint := *r.Checkpoint
+	return &checkpoint
+}
+
+// CanResume returns true if the run stopped before it finished, so that a later run can pick up from its checkpoint
+func (r *JobRun) CanResume() bool {
+	if r == nil {
+		return false
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.Status == JobRunStatusFailed || r.Status == JobRunStatusCancelled
+}
+
+// GetCounts returns a copy of the run's counts
+func (r *JobRun) GetCounts() JobRunCounts {
+	if r == nil {
+		return JobRunCounts{}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.Counts
+}
+
+// MarshalJSON locks the run while it is serialized, since items can still be recorded while it is being saved
+func (r *JobRun) MarshalJSON() ([]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	type jobRunJSON JobRun
+	return json.Marshal((*jobRunJSON)(r))
+}
+
+func (r *JobRun) updateCounts(update func(counts *JobRunCounts)) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	update(&r.Counts)
+}
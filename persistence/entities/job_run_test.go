@@ -0,0 +1,104 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_JobRun_should_count_items_from_multiple_goroutines_and_cap_the_item_errors(t *testing.T) {
+	RegisterTestingT(t)
+	jobRun := NewJobRun("sync_revzilla_jackets", JobRunTriggerAPI)
+	jobRun.Start()
+	Expect(jobRun.Status).To(Equal(JobRunStatusRunning))
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < MaxJobRunItemErrors+50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			jobRun.AddCreated()
+			jobRun.AddUpdated()
+			jobRun.AddSkipped()
+			jobRun.AddFailed(fmt.Sprintf("%d", i), errors.New("Failed to upsert"))
+		}(i)
+	}
+	wg.Wait()
+	jobRun.Finish(nil)
+
+	expectedCount := MaxJobRunItemErrors + 50
+	Expect(jobRun.GetCounts()).To(Equal(JobRunCounts{Created: expectedCount, Updated: expectedCount, Skipped: expectedCount, Failed: expectedCount}))
+	Expect(jobRun.ItemErrors).To(HaveLen(MaxJobRunItemErrors))
+	Expect(jobRun.Status).To(Equal(JobRunStatusSucceeded))
+	Expect(jobRun.FinishedAtUTC).ToNot(BeNil())
+}
+
+func Test_JobRun_should_record_the_error_that_the_job_failed_with(t *testing.T) {
+	RegisterTestingT(t)
+	jobRun := NewJobRun("import_helmets", JobRunTriggerAPI)
+	jobRun.Start()
+	jobRun.Finish(errors.New("Not enough helmets found"))
+
+	jobRunJSONBytes, err := json.Marshal(jobRun)
+	Expect(err).To(BeNil())
+
+	savedJobRun := &JobRun{}
+	Expect(json.Unmarshal(jobRunJSONBytes, savedJobRun)).To(BeNil())
+	Expect(savedJobRun.UUID).To(Equal(jobRun.UUID))
+	Expect(savedJobRun.Status).To(Equal(JobRunStatusFailed))
+	Expect(savedJobRun.Error).To(Equal("Not enough helmets found"))
+	Expect(savedJobRun.StartedAtUTC).ToNot(BeNil())
+}
+
+func Test_JobRun_should_ignore_items_when_nil(t *testing.T) {
+	RegisterTestingT(t)
+	var jobRun *JobRun
+	jobRun.Start()
+	jobRun.AddCreated()
+	jobRun.AddFailed("391137", errors.New("Failed to upsert"))
+	jobRun.Finish(nil)
+	Expect(jobRun.GetCounts()).To(Equal(JobRunCounts{}))
+}
+
+func Test_JobRun_should_record_skipped_and_cancelled_runs(t *testing.T) {
+	RegisterTestingT(t)
+	skippedJobRun := NewJobRun("import_helmets", JobRunTriggerSchedule)
+	skippedJobRun.Skip("Another run of the job was in progress")
+	Expect(skippedJobRun.Status).To(Equal(JobRunStatusSkipped))
+	Expect(skippedJobRun.Error).To(Equal("Another run of the job was in progress"))
+	Expect(skippedJobRun.StartedAtUTC).To(BeNil())
+
+	cancelledJobRun := NewJobRun("import_helmets", JobRunTriggerAPI)
+	cancelledJobRun.Start()
+	Expect(cancelledJobRun.IsCancelRequested()).To(BeFalse())
+	cancelledJobRun.RequestCancel()
+	Expect(cancelledJobRun.IsCancelRequested()).To(BeTrue())
+	cancelledJobRun.Finish(ErrJobRunCancelled)
+	Expect(cancelledJobRun.Status).To(Equal(JobRunStatusCancelled))
+}
+
+func Test_JobRun_should_resume_from_the_checkpoint_of_a_run_that_stopped_early(t *testing.T) {
+	RegisterTestingT(t)
+	previousJobRun := NewJobRun("sync_revzilla_helmets", JobRunTriggerSchedule)
+	previousJobRun.Start()
+	Expect(previousJobRun.CanResume()).To(BeFalse())
+	previousJobRun.SetCheckpoint(42)
+	previousJobRun.Finish(ErrJobRunCancelled)
+	Expect(previousJobRun.CanResume()).To(BeTrue())
+
+	jobRun := NewJobRun("sync_revzilla_helmets", JobRunTriggerAPI)
+	jobRun.ResumeFrom(previousJobRun)
+	Expect(*jobRun.ResumedFromUUID).To(Equal(previousJobRun.UUID))
+	Expect(jobRun.GetCheckpoint().LastProductID).To(Equal(42))
+
+	// The checkpoint is copied, so that the new run moving on doesn't change the previous run's checkpoint
+	jobRun.SetCheckpoint(84)
+	Expect(previousJobRun.GetCheckpoint().LastProductID).To(Equal(42))
+
+	jobRun.Finish(nil)
+	Expect(jobRun.CanResume()).To(BeFalse())
+}
@@ -1,8 +1,10 @@
 package entities
 
 import (
+	"encoding/json"
 	"math"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -20,43 +22,65 @@ type Product struct {
 	Manufacturer         string               `json:"manufacturer"`
 	Model                string               `json:"model"`
 	ModelAliases         []*ProductModelAlias `json:"modelAliases"`
+	DisplayModel         string               `json:"displayModel,omitempty"`
+	Regions              []string             `json:"regions"`
 	SafetyPercentage     int                  `json:"safetyPercentage"`
 	OriginalImageURL     string               `json:"originalImageURL"`
 	ImageKey             string               `json:"imageKey"`
 	RevzillaBuyURL       string               `json:"revzillaBuyURL"`
 	RevzillaPriceCents   int                  `json:"revzillaPriceCents"`
+	Offers               []*ProductOffer      `json:"offers"`
 	MSRPCents            int                  `json:"msrpCents"`
+	MSRPCurrency         string               `json:"msrpCurrency"`
 	SearchPriceCents     int                  `json:"searchPriceCents"`
+	SearchPriceCurrency  string               `json:"searchPriceCurrency"`
 	LatchPercentage      int                  `json:"latchPercentage"`
 	WeightInLbs          float64              `json:"weightInLbs"`
 	Sizes                []string             `json:"sizes"`
+	Variants             []*ProductVariant    `json:"variants"`
+	Colors               []string             `json:"colors"`
+	RatingCount          int                  `json:"ratingCount"`
+	SKU                  string               `json:"sku"`
 	Materials            string               `json:"materials"`
 	RetentionSystem      string               `json:"retentionSystem"`
 	HelmetCertifications struct {
-		SHARP *SHARPCertification `json:"SHARP"`
-		SNELL bool                `json:"SNELL"`
-		ECE   bool                `json:"ECE"`
-		DOT   bool                `json:"DOT"`
+		SHARP         *SHARPCertification `json:"SHARP"`
+		SNELL         bool                `json:"SNELL"`
+		SNELLStandard string              `json:"SNELLStandard"`
+		ECE           bool                `json:"ECE"`
+		ECEVersion    string              `json:"ECEVersion"`
+		FIM           *FIMCertification   `json:"FIM"`
+		DOT           bool                `json:"DOT"`
 	} `json:"helmetCertifications"`
 	JacketCertifications struct {
-		Shoulder   *CEImpactZone `json:"shoulder"`
-		Elbow      *CEImpactZone `json:"elbow"`
-		Back       *CEImpactZone `json:"back"`
-		Chest      *CEImpactZone `json:"chest"`
-		FitsAirbag bool          `json:"fitsAirbag"`
+		Shoulder     *CEImpactZone `json:"shoulder"`
+		Elbow        *CEImpactZone `json:"elbow"`
+		Back         *CEImpactZone `json:"back"`
+		Chest        *CEImpactZone `json:"chest"`
+		FitsAirbag   bool          `json:"fitsAirbag"`
+		GarmentClass string        `json:"garmentClass"`
 	} `json:"jacketCertifications"`
 	PantsCertifications struct {
-		Knee     *CEImpactZone `json:"knee"`
-		Hip      *CEImpactZone `json:"hip"`
-		Tailbone *CEImpactZone `json:"tailbone"`
+		Knee         *CEImpactZone `json:"knee"`
+		Hip          *CEImpactZone `json:"hip"`
+		Tailbone     *CEImpactZone `json:"tailbone"`
+		GarmentClass string        `json:"garmentClass"`
 	} `json:"pantsCertifications"`
 	BootsCertifications struct {
-		Overall *CEImpactZone `json:"overall"`
+		Overall *CEImpactZone  `json:"overall"`
+		EN13634 *EN13634Rating `json:"EN13634"`
 	} `json:"bootsCertifications"`
 	GlovesCertifications struct {
-		Overall *CEImpactZone `json:"overall"`
+		Overall *CEImpactZone  `json:"overall"`
+		EN13594 *EN13594Rating `json:"EN13594"`
 	} `json:"glovesCertifications"`
-	IsDiscontinued bool `json:"isDiscontinued"`
+	AirbagCertifications    AirbagCertification `json:"airbagCertifications"`
+	ProtectorCertifications struct {
+		Back  *CEImpactZone `json:"back"`
+		Chest *CEImpactZone `json:"chest"`
+	} `json:"protectorCertifications"`
+	Sources        map[string]*ProductSource `json:"sources"`
+	IsDiscontinued bool                      `json:"isDiscontinued"`
 }
 
 const sharpImpactWeight float64 = 0.2
@@ -66,25 +90,353 @@ const defaultSNELLWeight float64 = 0.10
 const defaultECEWeight float64 = 0.08
 const defaultDOTWeight float64 = 0.02
 
-// UpdateSearchPrice sets the search price to the revzilla price if its defined, otherwise uses the MSRP
-func (p *Product) UpdateSearchPrice() {
+// Helmets certified to a superseded SNELL standard only get partial credit, since newer standards test more impact speeds and locations
+const supersededSNELLMultiplier float64 = 0.75
+
+// Helmets certified to ECE 22.05 only get partial credit, since 22.06 adds rotational impact testing and more impact points
+const supersededECEMultiplier float64 = 0.75
+
+// FIM homologation is a bonus on top of the other certifications as it is much stricter than ECE, but very few helmets are homologated
+const fimBonusWeight float64 = 0.05
+
+// Clone returns a deep copy of this product, which can be changed without affecting the original
+func (p *Product) Clone() (*Product, error) {
+	productJSONBytes, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := &Product{}
+	if err := json.Unmarshal(productJSONBytes, clone); err != nil {
+		return nil, err
+	}
+
+	clone.ID = p.ID
+	return clone, nil
+}
+
+// UpdateSearchPrice sets the search price to the lowest current offer if there is one, otherwise falls back to the revzilla price and then the MSRP. The search price is normalized to the base currency of the given exchange rates, and prices that can't be converted are skipped. If no price can be converted (i.e. exchange rates haven't been loaded yet), the first price is kept in its own currency rather than being stored as 0, which would match every price filter.
+func (p *Product) UpdateSearchPrice(exchangeRates *ExchangeRates) {
+	baseCurrency := exchangeRates.GetBaseCurrency()
+	p.SearchPriceCurrency = baseCurrency
+
+	lowestOffer, lowestOfferCents := p.getLowestOffer(exchangeRates)
+	if lowestOffer != nil {
+		p.SearchPriceCents = lowestOfferCents
+		return
+	}
+
+	fallbackPrices := []productPrice{}
 	if p.RevzillaPriceCents > 0 {
-		p.SearchPriceCents = p.RevzillaPriceCents
-	} else {
-		p.SearchPriceCents = p.MSRPCents
+		// the revzilla price is always in US cents
+		fallbackPrices = append(fallbackPrices, productPrice{cents: p.RevzillaPriceCents, currency: CurrencyUSD})
+	}
+	if p.MSRPCents > 0 {
+		fallbackPrices = append(fallbackPrices, productPrice{cents: p.MSRPCents, currency: p.MSRPCurrency})
+	}
+
+	for _, fallbackPrice := range fallbackPrices {
+		priceCents, err := exchangeRates.ConvertCents(fallbackPrice.cents, fallbackPrice.currency, baseCurrency)
+		if err == nil {
+			p.SearchPriceCents = priceCents
+			return
+		}
+	}
+
+	for _, offer := range p.Offers {
+		if offer != nil && offer.IsCurrent() {
+			fallbackPrices = append([]productPrice{{cents: offer.PriceCents, currency: offer.Currency}}, fallbackPrices...)
+			break
+		}
+	}
+
+	p.SearchPriceCents = 0
+	if len(fallbackPrices) > 0 {
+		p.SearchPriceCents = fallbackPrices[0].cents
+		p.SearchPriceCurrency = NormalizeCurrency(fallbackPrices[0].currency)
+		logrus.WithField("uuid", p.UUID).Warn("Could not convert any price to the base currency, so the search price was left in " + p.SearchPriceCurrency)
+	}
+}
+
+// productPrice is an amount of cents in a currency
+type productPrice struct {
+	cents    int
+	currency string
+}
+
+// GetLowestOffer returns the cheapest offer that can currently be bought when every price is converted to the base currency of the given exchange rates, or nil if there isn't one
+func (p *Product) GetLowestOffer(exchangeRates *ExchangeRates) *ProductOffer {
+	lowestOffer, _ := p.getLowestOffer(exchangeRates)
+	return lowestOffer
+}
+
+func (p *Product) getLowestOffer(exchangeRates *ExchangeRates) (*ProductOffer, int) {
+	var lowestOffer *ProductOffer
+	lowestOfferCents := 0
+	for _, offer := range p.Offers {
+		if offer == nil || !offer.IsCurrent() {
+			continue
+		}
+
+		offerCents, err := exchangeRates.ConvertCents(offer.PriceCents, offer.Currency, exchangeRates.GetBaseCurrency())
+		if err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{"uuid": p.UUID, "retailer": offer.Retailer}).Warn("Skipping an offer because its price could not be converted to the base currency")
+			continue
+		}
+
+		if lowestOffer == nil || offerCents < lowestOfferCents {
+			lowestOffer = offer
+			lowestOfferCents = offerCents
+		}
+	}
+
+	return lowestOffer, lowestOfferCents
+}
+
+// ConvertPrices converts every price of this product (the MSRP, search price, revzilla price, offers and variants) to the given display currency, so that a response never mixes currencies
+func (p *Product) ConvertPrices(exchangeRates *ExchangeRates, displayCurrency string) error {
+	normalizedDisplayCurrency := NormalizeCurrency(displayCurrency)
+
+	// the revzilla price doesn't have its own currency because it is always in US cents
+	revzillaPriceCents, err := exchangeRates.ConvertCents(p.RevzillaPriceCents, CurrencyUSD, normalizedDisplayCurrency)
+	if err != nil {
+		return err
+	}
+
+	msrpCents, err := exchangeRates.ConvertCents(p.MSRPCents, p.MSRPCurrency, normalizedDisplayCurrency)
+	if err != nil {
+		return err
+	}
+
+	searchPriceCents, err := exchangeRates.ConvertCents(p.SearchPriceCents, p.SearchPriceCurrency, normalizedDisplayCurrency)
+	if err != nil {
+		return err
+	}
+
+	convertedOffers := []*ProductOffer{}
+	for _, offer := range p.Offers {
+		if offer == nil {
+			continue
+		}
+
+		offerCents, err := exchangeRates.ConvertCents(offer.PriceCents, offer.Currency, normalizedDisplayCurrency)
+		if err != nil {
+			return err
+		}
+
+		convertedOffer := *offer
+		convertedOffer.PriceCents = offerCents
+		convertedOffer.Currency = normalizedDisplayCurrency
+		convertedOffers = append(convertedOffers, &convertedOffer)
+	}
+
+	convertedVariants := []*ProductVariant{}
+	for _, variant := range p.Variants {
+		if variant == nil {
+			continue
+		}
+
+		variantCents, err := exchangeRates.ConvertCents(variant.PriceCents, variant.Currency, normalizedDisplayCurrency)
+		if err != nil {
+			return err
+		}
+
+		convertedVariant := *variant
+		convertedVariant.PriceCents = variantCents
+		convertedVariant.Currency = normalizedDisplayCurrency
+		convertedVariants = append(convertedVariants, &convertedVariant)
+	}
+
+	p.MSRPCents = msrpCents
+	p.MSRPCurrency = normalizedDisplayCurrency
+	p.SearchPriceCents = searchPriceCents
+	p.SearchPriceCurrency = normalizedDisplayCurrency
+	p.RevzillaPriceCents = revzillaPriceCents
+	if p.Offers != nil {
+		p.Offers = convertedOffers
+	}
+	if p.Variants != nil {
+		p.Variants = convertedVariants
+	}
+
+	return nil
+}
+
+// UpdateVariants replaces the variants of this product, normalizing their sizes and listing every distinct size and color in Sizes and Colors. The existing sizes and colors are kept if there are no variants.
+func (p *Product) UpdateVariants(variants []*ProductVariant) {
+	p.Variants = []*ProductVariant{}
+	sizes := []string{}
+	colors := []string{}
+	seenSizes := map[string]bool{}
+	seenColors := map[string]bool{}
+	for _, variant := range variants {
+		if variant == nil {
+			continue
+		}
+
+		variant.Size = NormalizeSize(variant.Size)
+		variant.Currency = NormalizeCurrency(variant.Currency)
+		p.Variants = append(p.Variants, variant)
+
+		if variant.Size != "" && !seenSizes[variant.Size] {
+			seenSizes[variant.Size] = true
+			sizes = append(sizes, variant.Size)
+		}
+
+		if variant.Color != "" && !seenColors[variant.Color] {
+			seenColors[variant.Color] = true
+			colors = append(colors, variant.Color)
+		}
+	}
+
+	if len(sizes) > 0 {
+		p.Sizes = sizes
+	}
+	if len(colors) > 0 {
+		p.Colors = colors
+	}
+}
+
+// GetCheapestInStockVariant returns the variant with the lowest price that can currently be bought, which is the "from" price of the product, or nil if there isn't one
+func (p *Product) GetCheapestInStockVariant() *ProductVariant {
+	return GetCheapestInStockVariant(p.Variants)
+}
+
+// IsSizeInStock returns true if at least one variant in the given size can currently be bought
+func (p *Product) IsSizeInStock(size string) bool {
+	normalizedSize := NormalizeSize(size)
+	for _, variant := range p.Variants {
+		if variant != nil && variant.IsCurrent() && variant.Size == normalizedSize {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MarkSeen records that the given source listed this product, which resets its consecutive misses and revives the product if it was discontinued
+func (p *Product) MarkSeen(source string, seenAtUTC time.Time) {
+	if p.Sources == nil {
+		p.Sources = map[string]*ProductSource{}
+	}
+
+	p.Sources[source] = &ProductSource{LastSeenAtUTC: seenAtUTC}
+	p.IsDiscontinued = false
+}
+
+// MarkMissed records that the given source finished a sync without listing this product, ignoring sources that have never listed it. The product is discontinued once every source that has listed it has missed it maxConsecutiveMisses times in a row, and true is returned if this call is what discontinued it.
+func (p *Product) MarkMissed(source string, maxConsecutiveMisses int) bool {
+	productSource, exists := p.Sources[source]
+	if !exists || productSource == nil {
+		return false
+	}
+
+	productSource.ConsecutiveMisses++
+	if p.IsDiscontinued {
+		return false
+	}
+
+	for _, otherSource := range p.Sources {
+		if otherSource != nil && otherSource.ConsecutiveMisses < maxConsecutiveMisses {
+			return false
+		}
+	}
+
+	p.IsDiscontinued = true
+	return true
+}
+
+// UpsertOffer adds the given offer to this product, replacing any existing offer from the same retailer
+func (p *Product) UpsertOffer(offer *ProductOffer) {
+	if offer == nil {
+		return
+	}
+
+	for i, existingOffer := range p.Offers {
+		if existingOffer != nil && existingOffer.Retailer == offer.Retailer {
+			p.Offers[i] = offer
+			return
+		}
+	}
+
+	p.Offers = append(p.Offers, offer)
+}
+
+// GetOffer returns the offer from the given retailer, or nil if the retailer doesn't sell this product
+func (p *Product) GetOffer(retailer string) *ProductOffer {
+	for _, offer := range p.Offers {
+		if offer != nil && offer.Retailer == retailer {
+			return offer
+		}
+	}
+
+	return nil
+}
+
+// AddRegion marks this product as sold in the given region, ignoring unknown regions
+func (p *Product) AddRegion(region string) {
+	normalizedRegion := NormalizeRegion(region)
+	if normalizedRegion == "" || p.IsSoldInRegion(normalizedRegion) {
+		return
+	}
+
+	p.Regions = append(p.Regions, normalizedRegion)
+}
+
+// IsSoldInRegion returns true if this product is explicitly marked as sold in the given region
+func (p *Product) IsSoldInRegion(region string) bool {
+	normalizedRegion := NormalizeRegion(region)
+	for _, productRegion := range p.Regions {
+		if productRegion == normalizedRegion {
+			return true
+		}
 	}
+
+	return false
+}
+
+// IsAvailableInRegion returns true if this product is sold in the given region, or if we don't know where the product is sold
+func (p *Product) IsAvailableInRegion(region string) bool {
+	return len(p.Regions) == 0 || p.IsSoldInRegion(region)
 }
 
-// UpdateHelmetCertificationsByDescription updates the DOT and/or ECE certifications if the given description contains certain keywords indicating that the product has said certifications and returns booleans indicating whether or not updates occurred.
+// GetDisplayModel returns the name that this product is sold under in the given region, falling back to the alias flagged for display and then the model
+func (p *Product) GetDisplayModel(region string) string {
+	normalizedRegion := NormalizeRegion(region)
+	var displayAlias *ProductModelAlias
+	for _, modelAlias := range p.ModelAliases {
+		if modelAlias == nil {
+			continue
+		}
+
+		if normalizedRegion != "" && NormalizeRegion(modelAlias.Region) == normalizedRegion {
+			return modelAlias.ModelAlias
+		}
+
+		if modelAlias.IsForDisplay && displayAlias == nil {
+			displayAlias = modelAlias
+		}
+	}
+
+	// When a region is requested but has no alias of its own, the helmet is sold under its model name there
+	if normalizedRegion != "" || displayAlias == nil {
+		return p.Model
+	}
+
+	return displayAlias.ModelAlias
+}
+
+// UpdateHelmetCertificationsByDescription updates the DOT and/or ECE certifications (including the ECE version) if the given description contains certain keywords indicating that the product has said certifications and returns booleans indicating whether or not updates occurred.
 func (p *Product) UpdateHelmetCertificationsByDescription(productDescription string) (bool, bool) {
 	lowerDescription := strings.ToLower(productDescription)
 
 	// DOT and ECE are only 3 letters and are very common substrings, so it's better to use the real description and compare against that (the lowercase description probably has "dot" and "ece" in various words)
 	containsDOT := strings.Contains(productDescription, "DOT") || strings.Contains(productDescription, "D.O.T")
-	containsECE := strings.Contains(productDescription, "ECE") || strings.Contains(productDescription, "22/05") || strings.Contains(productDescription, "22.05")
+	eceVersion := ParseECEVersion(productDescription)
+	containsECE := strings.Contains(productDescription, "ECE") || eceVersion != ""
 
 	// SNELL is an "uncommon" enough substring that it's better to use the lower description
-	containsSNELL := strings.Contains(lowerDescription, "snell") || strings.Contains(lowerDescription, "m2010") || strings.Contains(lowerDescription, "m2015")
+	containsSNELL := strings.Contains(lowerDescription, "snell") || strings.Contains(lowerDescription, "m2010") || strings.Contains(lowerDescription, "m2015") || strings.Contains(lowerDescription, "m2020")
 
 	hasNewDOTCertification := false
 	hasNewECECertification := false
@@ -95,14 +447,78 @@ func (p *Product) UpdateHelmetCertificationsByDescription(productDescription str
 		hasNewDOTCertification = true
 	}
 
-	if !p.HelmetCertifications.ECE && containsECE {
-		p.HelmetCertifications.ECE = true
-		hasNewECECertification = true
+	if containsECE {
+		hasNewECECertification = p.UpdateECECertification(eceVersion)
 	}
 
 	return hasNewDOTCertification, hasNewECECertification
 }
 
+// UpdateSNELLCertification marks the helmet as SNELL certified to the given standard, keeping the existing standard if it is more recent, and returns true if an update occurred.
+func (p *Product) UpdateSNELLCertification(standard string) bool {
+	normalizedStandard := NormalizeSNELLStandard(standard)
+	if p.HelmetCertifications.SNELL && GetSNELLStandardRank(p.HelmetCertifications.SNELLStandard) >= GetSNELLStandardRank(normalizedStandard) {
+		return false
+	}
+
+	p.HelmetCertifications.SNELL = true
+	p.HelmetCertifications.SNELLStandard = normalizedStandard
+
+	// SNELL certification implies DOT certification
+	p.HelmetCertifications.DOT = true
+	return true
+}
+
+// UpdateECECertification marks the helmet as ECE certified to the given version, keeping the existing version if it is more recent, and returns true if an update occurred. An empty version only marks the helmet as ECE certified.
+func (p *Product) UpdateECECertification(version string) bool {
+	normalizedVersion := NormalizeECEVersion(version)
+	if p.HelmetCertifications.ECE && GetECEVersionRank(p.HelmetCertifications.ECEVersion) >= GetECEVersionRank(normalizedVersion) {
+		return false
+	}
+
+	p.HelmetCertifications.ECE = true
+	if normalizedVersion != "" {
+		p.HelmetCertifications.ECEVersion = normalizedVersion
+	}
+	return true
+}
+
+// UpdateFIMCertification marks the helmet as homologated to the given FIM standard, keeping the existing homologation if it is more recent, and returns true if an update occurred.
+func (p *Product) UpdateFIMCertification(standard string, homologationNumber string) bool {
+	normalizedStandard := NormalizeFIMStandard(standard)
+	if normalizedStandard == "" {
+		return false
+	}
+
+	if p.HelmetCertifications.FIM != nil && GetFIMStandardRank(p.HelmetCertifications.FIM.Standard) >= GetFIMStandardRank(normalizedStandard) {
+		return false
+	}
+
+	p.HelmetCertifications.FIM = &FIMCertification{Standard: normalizedStandard, HomologationNumber: strings.TrimSpace(homologationNumber)}
+
+	// FIM homologation requires the helmet to already be ECE certified
+	p.UpdateECECertification(GetECEVersionRequiredByFIMStandard(normalizedStandard))
+	return true
+}
+
+// getECEMultiplier only reduces the credit for helmets that are known to be certified to a superseded version, so that helmets imported before the version was tracked keep the score they had
+func (p *Product) getECEMultiplier() float64 {
+	version := p.HelmetCertifications.ECEVersion
+	if version == "" || IsCurrentECEVersion(version) {
+		return 1
+	}
+	return supersededECEMultiplier
+}
+
+// getSNELLMultiplier only reduces the credit for helmets that are known to be certified to a superseded standard, so that helmets imported before the standard was tracked keep the score they had
+func (p *Product) getSNELLMultiplier() float64 {
+	standard := p.HelmetCertifications.SNELLStandard
+	if standard == "" || IsCurrentSNELLStandard(standard) {
+		return 1
+	}
+	return supersededSNELLMultiplier
+}
+
 // UpdateGenericSubtypeByDescriptionParts updates the subtype when certain text appears in each part of the description
 func (p *Product) UpdateGenericSubtypeByDescriptionParts(productDescriptionParts []string) {
 	for _, part := range productDescriptionParts {
@@ -193,7 +609,7 @@ func (p *Product) UpdateSingleZoneCertificationsByDescriptionParts(zone *CEImpac
 	return updatedZone, newCEImpactZone
 }
 
-// UpdatePantsCertificationsByDescriptionParts updates all of the pants certifications when certain text appears in each part of the description
+// UpdatePantsCertificationsByDescriptionParts updates all of the pants certifications (including the EN 17092 garment class) when certain text appears in each part of the description
 func (p *Product) UpdatePantsCertificationsByDescriptionParts(productDescriptionParts []string) (bool, bool, bool) {
 	updatedTailbone := false
 	updatedHip := false
@@ -203,19 +619,30 @@ func (p *Product) UpdatePantsCertificationsByDescriptionParts(productDescription
 		newCEImpactZone, isCertified := estimateCEImpactZoneByDescriptionPart(part)
 		lowerPart := strings.ToLower(part)
 
+		garmentClass := ParseGarmentClass(part)
+		if GetGarmentClassRank(garmentClass) > GetGarmentClassRank(p.PantsCertifications.GarmentClass) {
+			p.PantsCertifications.GarmentClass = garmentClass
+		}
+
 		if isCertified || newCEImpactZone.IsApproved || newCEImpactZone.IsEmpty {
-			if newCEImpactZone.IsSaferThan(p.PantsCertifications.Tailbone) && strings.Contains(lowerPart, "tailbone") {
-				p.PantsCertifications.Tailbone = newCEImpactZone
+			standards := ParseEN1621Standards(part)
+
+			// Tailbone protectors are either limb armor or a cut down back protector
+			tailboneZone := newCEImpactZone.forSlot(standards, CEStandardEN16211, CEStandardEN16212)
+			if tailboneZone.IsSaferThan(p.PantsCertifications.Tailbone) && strings.Contains(lowerPart, "tailbone") {
+				p.PantsCertifications.Tailbone = tailboneZone
 				updatedTailbone = true
 			}
 
-			if newCEImpactZone.IsSaferThan(p.PantsCertifications.Hip) && strings.Contains(lowerPart, "hip") {
-				p.PantsCertifications.Hip = newCEImpactZone
+			hipZone := newCEImpactZone.forSlot(standards, CEStandardEN16211)
+			if hipZone.IsSaferThan(p.PantsCertifications.Hip) && strings.Contains(lowerPart, "hip") {
+				p.PantsCertifications.Hip = hipZone
 				updatedHip = true
 			}
 
-			if newCEImpactZone.IsSaferThan(p.PantsCertifications.Knee) && strings.Contains(lowerPart, "knee") {
-				p.PantsCertifications.Knee = newCEImpactZone
+			kneeZone := newCEImpactZone.forSlot(standards, CEStandardEN16211)
+			if kneeZone.IsSaferThan(p.PantsCertifications.Knee) && strings.Contains(lowerPart, "knee") {
+				p.PantsCertifications.Knee = kneeZone
 				updatedKnee = true
 			}
 		}
@@ -224,7 +651,7 @@ func (p *Product) UpdatePantsCertificationsByDescriptionParts(productDescription
 	return updatedTailbone, updatedHip, updatedKnee
 }
 
-// UpdateJacketCertificationsByDescriptionParts updates all of the jacket certifications when certain text appears in each part of the description
+// UpdateJacketCertificationsByDescriptionParts updates all of the jacket certifications (including the EN 17092 garment class) when certain text appears in each part of the description
 func (p *Product) UpdateJacketCertificationsByDescriptionParts(productDescriptionParts []string) (bool, bool, bool, bool, bool) {
 	updatedAirbag := false
 	updatedBack := false
@@ -242,24 +669,35 @@ func (p *Product) UpdateJacketCertificationsByDescriptionParts(productDescriptio
 			updatedAirbag = true
 		}
 
+		garmentClass := ParseGarmentClass(part)
+		if GetGarmentClassRank(garmentClass) > GetGarmentClassRank(p.JacketCertifications.GarmentClass) {
+			p.JacketCertifications.GarmentClass = garmentClass
+		}
+
 		if isCertified || newCEImpactZone.IsApproved || newCEImpactZone.IsEmpty {
-			if newCEImpactZone.IsSaferThan(p.JacketCertifications.Back) && strings.Contains(lowerPart, "back") {
-				p.JacketCertifications.Back = newCEImpactZone
+			standards := ParseEN1621Standards(part)
+
+			backZone := newCEImpactZone.forSlot(standards, CEStandardEN16212)
+			if backZone.IsSaferThan(p.JacketCertifications.Back) && strings.Contains(lowerPart, "back") {
+				p.JacketCertifications.Back = backZone
 				updatedBack = true
 			}
 
-			if newCEImpactZone.IsSaferThan(p.JacketCertifications.Elbow) && strings.Contains(lowerPart, "elbow") {
-				p.JacketCertifications.Elbow = newCEImpactZone
+			elbowZone := newCEImpactZone.forSlot(standards, CEStandardEN16211)
+			if elbowZone.IsSaferThan(p.JacketCertifications.Elbow) && strings.Contains(lowerPart, "elbow") {
+				p.JacketCertifications.Elbow = elbowZone
 				updatedElbow = true
 			}
 
-			if newCEImpactZone.IsSaferThan(p.JacketCertifications.Shoulder) && strings.Contains(lowerPart, "shoulder") {
-				p.JacketCertifications.Shoulder = newCEImpactZone
+			shoulderZone := newCEImpactZone.forSlot(standards, CEStandardEN16211)
+			if shoulderZone.IsSaferThan(p.JacketCertifications.Shoulder) && strings.Contains(lowerPart, "shoulder") {
+				p.JacketCertifications.Shoulder = shoulderZone
 				updatedShoulder = true
 			}
 
-			if newCEImpactZone.IsSaferThan(p.JacketCertifications.Chest) && strings.Contains(lowerPart, "chest") {
-				p.JacketCertifications.Chest = newCEImpactZone
+			chestZone := newCEImpactZone.forSlot(standards, CEStandardEN16213)
+			if chestZone.IsSaferThan(p.JacketCertifications.Chest) && strings.Contains(lowerPart, "chest") {
+				p.JacketCertifications.Chest = chestZone
 				updatedChest = true
 			}
 		}
@@ -268,14 +706,138 @@ func (p *Product) UpdateJacketCertificationsByDescriptionParts(productDescriptio
 	return updatedBack, updatedElbow, updatedShoulder, updatedChest, updatedAirbag
 }
 
-func (p *Product) getSingleZoneSafetyPercentage(zone *CEImpactZone) int {
+// UpdateBootsCertificationsByDescriptionParts updates the EN 13634 boots ratings when they appear in any part of the description and returns true if an update occurred
+func (p *Product) UpdateBootsCertificationsByDescriptionParts(productDescriptionParts []string) bool {
+	updatedEN13634 := false
+	for _, part := range productDescriptionParts {
+		rating := ParseEN13634Rating(part)
+		if rating != nil && rating.IsSaferThan(p.BootsCertifications.EN13634) {
+			p.BootsCertifications.EN13634 = rating
+			updatedEN13634 = true
+		}
+	}
+
+	return updatedEN13634
+}
+
+// UpdateGlovesCertificationsByDescriptionParts updates the EN 13594 gloves rating (including knuckle protection and palm sliders) when certain text appears in each part of the description and returns true if an update occurred
+func (p *Product) UpdateGlovesCertificationsByDescriptionParts(productDescriptionParts []string) bool {
+	newRating := EN13594Rating{}
+	if p.GlovesCertifications.EN13594 != nil {
+		newRating = *p.GlovesCertifications.EN13594
+	}
+
+	for _, part := range productDescriptionParts {
+		if level := ParseEN13594Level(part); level > newRating.Level {
+			newRating.Level = level
+		}
+
+		if HasEN13594KnuckleProtection(part) {
+			newRating.HasKnuckleProtection = true
+		}
+
+		if HasPalmSlider(part) {
+			newRating.HasPalmSlider = true
+		}
+	}
+
+	if newRating == (EN13594Rating{}) || (p.GlovesCertifications.EN13594 != nil && newRating == *p.GlovesCertifications.EN13594) {
+		return false
+	}
+
+	p.GlovesCertifications.EN13594 = &newRating
+	return true
+}
+
+func (p *Product) getBootsSafetyPercentage() int {
+	if p.BootsCertifications.EN13634 != nil {
+		return int(math.Round(p.BootsCertifications.EN13634.GetScore() * 100))
+	}
+
+	// Boots without EN 13634 ratings can only get partial credit from generic CE keywords in the description, since we don't know which attributes were tested
+	totalScore := float64(0)
+	if p.BootsCertifications.Overall != nil {
+		totalScore += p.BootsCertifications.Overall.GetScore() * float64(0.5)
+	}
+
+	return int(math.Round(totalScore * 100))
+}
+
+// UpdateAirbagCertificationsByDescriptionParts updates the airbag certification and coverage when certain text appears in each part of the description and returns true if an update occurred
+func (p *Product) UpdateAirbagCertificationsByDescriptionParts(productDescriptionParts []string) bool {
+	updatedAirbag := false
+	for _, part := range productDescriptionParts {
+		if p.AirbagCertifications.updateByDescriptionPart(part) {
+			updatedAirbag = true
+		}
+	}
+
+	return updatedAirbag
+}
+
+// UpdateProtectorCertificationsByDescriptionParts updates the back and chest protector certifications when certain text appears in each part of the description
+func (p *Product) UpdateProtectorCertificationsByDescriptionParts(productDescriptionParts []string) (bool, bool) {
+	updatedBack := false
+	updatedChest := false
+
+	for _, part := range productDescriptionParts {
+		newCEImpactZone, isCertified := estimateCEImpactZoneByDescriptionPart(part)
+		lowerPart := strings.ToLower(part)
+
+		// A standalone protector is the armor itself, so only certified zones are recorded
+		if (isCertified || newCEImpactZone.IsApproved) && !newCEImpactZone.IsEmpty {
+			standards := ParseEN1621Standards(part)
+
+			backZone := newCEImpactZone.forSlot(standards, CEStandardEN16212)
+			if backZone.IsSaferThan(p.ProtectorCertifications.Back) && (strings.Contains(lowerPart, "back") || containsString(standards, CEStandardEN16212)) {
+				p.ProtectorCertifications.Back = backZone
+				updatedBack = true
+			}
+
+			chestZone := newCEImpactZone.forSlot(standards, CEStandardEN16213)
+			if chestZone.IsSaferThan(p.ProtectorCertifications.Chest) && (strings.Contains(lowerPart, "chest") || containsString(standards, CEStandardEN16213)) {
+				p.ProtectorCertifications.Chest = chestZone
+				updatedChest = true
+			}
+		}
+	}
+
+	return updatedBack, updatedChest
+}
+
+func (p *Product) getAirbagSafetyPercentage() int {
+	return int(math.Round(p.AirbagCertifications.GetScore() * 100))
+}
+
+func (p *Product) getProtectorSafetyPercentage() int {
 	totalScore := float64(0)
-	if zone != nil {
-		totalScore += zone.GetScore() * float64(0.5)
+
+	// Most standalone protectors are back protectors, so weight the back the highest, but still reward combined back and chest protectors
+	if p.ProtectorCertifications.Back != nil {
+		totalScore += p.ProtectorCertifications.Back.GetScore() * float64(0.75)
 	}
 
-	if p.Materials == "leather" || p.Materials == "kevlar" {
-		totalScore += float64(0.5)
+	if p.ProtectorCertifications.Chest != nil {
+		totalScore += p.ProtectorCertifications.Chest.GetScore() * float64(0.25)
+	}
+
+	return int(math.Round(totalScore * 100))
+}
+
+func (p *Product) getGlovesSafetyPercentage() int {
+	rating := p.GlovesCertifications.EN13594
+	if rating != nil && rating.Level > 0 {
+		return int(math.Round(rating.GetScore() * 100))
+	}
+
+	// Gloves without an EN 13594 level can only get partial credit from generic CE keywords in the description
+	totalScore := float64(0)
+	if p.GlovesCertifications.Overall != nil {
+		totalScore += p.GlovesCertifications.Overall.GetScore() * float64(0.5)
+	}
+
+	if rating != nil {
+		totalScore += rating.GetScore()
 	}
 
 	return int(math.Round(totalScore * 100))
@@ -291,9 +853,8 @@ func (p *Product) getPantsSafetyPercentage() int {
 		}
 	}
 
-	if p.Materials == "leather" || p.Materials == "kevlar" || p.Materials == "covec" {
-		totalScore += float64(0.15)
-	}
+	// The EN 17092 class captures abrasion resistance and seam strength for the whole garment, which the per-zone armor ratings don't
+	totalScore += GetGarmentClassScore(p.PantsCertifications.GarmentClass) * float64(0.15)
 
 	return int(math.Round(totalScore * 100))
 }
@@ -308,9 +869,8 @@ func (p *Product) getJacketSafetyPercentage() int {
 		}
 	}
 
-	if p.Materials == "leather" {
-		totalScore += float64(0.10)
-	}
+	// The EN 17092 class captures abrasion resistance and seam strength for the whole garment, which the per-zone armor ratings don't
+	totalScore += GetGarmentClassScore(p.JacketCertifications.GarmentClass) * float64(0.10)
 
 	if p.JacketCertifications.FitsAirbag {
 		totalScore += float64(0.05)
@@ -341,12 +901,12 @@ func (p *Product) getHelmetSafetyPercentage() int {
 
 	// SNELL is rated slightly higher than ECE or DOT because they're an independent testing agency and publish their results online, but they don't have detailed enough crash test ratings and use manufacturer-supplied helmets
 	if p.HelmetCertifications.SNELL {
-		totalScore += snellWeightToUse
+		totalScore += snellWeightToUse * p.getSNELLMultiplier()
 	}
 
 	// ECE is the minimum standard required for helmet use in the EU, and helmets must be proven to meet this standard before being sold (not based on the honor system!)
 	if p.HelmetCertifications.ECE {
-		totalScore += eceWeightToUse
+		totalScore += eceWeightToUse * p.getECEMultiplier()
 	}
 
 	// DOT is pretty much useless since it's based off the honor system, hence a very low weight
@@ -354,6 +914,11 @@ func (p *Product) getHelmetSafetyPercentage() int {
 		totalScore += dotWeightToUse
 	}
 
+	// FIM homologation is a bonus, so cap the score to avoid going over 100%
+	if p.HelmetCertifications.FIM != nil {
+		totalScore = math.Min(totalScore+fimBonusWeight, 1)
+	}
+
 	return int(math.Round(totalScore * 100))
 }
 
@@ -379,10 +944,16 @@ func (p *Product) UpdateSafetyPercentage() {
 		safetyPercentage = p.getPantsSafetyPercentage()
 		break
 	case "boots":
-		safetyPercentage = p.getSingleZoneSafetyPercentage(p.BootsCertifications.Overall)
+		safetyPercentage = p.getBootsSafetyPercentage()
 		break
 	case "gloves":
-		safetyPercentage = p.getSingleZoneSafetyPercentage(p.GlovesCertifications.Overall)
+		safetyPercentage = p.getGlovesSafetyPercentage()
+		break
+	case "airbag":
+		safetyPercentage = p.getAirbagSafetyPercentage()
+		break
+	case "protector":
+		safetyPercentage = p.getProtectorSafetyPercentage()
 		break
 	}
 
@@ -1,9 +1,29 @@
 package entities
 
+import "strings"
+
 // ProductModelAlias represents an alias for a given Manufacturer + Model pair. i.e. Shoei XR-1100 is known as the Shoei RF-1100 in the USA (alias)
 type ProductModelAlias struct {
 	Manufacturer string `json:"manufacturer"`
 	Model        string `json:"model"`
 	ModelAlias   string `json:"modelAlias"`
 	IsForDisplay bool   `json:"isForDisplay"`
+	Region       string `json:"region"` // the region this alias is the display name in, empty if the alias is only used for matching
+}
+
+// knownRegions contains every region that products can be sold in
+var knownRegions = map[string]bool{
+	RegionUS: true,
+	RegionUK: true,
+	RegionEU: true,
+}
+
+// NormalizeRegion upper-cases the given region and returns an empty string if the region is not recognized
+func NormalizeRegion(region string) string {
+	normalizedRegion := strings.ToUpper(strings.TrimSpace(region))
+	if !knownRegions[normalizedRegion] {
+		return ""
+	}
+
+	return normalizedRegion
 }
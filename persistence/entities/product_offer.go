@@ -0,0 +1,18 @@
+package entities
+
+import "time"
+
+// ProductOffer represents a single retailer's current price and availability for a product. Each product has at most one offer per retailer.
+type ProductOffer struct {
+	Retailer      string    `json:"retailer"`
+	PriceCents    int       `json:"priceCents"`
+	Currency      string    `json:"currency"`
+	BuyURL        string    `json:"buyURL"`
+	IsInStock     bool      `json:"isInStock"`
+	LastSeenAtUTC time.Time `json:"lastSeenAtUTC"`
+}
+
+// IsCurrent returns true if this offer can currently be bought i.e. the retailer has it in stock at a real price
+func (o *ProductOffer) IsCurrent() bool {
+	return o.IsInStock && o.PriceCents > 0
+}
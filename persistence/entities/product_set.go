@@ -30,6 +30,12 @@ type ProductSet struct {
 
 	GlovesProductID *int
 	GlovesProduct   *Product
+
+	AirbagProductID *int
+	AirbagProduct   *Product
+
+	ProtectorProductID *int
+	ProtectorProduct   *Product
 }
 
 // AddOrReplaceProduct adds or overwrites a given product on this product set, using the type to determine which product to create/update.
@@ -54,6 +60,12 @@ func (p *ProductSet) AddOrReplaceProduct(product *Product) error {
 	case ProductTypeGloves:
 		p.GlovesProduct = product
 		p.GlovesProductID = &product.ID
+	case ProductTypeAirbag:
+		p.AirbagProduct = product
+		p.AirbagProductID = &product.ID
+	case ProductTypeProtector:
+		p.ProtectorProduct = product
+		p.ProtectorProductID = &product.ID
 	default:
 		return fmt.Errorf("Unexpected product type %s", product.Type)
 	}
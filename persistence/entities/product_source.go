@@ -0,0 +1,9 @@
+package entities
+
+import "time"
+
+// ProductSource tracks when a product was last listed by a single source that we sync from (i.e. RevZilla's listing pages) and how many syncs in a row have not listed it
+type ProductSource struct {
+	LastSeenAtUTC     time.Time `json:"lastSeenAtUTC"`
+	ConsecutiveMisses int       `json:"consecutiveMisses"`
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	. "github.com/onsi/gomega"
@@ -139,6 +140,146 @@ func Test_CalculateSafetyPercentage_should_return_correctly_when_the_product_has
 	Expect(product.SafetyPercentage).To(Equal(62))
 }
 
+func Test_CalculateSafetyPercentage_should_return_partial_SNELL_credit_when_the_product_has_a_superseded_snell_certification(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{ImageKey: "google.com/lol.png", Manufacturer: "Manufacturer5", Model: "RF-SR3", MSRPCents: 70099, Type: "helmet", Subtype: "full", SafetyPercentage: -1234}
+	product.HelmetCertifications.SNELL = true
+	product.HelmetCertifications.SNELLStandard = SNELLStandardM2015
+	product.UpdateSafetyPercentage()
+
+	Expect(product.SafetyPercentage).To(Equal(49))
+}
+
+func Test_CalculateSafetyPercentage_should_return_full_SNELL_and_ECE_credit_when_the_standards_are_unknown(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{ImageKey: "google.com/lol.png", Manufacturer: "Manufacturer5", Model: "RF-SR3", MSRPCents: 70099, Type: "helmet", Subtype: "full", SafetyPercentage: -1234}
+	product.HelmetCertifications.SNELL = true
+	product.HelmetCertifications.ECE = true
+	product.UpdateSafetyPercentage()
+	unknownStandardsPercentage := product.SafetyPercentage
+
+	product.HelmetCertifications.SNELLStandard = SNELLStandardM2020D
+	product.HelmetCertifications.ECEVersion = ECEVersion2206
+	product.UpdateSafetyPercentage()
+
+	Expect(unknownStandardsPercentage).To(Equal(75))
+	Expect(product.SafetyPercentage).To(Equal(unknownStandardsPercentage))
+}
+
+func Test_CalculateSafetyPercentage_should_return_partial_ECE_credit_when_the_product_has_an_ECE_22_05_certification(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{ImageKey: "google.com/lol.png", Manufacturer: "Manufacturer5", Model: "RF-SR3", MSRPCents: 70099, Type: "helmet", Subtype: "full", SafetyPercentage: -1234}
+	product.HelmetCertifications.ECE = true
+	product.HelmetCertifications.ECEVersion = ECEVersion2205
+	product.UpdateSafetyPercentage()
+
+	Expect(product.SafetyPercentage).To(Equal(8))
+}
+
+func Test_UpdateHelmetCertificationsByDescription_should_parse_the_ECE_version(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{Type: "helmet"}
+
+	hasNewDOTCertification, hasNewECECertification := product.UpdateHelmetCertificationsByDescription("DOT and ECE 22/05 approved")
+	Expect(hasNewDOTCertification).To(BeTrue())
+	Expect(hasNewECECertification).To(BeTrue())
+	Expect(product.HelmetCertifications.ECE).To(BeTrue())
+	Expect(product.HelmetCertifications.ECEVersion).To(Equal(ECEVersion2205))
+
+	_, hasNewECECertification = product.UpdateHelmetCertificationsByDescription("Meets the new ECE 22.06 standard")
+	Expect(hasNewECECertification).To(BeTrue())
+	Expect(product.HelmetCertifications.ECEVersion).To(Equal(ECEVersion2206))
+
+	_, hasNewECECertification = product.UpdateHelmetCertificationsByDescription("ECE 22.05")
+	Expect(hasNewECECertification).To(BeFalse())
+	Expect(product.HelmetCertifications.ECEVersion).To(Equal(ECEVersion2206))
+}
+
+func Test_ParseECEVersion_should_only_match_versions_written_as_an_ECE_standard(t *testing.T) {
+	RegisterTestingT(t)
+	Expect(ParseECEVersion("ECE 22.05 P/J")).To(Equal(ECEVersion2205))
+	Expect(ParseECEVersion("Certified to ECE R22-06")).To(Equal(ECEVersion2206))
+	Expect(ParseECEVersion("UN ECE 22/05 and ECE 22.06")).To(Equal(ECEVersion2206))
+	Expect(ParseECEVersion("Meets 22.06")).To(Equal(ECEVersion2206))
+
+	Expect(ParseECEVersion("Replacement visor part number 1022-05-XL")).To(BeEmpty())
+	Expect(ParseECEVersion("Pinlock 22-05 insert")).To(BeEmpty())
+	Expect(ParseECEVersion("Released 10/22/05")).To(BeEmpty())
+	Expect(ParseECEVersion("Version 1.22.05")).To(BeEmpty())
+}
+
+func Test_UpdateHelmetCertificationsByDescription_should_not_set_an_ECE_version_when_none_is_mentioned(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{Type: "helmet"}
+
+	_, hasNewECECertification := product.UpdateHelmetCertificationsByDescription("ECE certified")
+	Expect(hasNewECECertification).To(BeTrue())
+	Expect(product.HelmetCertifications.ECE).To(BeTrue())
+	Expect(product.HelmetCertifications.ECEVersion).To(BeEmpty())
+}
+
+func Test_CalculateSafetyPercentage_should_add_a_bonus_when_the_product_has_an_FIM_homologation(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{ImageKey: "google.com/lol.png", Manufacturer: "Manufacturer5", Model: "RF-SR3", MSRPCents: 70099, Type: "helmet", Subtype: "full", SafetyPercentage: -1234}
+	product.HelmetCertifications.SNELL = true
+	product.HelmetCertifications.SNELLStandard = SNELLStandardM2020D
+	product.UpdateFIMCertification("FRHPhe-02", "FIM-0001")
+	product.UpdateSafetyPercentage()
+
+	Expect(product.SafetyPercentage).To(Equal(80))
+}
+
+func Test_CalculateSafetyPercentage_should_not_exceed_100_when_the_product_has_an_FIM_homologation_and_all_other_certifications(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{ImageKey: "google.com/lol.png", Manufacturer: "Manufacturer5", Model: "RF-SR3", MSRPCents: 70099, Type: "helmet", Subtype: "full", SafetyPercentage: -1234}
+	product.HelmetCertifications.DOT = true
+	product.HelmetCertifications.SHARP = &SHARPCertification{}
+	product.HelmetCertifications.SHARP.Stars = 5
+	product.HelmetCertifications.SHARP.ImpactZoneRatings = &SHARPImpactZoneRatings{}
+	product.HelmetCertifications.SHARP.ImpactZoneRatings.Left = 5
+	product.HelmetCertifications.SHARP.ImpactZoneRatings.Right = 5
+	product.HelmetCertifications.SHARP.ImpactZoneRatings.Rear = 5
+	product.HelmetCertifications.SHARP.ImpactZoneRatings.Top.Front = 5
+	product.HelmetCertifications.SHARP.ImpactZoneRatings.Top.Rear = 5
+	product.HelmetCertifications.SNELL = true
+	product.HelmetCertifications.SNELLStandard = SNELLStandardM2020D
+	product.UpdateFIMCertification("FRHPhe-02", "FIM-0001")
+	product.UpdateSafetyPercentage()
+
+	Expect(product.SafetyPercentage).To(Equal(100))
+}
+
+func Test_UpdateFIMCertification_should_keep_the_most_recent_standard_and_imply_an_ECE_certification(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{Type: "helmet"}
+
+	Expect(product.UpdateFIMCertification("frhphe 01", " FIM-0001 ")).To(BeTrue())
+	Expect(product.HelmetCertifications.FIM).To(Equal(&FIMCertification{Standard: FIMStandardFRHPhe01, HomologationNumber: "FIM-0001"}))
+	Expect(product.HelmetCertifications.ECE).To(BeTrue())
+	Expect(product.HelmetCertifications.ECEVersion).To(Equal(ECEVersion2205))
+
+	Expect(product.UpdateFIMCertification(FIMStandardFRHPhe02, "FIM-0002")).To(BeTrue())
+	Expect(product.HelmetCertifications.FIM.Standard).To(Equal(FIMStandardFRHPhe02))
+	Expect(product.HelmetCertifications.ECEVersion).To(Equal(ECEVersion2206))
+
+	Expect(product.UpdateFIMCertification(FIMStandardFRHPhe01, "FIM-0003")).To(BeFalse())
+	Expect(product.UpdateFIMCertification("FRHPhe-99", "FIM-0004")).To(BeFalse())
+	Expect(product.HelmetCertifications.FIM.HomologationNumber).To(Equal("FIM-0002"))
+}
+
+func Test_UpdateSNELLCertification_should_keep_the_most_recent_standard(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{Type: "helmet"}
+
+	Expect(product.UpdateSNELLCertification(" m2020r")).To(BeTrue())
+	Expect(product.HelmetCertifications.SNELLStandard).To(Equal(SNELLStandardM2020R))
+	Expect(product.HelmetCertifications.SNELL).To(BeTrue())
+	Expect(product.HelmetCertifications.DOT).To(BeTrue())
+
+	Expect(product.UpdateSNELLCertification(SNELLStandardM2015)).To(BeFalse())
+	Expect(product.HelmetCertifications.SNELLStandard).To(Equal(SNELLStandardM2020R))
+}
+
 func Test_CalculateSafetyPercentage_should_return_a_full_safety_score_when_the_product_is_a_jacket_with_all_parts_certified(t *testing.T) {
 	RegisterTestingT(t)
 	product := &Product{ImageKey: "google.com/lol.png", Manufacturer: "Manufacturer5", Model: "RF-SR3", MSRPCents: 70099, Type: "jacket", Subtype: "leather", Materials: "leather", SafetyPercentage: -1234}
@@ -148,6 +289,7 @@ func Test_CalculateSafetyPercentage_should_return_a_full_safety_score_when_the_p
 	product.JacketCertifications.Elbow = fullImpactZone
 	product.JacketCertifications.Shoulder = fullImpactZone
 	product.JacketCertifications.FitsAirbag = true
+	product.JacketCertifications.GarmentClass = GarmentClassAAA
 	product.UpdateSafetyPercentage()
 
 	Expect(product.SafetyPercentage).To(Equal(100))
@@ -187,6 +329,491 @@ func Test_CalculateSafetyPercentage_should_return_64_when_the_product_is_a_jacke
 	Expect(product.SafetyPercentage).To(Equal(68))
 }
 
+func Test_CalculateSafetyPercentage_should_not_give_leather_jackets_credit_without_a_garment_class(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{ImageKey: "google.com/lol.png", Manufacturer: "Manufacturer5", Model: "RF-SR3", MSRPCents: 70099, Type: "jacket", Subtype: "leather", Materials: "leather", SafetyPercentage: -1234}
+	product.UpdateSafetyPercentage()
+
+	Expect(product.SafetyPercentage).To(Equal(0))
+}
+
+func Test_CalculateSafetyPercentage_should_give_partial_credit_for_lower_garment_classes(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{ImageKey: "google.com/lol.png", Manufacturer: "Manufacturer5", Model: "RF-SR3", MSRPCents: 70099, Type: "pants", SafetyPercentage: -1234}
+	fullImpactZone := &CEImpactZone{IsApproved: true, IsLevel2: true}
+	product.PantsCertifications.Hip = fullImpactZone
+	product.PantsCertifications.Knee = fullImpactZone
+	product.PantsCertifications.Tailbone = fullImpactZone
+	product.PantsCertifications.GarmentClass = GarmentClassA
+	product.UpdateSafetyPercentage()
+
+	Expect(product.SafetyPercentage).To(Equal(94))
+}
+
+func Test_ParseGarmentClass_should_parse_the_class_from_common_formats(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(ParseGarmentClass("Certified to EN 17092-4:2020 AA")).To(Equal(GarmentClassAA))
+	Expect(ParseGarmentClass("EN17092 Class AAA certified")).To(Equal(GarmentClassAAA))
+	Expect(ParseGarmentClass("CE certified to EN 17092 - A")).To(Equal(GarmentClassA))
+	Expect(ParseGarmentClass("Class B garment according to EN 17092")).To(Equal(GarmentClassB))
+	Expect(ParseGarmentClass("allow the jacket to meet CE - Cat II - prEN 17092 certification")).To(BeEmpty())
+	Expect(ParseGarmentClass("Class AA touring jacket")).To(BeEmpty())
+}
+
+func Test_CalculateSafetyPercentage_should_use_the_EN_13634_ratings_when_the_product_is_rated_boots(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{ImageKey: "google.com/lol.png", Manufacturer: "Manufacturer5", Model: "RF-SR3", MSRPCents: 70099, Type: "boots", Materials: "leather", SafetyPercentage: -1234}
+	product.BootsCertifications.Overall = &CEImpactZone{IsApproved: true, IsLevel2: true}
+	product.BootsCertifications.EN13634 = &EN13634Rating{Height: 1, Abrasion: 2, ImpactCut: 1, TransverseRigidity: 1}
+	product.UpdateSafetyPercentage()
+
+	Expect(product.SafetyPercentage).To(Equal(63))
+}
+
+func Test_CalculateSafetyPercentage_should_give_partial_credit_when_the_product_is_unrated_boots(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{ImageKey: "google.com/lol.png", Manufacturer: "Manufacturer5", Model: "RF-SR3", MSRPCents: 70099, Type: "boots", Materials: "leather", SafetyPercentage: -1234}
+	product.BootsCertifications.Overall = &CEImpactZone{IsApproved: true, IsLevel2: true}
+	product.UpdateSafetyPercentage()
+
+	Expect(product.SafetyPercentage).To(Equal(50))
+}
+
+func Test_UpdateBootsCertificationsByDescriptionParts_should_apply_the_highest_EN_13634_ratings(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{Type: "boots"}
+
+	updated := product.UpdateBootsCertificationsByDescriptionParts([]string{
+		"Full grain leather construction",
+		"CE certified to EN 13634:2015 1/2/1/1",
+		"Certified to EN13634:2017 2 / 2 / 2 / 2",
+		"Replaceable toe sliders",
+	})
+
+	Expect(updated).To(BeTrue())
+	Expect(product.BootsCertifications.EN13634).To(Equal(&EN13634Rating{Height: 2, Abrasion: 2, ImpactCut: 2, TransverseRigidity: 2}))
+}
+
+func Test_ParseEN13634Rating_should_parse_ratings_when_the_year_is_written_without_a_colon(t *testing.T) {
+	RegisterTestingT(t)
+	Expect(ParseEN13634Rating("CE certified to EN 13634 2017 2/1/2/1")).To(Equal(&EN13634Rating{Height: 2, Abrasion: 1, ImpactCut: 2, TransverseRigidity: 1}))
+	Expect(ParseEN13634Rating("EN13634 : 2015 1/2/1/1")).To(Equal(&EN13634Rating{Height: 1, Abrasion: 2, ImpactCut: 1, TransverseRigidity: 1}))
+	Expect(ParseEN13634Rating("EN 13634 2/2/2/2")).To(Equal(&EN13634Rating{Height: 2, Abrasion: 2, ImpactCut: 2, TransverseRigidity: 2}))
+}
+
+func Test_UpdateBootsCertificationsByDescriptionParts_should_not_apply_ratings_when_none_are_listed(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{Type: "boots"}
+
+	updated := product.UpdateBootsCertificationsByDescriptionParts([]string{"CE certified to EN 13634", "Size 12/13 available"})
+
+	Expect(updated).To(BeFalse())
+	Expect(product.BootsCertifications.EN13634).To(BeNil())
+}
+
+func Test_CalculateSafetyPercentage_should_use_the_EN_13594_rating_when_the_product_is_rated_gloves(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{ImageKey: "google.com/lol.png", Manufacturer: "Manufacturer5", Model: "RF-SR3", MSRPCents: 70099, Type: "gloves", SafetyPercentage: -1234}
+	product.GlovesCertifications.Overall = &CEImpactZone{IsApproved: true, IsLevel2: true}
+	product.GlovesCertifications.EN13594 = &EN13594Rating{Level: 2, HasKnuckleProtection: true, HasPalmSlider: true}
+	product.UpdateSafetyPercentage()
+
+	Expect(product.SafetyPercentage).To(Equal(100))
+}
+
+func Test_CalculateSafetyPercentage_should_give_partial_credit_when_the_product_is_gloves_without_an_EN_13594_level(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{ImageKey: "google.com/lol.png", Manufacturer: "Manufacturer5", Model: "RF-SR3", MSRPCents: 70099, Type: "gloves", SafetyPercentage: -1234}
+	product.GlovesCertifications.Overall = &CEImpactZone{IsLevel2: true}
+	product.GlovesCertifications.EN13594 = &EN13594Rating{HasPalmSlider: true}
+	product.UpdateSafetyPercentage()
+
+	Expect(product.SafetyPercentage).To(Equal(53))
+}
+
+func Test_UpdateGlovesCertificationsByDescriptionParts_should_apply_the_EN_13594_level_knuckle_protection_and_palm_sliders(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{Type: "gloves"}
+
+	updated := product.UpdateGlovesCertificationsByDescriptionParts([]string{
+		"Goatskin leather chassis",
+		"CE certified to EN 13594:2015 1KP",
+		"SuperFabric palm sliders",
+	})
+
+	Expect(updated).To(BeTrue())
+	Expect(product.GlovesCertifications.EN13594).To(Equal(&EN13594Rating{Level: 1, HasKnuckleProtection: true, HasPalmSlider: true}))
+
+	updated = product.UpdateGlovesCertificationsByDescriptionParts([]string{"Certified to EN 13594 level 2"})
+	Expect(updated).To(BeTrue())
+	Expect(product.GlovesCertifications.EN13594).To(Equal(&EN13594Rating{Level: 2, HasKnuckleProtection: true, HasPalmSlider: true}))
+
+	updated = product.UpdateGlovesCertificationsByDescriptionParts([]string{"Certified to EN 13594 level 1"})
+	Expect(updated).To(BeFalse())
+}
+
+func Test_UpdateGlovesCertificationsByDescriptionParts_should_only_read_the_level_and_knuckle_protection_from_the_EN_13594_text(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{Type: "gloves"}
+
+	updated := product.UpdateGlovesCertificationsByDescriptionParts([]string{
+		"EN 1621-1 Level 2 knuckle armor, EN 13594 level 1",
+		"Reinforced KP panel on the thumb",
+	})
+
+	Expect(updated).To(BeTrue())
+	Expect(product.GlovesCertifications.EN13594).To(Equal(&EN13594Rating{Level: 1}))
+	Expect(ParseEN13594Level("CE certified to EN 13594:2015 level 1, armor certified to EN 1621-1 level 2")).To(Equal(1))
+	Expect(HasEN13594KnuckleProtection("KP")).To(BeFalse())
+	Expect(HasEN13594KnuckleProtection("EN 13594:2015 Level 1 KP")).To(BeTrue())
+}
+
+func Test_UpdateGlovesCertificationsByDescriptionParts_should_not_apply_a_rating_when_nothing_is_found(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{Type: "gloves"}
+
+	updated := product.UpdateGlovesCertificationsByDescriptionParts([]string{"Touchscreen compatible fingertips", "Hard knuckle armor"})
+
+	Expect(updated).To(BeFalse())
+	Expect(product.GlovesCertifications.EN13594).To(BeNil())
+}
+
+func Test_CalculateSafetyPercentage_should_use_the_airbag_certification_when_the_product_is_an_airbag(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{ImageKey: "google.com/lol.png", Manufacturer: "Manufacturer5", Model: "RF-SR3", MSRPCents: 70099, Type: "airbag", SafetyPercentage: -1234}
+	product.AirbagCertifications = AirbagCertification{IsCertified: true, IsLevel2: true, IsElectronic: true, ProtectsBack: true, ProtectsChest: true, ProtectsNeck: true}
+	product.UpdateSafetyPercentage()
+
+	Expect(product.SafetyPercentage).To(Equal(100))
+
+	product.AirbagCertifications = AirbagCertification{ProtectsBack: true, ProtectsChest: true}
+	product.UpdateSafetyPercentage()
+
+	Expect(product.SafetyPercentage).To(Equal(20))
+}
+
+func Test_UpdateAirbagCertificationsByDescriptionParts_should_apply_the_EN_1621_4_certification_and_coverage(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{Type: "airbag"}
+
+	updated := product.UpdateAirbagCertificationsByDescriptionParts([]string{
+		"Tech-Air 5 electronic airbag system",
+		"Certified to EN 1621-4 level 2",
+		"Protects the back, chest, and neck",
+	})
+
+	Expect(updated).To(BeTrue())
+	Expect(product.AirbagCertifications).To(Equal(AirbagCertification{IsCertified: true, IsLevel2: true, IsElectronic: true, ProtectsBack: true, ProtectsChest: true, ProtectsNeck: true}))
+
+	updated = product.UpdateAirbagCertificationsByDescriptionParts([]string{"Machine washable shell"})
+	Expect(updated).To(BeFalse())
+}
+
+func Test_CalculateSafetyPercentage_should_weight_the_back_over_the_chest_when_the_product_is_a_protector(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{ImageKey: "google.com/lol.png", Manufacturer: "Manufacturer5", Model: "RF-SR3", MSRPCents: 70099, Type: "protector", SafetyPercentage: -1234}
+	product.ProtectorCertifications.Back = &CEImpactZone{IsApproved: true, IsLevel2: true}
+	product.UpdateSafetyPercentage()
+
+	Expect(product.SafetyPercentage).To(Equal(75))
+
+	product.ProtectorCertifications.Chest = &CEImpactZone{IsApproved: true, IsLevel2: true}
+	product.UpdateSafetyPercentage()
+
+	Expect(product.SafetyPercentage).To(Equal(100))
+}
+
+func Test_UpdateProtectorCertificationsByDescriptionParts_should_apply_back_and_chest_certifications(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{Type: "protector"}
+
+	updatedBack, updatedChest := product.UpdateProtectorCertificationsByDescriptionParts([]string{
+		"CE level 2 back protector certified to EN 1621-2",
+		"Adjustable shoulder straps",
+	})
+
+	Expect(updatedBack).To(BeTrue())
+	Expect(updatedChest).To(BeFalse())
+	Expect(product.ProtectorCertifications.Back).To(Equal(&CEImpactZone{IsLevel2: true, Standard: CEStandardEN16212}))
+	Expect(product.ProtectorCertifications.Chest).To(BeNil())
+
+	updatedBack, updatedChest = product.UpdateProtectorCertificationsByDescriptionParts([]string{"Chest protector certified to EN 1621-3 level 1"})
+
+	Expect(updatedBack).To(BeFalse())
+	Expect(updatedChest).To(BeTrue())
+	Expect(product.ProtectorCertifications.Chest).To(Equal(&CEImpactZone{Standard: CEStandardEN16213}))
+}
+
+func Test_UpdateSearchPrice_should_use_the_lowest_offer_that_is_in_stock(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{MSRPCents: 50000, RevzillaPriceCents: 45000}
+	product.UpsertOffer(&ProductOffer{Retailer: RetailerRevzilla, PriceCents: 45000, Currency: CurrencyUSD, IsInStock: true})
+	product.UpsertOffer(&ProductOffer{Retailer: "cyclegear", PriceCents: 42000, Currency: CurrencyUSD, IsInStock: true})
+	product.UpsertOffer(&ProductOffer{Retailer: "motoport", PriceCents: 30000, Currency: CurrencyUSD, IsInStock: false})
+	product.UpdateSearchPrice(nil)
+
+	Expect(product.SearchPriceCents).To(Equal(42000))
+	Expect(product.GetLowestOffer(nil).Retailer).To(Equal("cyclegear"))
+
+	product.UpsertOffer(&ProductOffer{Retailer: "cyclegear", PriceCents: 42000, Currency: CurrencyUSD, IsInStock: false})
+	product.UpdateSearchPrice(nil)
+
+	Expect(product.Offers).To(HaveLen(3))
+	Expect(product.SearchPriceCents).To(Equal(45000))
+}
+
+func Test_UpdateSearchPrice_should_fall_back_to_the_revzilla_price_and_then_the_MSRP_when_there_are_no_current_offers(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{MSRPCents: 50000, RevzillaPriceCents: 45000}
+	product.UpsertOffer(&ProductOffer{Retailer: "cyclegear", PriceCents: 42000, Currency: CurrencyUSD, IsInStock: false})
+	product.UpdateSearchPrice(nil)
+
+	Expect(product.SearchPriceCents).To(Equal(45000))
+
+	product.RevzillaPriceCents = 0
+	product.UpdateSearchPrice(nil)
+
+	Expect(product.SearchPriceCents).To(Equal(50000))
+}
+
+func Test_UpdateSearchPrice_should_normalize_prices_in_other_currencies_to_the_base_currency(t *testing.T) {
+	RegisterTestingT(t)
+	exchangeRates := &ExchangeRates{BaseCurrency: CurrencyUSD, Rates: map[string]float64{CurrencyGBP: 0.8, "EUR": 0.9}}
+	product := &Product{MSRPCents: 40000, MSRPCurrency: CurrencyGBP}
+	product.UpdateSearchPrice(exchangeRates)
+
+	Expect(product.SearchPriceCents).To(Equal(50000))
+	Expect(product.SearchPriceCurrency).To(Equal(CurrencyUSD))
+
+	product.UpsertOffer(&ProductOffer{Retailer: RetailerRevzilla, PriceCents: 46000, Currency: CurrencyUSD, IsInStock: true})
+	product.UpsertOffer(&ProductOffer{Retailer: "louis", PriceCents: 40500, Currency: "EUR", IsInStock: true})
+	product.UpsertOffer(&ProductOffer{Retailer: "unknown", PriceCents: 100, Currency: "JPY", IsInStock: true})
+	product.UpdateSearchPrice(exchangeRates)
+
+	Expect(product.SearchPriceCents).To(Equal(45000))
+	Expect(product.GetLowestOffer(exchangeRates).Retailer).To(Equal("louis"))
+}
+
+func Test_UpdateSearchPrice_should_keep_the_price_in_its_own_currency_when_it_cannot_be_converted(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{MSRPCents: 40000, MSRPCurrency: CurrencyGBP}
+	product.UpdateSearchPrice(nil)
+
+	Expect(product.SearchPriceCents).To(Equal(40000))
+	Expect(product.SearchPriceCurrency).To(Equal(CurrencyGBP))
+
+	product.UpsertOffer(&ProductOffer{Retailer: "sportsbikeshop", PriceCents: 38000, Currency: CurrencyGBP, IsInStock: true})
+	product.UpdateSearchPrice(nil)
+
+	Expect(product.SearchPriceCents).To(Equal(38000))
+	Expect(product.SearchPriceCurrency).To(Equal(CurrencyGBP))
+
+	product = &Product{}
+	product.UpdateSearchPrice(nil)
+
+	Expect(product.SearchPriceCents).To(Equal(0))
+	Expect(product.SearchPriceCurrency).To(Equal(CurrencyUSD))
+}
+
+func Test_ConvertPrices_should_convert_every_price_to_the_display_currency(t *testing.T) {
+	RegisterTestingT(t)
+	exchangeRates := &ExchangeRates{BaseCurrency: CurrencyUSD, Rates: map[string]float64{CurrencyGBP: 0.8}}
+	product := &Product{MSRPCents: 40000, MSRPCurrency: CurrencyGBP, RevzillaPriceCents: 45000}
+	product.UpsertOffer(&ProductOffer{Retailer: RetailerRevzilla, PriceCents: 45000, Currency: CurrencyUSD, IsInStock: true})
+	product.UpdateSearchPrice(exchangeRates)
+	originalOffer := product.Offers[0]
+
+	err := product.ConvertPrices(exchangeRates, "gbp")
+
+	Expect(err).To(BeNil())
+	Expect(product.MSRPCents).To(Equal(40000))
+	Expect(product.SearchPriceCents).To(Equal(36000))
+	Expect(product.SearchPriceCurrency).To(Equal(CurrencyGBP))
+	Expect(product.RevzillaPriceCents).To(Equal(36000))
+	Expect(product.Offers[0]).To(Equal(&ProductOffer{Retailer: RetailerRevzilla, PriceCents: 36000, Currency: CurrencyGBP, IsInStock: true}))
+	Expect(originalOffer.PriceCents).To(Equal(45000))
+
+	err = product.ConvertPrices(exchangeRates, "JPY")
+	Expect(err).ToNot(BeNil())
+}
+
+func Test_Rebase_should_express_every_rate_relative_to_the_new_base_currency(t *testing.T) {
+	RegisterTestingT(t)
+	exchangeRates := &ExchangeRates{BaseCurrency: "eur", Rates: map[string]float64{"USD": 1.25, "GBP": 0.8}}
+
+	rebasedExchangeRates, err := exchangeRates.Rebase("USD")
+
+	Expect(err).To(BeNil())
+	Expect(rebasedExchangeRates.BaseCurrency).To(Equal(CurrencyUSD))
+	Expect(rebasedExchangeRates.Rates).To(HaveLen(2))
+	Expect(rebasedExchangeRates.Rates["EUR"]).To(BeNumerically("~", 0.8, 0.0001))
+	Expect(rebasedExchangeRates.Rates[CurrencyGBP]).To(BeNumerically("~", 0.64, 0.0001))
+
+	_, err = exchangeRates.Rebase("JPY")
+	Expect(err).ToNot(BeNil())
+}
+
+func Test_GetDisplayModel_should_prefer_the_alias_for_the_requested_region(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{Manufacturer: "Shoei", Model: "XR-1100", ModelAliases: []*ProductModelAlias{
+		{ModelAlias: "XR1100"},
+		{ModelAlias: "RF-1100", IsForDisplay: true, Region: RegionUS},
+	}}
+
+	Expect(product.GetDisplayModel("us")).To(Equal("RF-1100"))
+	Expect(product.GetDisplayModel(RegionEU)).To(Equal("XR-1100"))
+	Expect(product.GetDisplayModel("")).To(Equal("RF-1100"))
+
+	product.ModelAliases = nil
+	Expect(product.GetDisplayModel(RegionUS)).To(Equal("XR-1100"))
+}
+
+func Test_IsAvailableInRegion_should_assume_products_without_regions_are_sold_everywhere(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{}
+
+	Expect(product.IsAvailableInRegion(RegionUS)).To(BeTrue())
+
+	product.AddRegion("uk")
+	product.AddRegion(RegionUK)
+	product.AddRegion("Narnia")
+
+	Expect(product.Regions).To(Equal([]string{RegionUK}))
+	Expect(product.IsAvailableInRegion(RegionUK)).To(BeTrue())
+	Expect(product.IsAvailableInRegion(RegionUS)).To(BeFalse())
+}
+
+func Test_UpdateVariants_should_normalize_sizes_and_list_each_distinct_size_and_color(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{Sizes: []string{"XS-XXL"}}
+
+	product.UpdateVariants([]*ProductVariant{
+		{Size: " s", Color: "Black", PriceCents: 30000, IsInStock: true},
+		{Size: "S", Color: "Red Graphic", PriceCents: 35000, IsInStock: true},
+		{Size: "m", Color: "Black", PriceCents: 30000, IsInStock: false},
+		nil,
+	})
+
+	Expect(product.Variants).To(HaveLen(3))
+	Expect(product.Variants[0].Currency).To(Equal(CurrencyUSD))
+	Expect(product.Sizes).To(Equal([]string{"S", "M"}))
+	Expect(product.Colors).To(Equal([]string{"Black", "Red Graphic"}))
+}
+
+func Test_GetCheapestInStockVariant_should_ignore_variants_that_cannot_be_bought(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{}
+	Expect(product.GetCheapestInStockVariant()).To(BeNil())
+
+	product.UpdateVariants([]*ProductVariant{
+		{Size: "S", Color: "Graphic", PriceCents: 45000, IsInStock: true},
+		{Size: "M", Color: "Black", PriceCents: 30000, IsInStock: false},
+		{Size: "L", Color: "White", PriceCents: 0, IsInStock: true},
+		{Size: "XL", Color: "Black", PriceCents: 40000, IsInStock: true},
+	})
+
+	Expect(product.GetCheapestInStockVariant().Size).To(Equal("XL"))
+	Expect(product.IsSizeInStock("xl")).To(BeTrue())
+	Expect(product.IsSizeInStock("M")).To(BeFalse())
+	Expect(product.IsSizeInStock("L")).To(BeFalse())
+}
+
+func Test_MarkMissed_should_only_discontinue_a_product_after_every_source_misses_it_enough_times_in_a_row(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{}
+	seenAtUTC := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	Expect(product.MarkMissed(SourceRevzilla, 2)).To(BeFalse())
+	Expect(product.Sources).To(BeEmpty())
+
+	product.MarkSeen(SourceRevzilla, seenAtUTC)
+	product.MarkSeen(SourceCJ, seenAtUTC)
+	Expect(product.MarkMissed(SourceRevzilla, 2)).To(BeFalse())
+	Expect(product.MarkMissed(SourceRevzilla, 2)).To(BeFalse())
+	Expect(product.MarkMissed(SourceCJ, 2)).To(BeFalse())
+	Expect(product.IsDiscontinued).To(BeFalse())
+
+	Expect(product.MarkMissed(SourceCJ, 2)).To(BeTrue())
+	Expect(product.IsDiscontinued).To(BeTrue())
+	Expect(product.MarkMissed(SourceCJ, 2)).To(BeFalse())
+	Expect(product.Sources[SourceCJ].ConsecutiveMisses).To(Equal(3))
+}
+
+func Test_MarkSeen_should_revive_a_discontinued_product_and_reset_its_misses(t *testing.T) {
+	RegisterTestingT(t)
+	product := &Product{}
+	seenAtUTC := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	product.MarkSeen(SourceRevzilla, seenAtUTC)
+	Expect(product.MarkMissed(SourceRevzilla, 1)).To(BeTrue())
+
+	product.MarkSeen(SourceRevzilla, seenAtUTC.Add(time.Hour))
+	Expect(product.IsDiscontinued).To(BeFalse())
+	Expect(product.Sources[SourceRevzilla]).To(Equal(&ProductSource{LastSeenAtUTC: seenAtUTC.Add(time.Hour)}))
+}
+
+func Test_DiffProducts_should_return_each_nested_field_that_changed(t *testing.T) {
+	RegisterTestingT(t)
+	before := &Product{Manufacturer: "Shoei", Model: "RF-1400", Regions: []string{RegionUS}}
+	before.HelmetCertifications.ECE = true
+	before.HelmetCertifications.ECEVersion = ECEVersion2205
+
+	after, err := before.Clone()
+	Expect(err).To(BeNil())
+	after.HelmetCertifications.ECEVersion = ECEVersion2206
+	after.AddRegion(RegionUK)
+
+	diffs, err := DiffProducts(before, after)
+	Expect(err).To(BeNil())
+	Expect(diffs).To(HaveLen(2))
+	Expect(diffs[0]).To(Equal(&FieldDiff{Field: "helmetCertifications.ECEVersion", Before: ECEVersion2205, After: ECEVersion2206}))
+	Expect(diffs[1].Field).To(Equal("regions"))
+	Expect(before.Regions).To(Equal([]string{RegionUS}))
+}
+
+func Test_DiffProducts_should_diff_offers_by_retailer_and_leave_out_when_they_were_last_seen(t *testing.T) {
+	RegisterTestingT(t)
+	seenAtUTC := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := &Product{Manufacturer: "Shoei", Model: "RF-1400"}
+	before.UpsertOffer(&ProductOffer{Retailer: RetailerRevzilla, PriceCents: 54999, Currency: "USD", IsInStock: true, LastSeenAtUTC: seenAtUTC})
+	before.MarkSeen(SourceRevzilla, seenAtUTC)
+
+	after, err := before.Clone()
+	Expect(err).To(BeNil())
+	after.GetOffer(RetailerRevzilla).LastSeenAtUTC = seenAtUTC.Add(24 * time.Hour)
+	after.MarkSeen(SourceRevzilla, seenAtUTC.Add(24*time.Hour))
+
+	diffs, err := DiffProducts(before, after)
+	Expect(err).To(BeNil())
+	Expect(diffs).To(BeEmpty())
+
+	after.GetOffer(RetailerRevzilla).PriceCents = 49999
+	diffs, err = DiffProducts(before, after)
+	Expect(err).To(BeNil())
+	Expect(diffs).To(Equal([]*FieldDiff{{Field: "offers.revzilla.priceCents", Before: float64(54999), After: float64(49999)}}))
+}
+
+func Test_AddUpdatedProduct_should_ignore_products_that_did_not_change(t *testing.T) {
+	RegisterTestingT(t)
+	changeReport := NewChangeReport("import_helmets")
+	product := &Product{Manufacturer: "Shoei", Model: "RF-1400"}
+	unchangedProduct, err := product.Clone()
+	Expect(err).To(BeNil())
+
+	isChanged, err := changeReport.AddUpdatedProduct(product, unchangedProduct)
+	Expect(err).To(BeNil())
+	Expect(isChanged).To(BeFalse())
+	Expect(changeReport.UpdatedProducts).To(BeEmpty())
+
+	unchangedProduct.IsDiscontinued = true
+	isChanged, err = changeReport.AddUpdatedProduct(product, unchangedProduct)
+	Expect(err).To(BeNil())
+	Expect(isChanged).To(BeTrue())
+	Expect(changeReport.UpdatedProducts).To(HaveLen(1))
+	Expect(changeReport.UpdatedProducts[0].Diffs).To(Equal([]*FieldDiff{{Field: "isDiscontinued", Before: false, After: true}}))
+}
+
 func generateMockDescriptionPartsFromHTML(html string) ([]string, error) {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
@@ -299,13 +926,13 @@ func Test_UpdateJacketCertificationsByDescriptionParts_should_apply_CE_level_1_c
 	Expect(product.JacketCertifications.Back).To(Equal(&CEImpactZone{IsLevel2: false, IsApproved: false, IsEmpty: true}))
 
 	Expect(updatedShoulder).To(BeTrue())
-	Expect(product.JacketCertifications.Shoulder).To(Equal(&CEImpactZone{IsLevel2: false, IsApproved: false, IsEmpty: false}))
+	Expect(product.JacketCertifications.Shoulder).To(Equal(&CEImpactZone{IsLevel2: false, IsApproved: false, IsEmpty: false, Standard: CEStandardEN16211}))
 
 	Expect(updatedChest).To(BeFalse())
 	Expect(product.JacketCertifications.Chest).To(BeNil())
 
 	Expect(updatedElbow).To(BeTrue())
-	Expect(product.JacketCertifications.Elbow).To(Equal(&CEImpactZone{IsLevel2: false, IsApproved: false, IsEmpty: false}))
+	Expect(product.JacketCertifications.Elbow).To(Equal(&CEImpactZone{IsLevel2: false, IsApproved: false, IsEmpty: false, Standard: CEStandardEN16211}))
 
 	Expect(updatedAirbag).To(BeFalse())
 	Expect(product.JacketCertifications.FitsAirbag).To(BeFalse())
@@ -349,13 +976,13 @@ func Test_UpdateJacketCertificationsByDescriptionParts_should_apply_CE_level_1_c
 	Expect(product.JacketCertifications.Back).To(Equal(&CEImpactZone{IsLevel2: false, IsApproved: false, IsEmpty: true}))
 
 	Expect(updatedShoulder).To(BeTrue())
-	Expect(product.JacketCertifications.Shoulder).To(Equal(&CEImpactZone{IsLevel2: false, IsApproved: false, IsEmpty: false}))
+	Expect(product.JacketCertifications.Shoulder).To(Equal(&CEImpactZone{IsLevel2: false, IsApproved: false, IsEmpty: false, Standard: CEStandardEN16211}))
 
 	Expect(updatedChest).To(BeFalse())
 	Expect(product.JacketCertifications.Chest).To(BeNil())
 
 	Expect(updatedElbow).To(BeTrue())
-	Expect(product.JacketCertifications.Elbow).To(Equal(&CEImpactZone{IsLevel2: false, IsApproved: false, IsEmpty: false}))
+	Expect(product.JacketCertifications.Elbow).To(Equal(&CEImpactZone{IsLevel2: false, IsApproved: false, IsEmpty: false, Standard: CEStandardEN16211}))
 
 	Expect(updatedAirbag).To(BeFalse())
 	Expect(product.JacketCertifications.FitsAirbag).To(BeFalse())
@@ -420,6 +1047,49 @@ func Test_UpdateJacketCertificationsByDescriptionParts_should_apply_CE_level_2_c
 
 	Expect(updatedAirbag).To(BeFalse())
 	Expect(product.JacketCertifications.FitsAirbag).To(BeFalse())
+	Expect(product.JacketCertifications.GarmentClass).To(BeEmpty())
+}
+
+func Test_UpdateJacketCertificationsByDescriptionParts_should_flag_limb_armor_in_the_back_slot_as_mismatched(t *testing.T) {
+	RegisterTestingT(t)
+
+	product := &Product{}
+	updatedBack, updatedElbow, updatedShoulder, updatedChest, _ := product.UpdateJacketCertificationsByDescriptionParts([]string{
+		"CE level 2 armor certified to EN 1621-1 at the shoulders, elbows, and back",
+		"CE level 1 chest protector certified to EN 1621-3",
+	})
+
+	Expect(updatedBack).To(BeTrue())
+	Expect(product.JacketCertifications.Back).To(Equal(&CEImpactZone{IsLevel2: true, Standard: CEStandardEN16211, IsMismatched: true}))
+	Expect(product.JacketCertifications.Back.GetScore()).To(Equal(0.5))
+
+	Expect(updatedShoulder).To(BeTrue())
+	Expect(product.JacketCertifications.Shoulder).To(Equal(&CEImpactZone{IsLevel2: true, Standard: CEStandardEN16211}))
+
+	Expect(updatedElbow).To(BeTrue())
+	Expect(product.JacketCertifications.Elbow).To(Equal(&CEImpactZone{IsLevel2: true, Standard: CEStandardEN16211}))
+
+	Expect(updatedChest).To(BeTrue())
+	Expect(product.JacketCertifications.Chest).To(Equal(&CEImpactZone{Standard: CEStandardEN16213}))
+}
+
+func Test_UpdateJacketCertificationsByDescriptionParts_should_replace_mismatched_armor_when_a_back_protector_is_found(t *testing.T) {
+	RegisterTestingT(t)
+
+	product := &Product{}
+	product.UpdateJacketCertificationsByDescriptionParts([]string{
+		"CE level 2 EN1621.1 armor at the shoulders, elbows, and back",
+		"Includes a CE level 1 back protector certified to EN 1621-2:2014",
+	})
+
+	Expect(product.JacketCertifications.Back).To(Equal(&CEImpactZone{Standard: CEStandardEN16212}))
+}
+
+func Test_ParseEN1621Standards_should_parse_each_standard_that_is_mentioned(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(ParseEN1621Standards("EN 1621-1 shoulders and EN1621.2 back, EN 1621/1 elbows")).To(Equal([]string{CEStandardEN16211, CEStandardEN16212}))
+	Expect(ParseEN1621Standards("CE level 2 armor")).To(BeEmpty())
 }
 
 func Test_UpdatePantsCertificationsByDescriptionParts_should_apply_CE_level_2_certifications(t *testing.T) {
@@ -474,5 +1144,5 @@ func Test_UpdatePantsCertificationsByDescriptionParts_should_apply_CE_level_2_ce
 	Expect(product.PantsCertifications.Hip).To(Equal(&CEImpactZone{IsLevel2: true, IsApproved: false, IsEmpty: false}))
 
 	Expect(updatedKnee).To(BeTrue())
-	Expect(product.PantsCertifications.Knee).To(Equal(&CEImpactZone{IsLevel2: true, IsApproved: true, IsEmpty: false}))
+	Expect(product.PantsCertifications.Knee).To(Equal(&CEImpactZone{IsLevel2: true, IsApproved: true, IsEmpty: false, Standard: CEStandardEN16211}))
 }
@@ -0,0 +1,39 @@
+package entities
+
+import "strings"
+
+// ProductVariant represents a single purchasable size and colorway of a product, which may be priced differently from the other variants i.e. graphic colorways often cost more
+type ProductVariant struct {
+	Size       string `json:"size"`
+	Color      string `json:"color"`
+	SKU        string `json:"sku"`
+	PriceCents int    `json:"priceCents"`
+	Currency   string `json:"currency"`
+	IsInStock  bool   `json:"isInStock"`
+}
+
+// IsCurrent returns true if this variant can currently be bought i.e. it is in stock at a real price
+func (v *ProductVariant) IsCurrent() bool {
+	return v.IsInStock && v.PriceCents > 0
+}
+
+// GetCheapestInStockVariant returns the variant with the lowest price that can currently be bought, or nil if there isn't one. Variants are assumed to all be priced in the same currency since they come from the same retailer.
+func GetCheapestInStockVariant(variants []*ProductVariant) *ProductVariant {
+	var cheapestVariant *ProductVariant
+	for _, variant := range variants {
+		if variant == nil || !variant.IsCurrent() {
+			continue
+		}
+
+		if cheapestVariant == nil || variant.PriceCents < cheapestVariant.PriceCents {
+			cheapestVariant = variant
+		}
+	}
+
+	return cheapestVariant
+}
+
+// NormalizeSize converts a size to the form that variants are stored and filtered by i.e. " xl " becomes "XL"
+func NormalizeSize(size string) string {
+	return strings.ToUpper(strings.TrimSpace(size))
+}
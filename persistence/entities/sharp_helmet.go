@@ -2,16 +2,18 @@ package entities
 
 // SHARPHelmet represents the data scraped for one motorcycle helmet from the SHARP website
 type SHARPHelmet struct {
-	Subtype              string
-	Manufacturer         string
-	Model                string
-	ImageURL             string
-	LatchPercentage      int
-	WeightInLbs          float64
-	Sizes                []string
-	Materials            string
-	RetentionSystem      string
-	Certifications       *SHARPCertification
-	IsECECertified       bool
-	ApproximateMSRPCents int
+	Subtype                 string
+	Manufacturer            string
+	Model                   string
+	ImageURL                string
+	LatchPercentage         int
+	WeightInLbs             float64
+	Sizes                   []string
+	Materials               string
+	RetentionSystem         string
+	Certifications          *SHARPCertification
+	IsECECertified          bool
+	ECEVersion              string
+	ApproximateMSRPCents    int
+	ApproximateMSRPCurrency string
 }
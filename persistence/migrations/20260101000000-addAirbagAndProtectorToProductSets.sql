@@ -0,0 +1,10 @@
+-- +migrate Up
+alter table product_sets add column airbag_product_id int null references products(id);
+alter table product_sets add column protector_product_id int null references products(id);
+drop index product_sets_unique_key;
+create unique index product_sets_unique_key on product_sets(coalesce(helmet_product_id, -1), coalesce(jacket_product_id, -1), coalesce(pants_product_id, -1), coalesce(boots_product_id, -1), coalesce(gloves_product_id, -1), coalesce(airbag_product_id, -1), coalesce(protector_product_id, -1));
+-- +migrate Down
+drop index product_sets_unique_key;
+create unique index product_sets_unique_key on product_sets(coalesce(helmet_product_id, -1), coalesce(jacket_product_id, -1), coalesce(pants_product_id, -1), coalesce(boots_product_id, -1), coalesce(gloves_product_id, -1));
+alter table product_sets drop column protector_product_id;
+alter table product_sets drop column airbag_product_id;
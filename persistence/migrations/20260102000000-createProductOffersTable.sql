@@ -0,0 +1,49 @@
+-- +migrate Up
+create table product_offers (
+    id serial primary key,
+    product_id int not null references products(id) on delete cascade,
+
+    retailer text not null,
+    price_cents int not null,
+    currency text not null,
+    buy_url text not null,
+    is_in_stock boolean not null,
+    last_seen_at_utc timestamp not null,
+
+    created_at_utc timestamp not null,
+    updated_at_utc timestamp null,
+
+    unique(product_id, retailer)
+);
+
+-- Existing RevZilla prices become the first offer for each product
+insert into product_offers (product_id, retailer, price_cents, currency, buy_url, is_in_stock, last_seen_at_utc, created_at_utc, updated_at_utc)
+select
+    id,
+    'revzilla',
+    cast((document->>'revzillaPriceCents') as int),
+    'USD',
+    coalesce(document->>'revzillaBuyURL', ''),
+    not coalesce(cast((document->>'isDiscontinued') as boolean), false),
+    coalesce(updated_at_utc, created_at_utc),
+    (now() at time zone 'utc'),
+    null
+from products
+where cast((document->>'revzillaPriceCents') as int) > 0;
+
+update products p set document = jsonb_set(p.document, '{offers}', (
+    select coalesce(jsonb_agg(jsonb_build_object(
+        'retailer', o.retailer,
+        'priceCents', o.price_cents,
+        'currency', o.currency,
+        'buyURL', o.buy_url,
+        'isInStock', o.is_in_stock,
+        'lastSeenAtUTC', to_char(o.last_seen_at_utc, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+    )), 'null'::jsonb)
+    from product_offers o
+    where o.product_id = p.id
+));
+
+-- +migrate Down
+update products set document = document - 'offers';
+drop table product_offers;
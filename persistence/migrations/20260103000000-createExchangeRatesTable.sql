@@ -0,0 +1,17 @@
+-- +migrate Up
+create table exchange_rates (
+    base_currency text not null,
+    currency text not null,
+    rate double precision not null,
+    updated_at_utc timestamp not null,
+
+    primary key(base_currency, currency)
+);
+
+-- SHARP prices are in pounds sterling, but were previously stored as if they were US cents
+update products set document = jsonb_set(document, '{msrpCurrency}', '"GBP"')
+where document->>'type' = 'helmet' and jsonb_typeof(document->'helmetCertifications'->'SHARP') = 'object' and cast((document->>'msrpCents') as int) > 0;
+
+-- +migrate Down
+update products set document = document - 'msrpCurrency' - 'searchPriceCurrency';
+drop table exchange_rates;
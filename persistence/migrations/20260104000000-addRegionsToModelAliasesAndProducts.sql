@@ -0,0 +1,32 @@
+-- +migrate Up
+alter table product_model_aliases add column region text null;
+
+-- Aliases flagged for display were the names that helmets are sold under in the US
+update product_model_aliases set region = 'US' where is_for_display = true;
+create unique index product_model_aliases_region_once on product_model_aliases (manufacturer, model, region) where (region is not null);
+
+update products set document = jsonb_set(document, '{modelAliases}', (
+    select jsonb_agg(case when elem->>'isForDisplay' = 'true' then elem || jsonb_build_object('region', 'US') else elem end)
+    from jsonb_array_elements(document->'modelAliases') elem
+))
+where jsonb_typeof(document->'modelAliases') = 'array' and jsonb_array_length(document->'modelAliases') > 0;
+
+-- Products bought through RevZilla are sold in the US, and SHARP only rates helmets that are sold in the UK
+update products set document = jsonb_set(document, '{regions}', (
+    select coalesce(jsonb_agg(region), '[]'::jsonb)
+    from (
+        select 'US' as region where coalesce(document->>'revzillaBuyURL', '') <> ''
+        union all
+        select 'UK' as region where jsonb_typeof(document->'helmetCertifications'->'SHARP') = 'object'
+    ) regions
+));
+
+-- +migrate Down
+update products set document = document - 'regions';
+update products set document = jsonb_set(document, '{modelAliases}', (
+    select jsonb_agg(elem - 'region')
+    from jsonb_array_elements(document->'modelAliases') elem
+))
+where jsonb_typeof(document->'modelAliases') = 'array' and jsonb_array_length(document->'modelAliases') > 0;
+drop index product_model_aliases_region_once;
+alter table product_model_aliases drop column region;
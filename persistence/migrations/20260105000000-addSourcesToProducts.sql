@@ -0,0 +1,17 @@
+-- +migrate Up
+-- Products imported from RevZilla's listing pages have an external ID, and the rest were matched through CJ. Discontinued products start at the miss threshold so that they stay discontinued until they are seen again.
+update products p set document = jsonb_set(p.document, '{sources}', jsonb_build_object(
+    case when coalesce(p.document->>'externalID', '') <> '' then 'revzilla' else 'cj' end,
+    jsonb_build_object(
+        'lastSeenAtUTC', to_char(coalesce(
+            (select o.last_seen_at_utc from product_offers o where o.product_id = p.id and o.retailer = 'revzilla'),
+            p.updated_at_utc,
+            p.created_at_utc
+        ), 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+        'consecutiveMisses', case when p.document->>'isDiscontinued' = 'true' then 3 else 0 end
+    )
+))
+where coalesce(p.document->>'revzillaBuyURL', '') <> '';
+
+-- +migrate Down
+update products set document = document - 'sources';
@@ -0,0 +1,13 @@
+-- +migrate Up
+create table change_reports (
+    id serial primary key,
+    uuid uuid not null unique,
+    job_name text not null,
+    document jsonb not null,
+    created_at_utc timestamp not null
+);
+
+create index change_reports_job_name on change_reports (job_name, created_at_utc desc);
+
+-- +migrate Down
+drop table change_reports;
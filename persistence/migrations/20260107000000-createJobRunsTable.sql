@@ -0,0 +1,17 @@
+-- +migrate Up
+create table job_runs (
+    id serial primary key,
+    uuid uuid not null unique,
+    job_name text not null,
+    trigger_source text not null,
+    status text not null,
+    queued_at_utc timestamp not null,
+    started_at_utc timestamp,
+    finished_at_utc timestamp,
+    document jsonb not null
+);
+
+create index job_runs_job_name on job_runs (job_name, queued_at_utc desc);
+
+-- +migrate Down
+drop table job_runs;
@@ -0,0 +1,5 @@
+-- +migrate Up
+alter table job_runs add column cancel_requested_at_utc timestamp;
+
+-- +migrate Down
+alter table job_runs drop column cancel_requested_at_utc;
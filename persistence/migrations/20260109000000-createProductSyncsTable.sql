@@ -0,0 +1,11 @@
+-- +migrate Up
+create table product_syncs (
+    product_id int not null references products(id) on delete cascade,
+    job_name text not null,
+    synced_at_utc timestamp not null,
+
+    primary key(product_id, job_name)
+);
+
+-- +migrate Down
+drop table product_syncs;
@@ -0,0 +1,32 @@
+-- +migrate Up
+-- RevZilla only sells in the US, so every product with a RevZilla offer is sold there, even if it was imported before it had a buy URL
+update products set document = jsonb_set(document, '{regions}',
+    (case when jsonb_typeof(document->'regions') = 'array' then document->'regions' else '[]'::jsonb end) || '["US"]'::jsonb
+)
+where not (case when jsonb_typeof(document->'regions') = 'array' then document->'regions' else '[]'::jsonb end) @> '["US"]'::jsonb
+    and exists (
+        select 1
+        from jsonb_array_elements(case when jsonb_typeof(document->'offers') = 'array' then document->'offers' else '[]'::jsonb end) o
+        where o->>'retailer' = 'revzilla'
+    );
+
+-- Aliases flagged for display were assumed to be US names, which only holds for products that a US retailer actually sells
+update product_model_aliases a set region = null
+where a.region = 'US' and not exists (
+    select 1
+    from products p
+    where p.document->>'manufacturer' = a.manufacturer
+        and p.document->>'model' = a.model
+        and jsonb_typeof(p.document->'regions') = 'array'
+        and p.document->'regions' @> '["US"]'::jsonb
+);
+
+update products set document = jsonb_set(document, '{modelAliases}', (
+    select jsonb_agg(case when elem->>'region' = 'US' then elem - 'region' else elem end)
+    from jsonb_array_elements(document->'modelAliases') elem
+))
+where jsonb_typeof(document->'modelAliases') = 'array' and jsonb_array_length(document->'modelAliases') > 0
+    and not (jsonb_typeof(document->'regions') = 'array' and document->'regions' @> '["US"]'::jsonb);
+
+-- +migrate Down
+-- The regions that were guessed from isForDisplay can't be told apart from the ones that were backfilled, and the next import sets them again anyway
@@ -0,0 +1,9 @@
+package queries
+
+// BootsCertificationsQueryParams represents parameters that can be used to filter Boots-related certifications. Each minimum is an EN 13634 attribute rating between 0 and 2, where 0 means the attribute is not filtered on.
+type BootsCertificationsQueryParams struct {
+	MinimumHeight             int `json:"minimumHeight"`
+	MinimumAbrasion           int `json:"minimumAbrasion"`
+	MinimumImpactCut          int `json:"minimumImpactCut"`
+	MinimumTransverseRigidity int `json:"minimumTransverseRigidity"`
+}
@@ -8,6 +8,9 @@ type FilterProductsQuery struct {
 	Model                string                           `json:"model"`
 	HelmetCertifications *HelmetCertificationsQueryParams `json:"helmetCertifications"`
 	JacketCertifications *JacketCertificationsQueryParams `json:"jacketCertifications"`
+	PantsCertifications  *PantsCertificationsQueryParams  `json:"pantsCertifications"`
+	BootsCertifications  *BootsCertificationsQueryParams  `json:"bootsCertifications"`
+	GlovesCertifications *GlovesCertificationsQueryParams `json:"glovesCertifications"`
 	UsdPriceRange        []int                            `json:"usdPriceRange"`
 	Start                int                              `json:"start"`
 	Limit                int                              `json:"limit"`
@@ -15,5 +18,8 @@ type FilterProductsQuery struct {
 		Field      string `json:"field"`
 		Descending bool   `json:"descending"`
 	} `json:"order"`
-	ExcludeDiscontinued bool `json:"excludeDiscontinued"`
+	ExcludeDiscontinued bool     `json:"excludeDiscontinued"`
+	DisplayCurrency     string   `json:"displayCurrency"`
+	Region              string   `json:"region"`
+	AvailableSizes      []string `json:"availableSizes"`
 }
@@ -1,11 +1,15 @@
 package queries
 
 import (
+	"atgatt-backend/persistence/entities"
 	"errors"
+	"regexp"
 
 	"github.com/go-ozzo/ozzo-validation"
 )
 
+var currencyRegexp = regexp.MustCompile(`^[A-Za-z]{3}$`)
+
 // FilterProductsQueryValidator is responsible for validating (or returning an error) for a single FilterProductsQuery
 type FilterProductsQueryValidator struct {
 	Query              *FilterProductsQuery
@@ -31,11 +35,80 @@ func (v *FilterProductsQueryValidator) Validate() error {
 		validation.Field(&v.Query.HelmetCertifications,
 			validation.By(v.HelmetCertifications),
 		),
+		validation.Field(&v.Query.DisplayCurrency,
+			validation.By(Currency),
+		),
+		validation.Field(&v.Query.Region,
+			validation.By(Region),
+		),
+		validation.Field(&v.Query.AvailableSizes,
+			validation.By(Sizes),
+		),
 	)
 	if err != nil {
 		return err
 	}
 
+	if v.Query.HelmetCertifications != nil {
+		err = validation.ValidateStruct(v.Query.HelmetCertifications,
+			validation.Field(&v.Query.HelmetCertifications.MinimumSNELLStandard,
+				validation.By(SNELLStandard),
+			),
+			validation.Field(&v.Query.HelmetCertifications.MinimumECEVersion,
+				validation.By(ECEVersion),
+			),
+			validation.Field(&v.Query.HelmetCertifications.MinimumFIMStandard,
+				validation.By(FIMStandard),
+			),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if v.Query.JacketCertifications != nil {
+		err = validation.ValidateStruct(v.Query.JacketCertifications,
+			validation.Field(&v.Query.JacketCertifications.MinimumGarmentClass,
+				validation.By(GarmentClass),
+			),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if v.Query.PantsCertifications != nil {
+		err = validation.ValidateStruct(v.Query.PantsCertifications,
+			validation.Field(&v.Query.PantsCertifications.MinimumGarmentClass,
+				validation.By(GarmentClass),
+			),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if v.Query.BootsCertifications != nil {
+		err = validation.ValidateStruct(v.Query.BootsCertifications,
+			validation.Field(&v.Query.BootsCertifications.MinimumHeight, validation.Min(0), validation.Max(2)),
+			validation.Field(&v.Query.BootsCertifications.MinimumAbrasion, validation.Min(0), validation.Max(2)),
+			validation.Field(&v.Query.BootsCertifications.MinimumImpactCut, validation.Min(0), validation.Max(2)),
+			validation.Field(&v.Query.BootsCertifications.MinimumTransverseRigidity, validation.Min(0), validation.Max(2)),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if v.Query.GlovesCertifications != nil {
+		err = validation.ValidateStruct(v.Query.GlovesCertifications,
+			validation.Field(&v.Query.GlovesCertifications.MinimumLevel, validation.Min(0), validation.Max(2)),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = validation.Validate(v.Query.Order.Field, validation.Required, validation.By(v.OrderByField))
 	if err != nil {
 		validationErrors := validation.Errors{}
@@ -56,10 +129,103 @@ func (v *FilterProductsQueryValidator) OrderByField(value interface{}) error {
 	return nil
 }
 
-// HelmetCertifications ensures we either have helmet certifications or jacket certifications but not both
+// HelmetCertifications ensures we only have certifications for one type of product i.e. helmet certifications or jacket certifications but not both
 func (v *FilterProductsQueryValidator) HelmetCertifications(value interface{}) error {
-	if v.Query.HelmetCertifications != nil && v.Query.JacketCertifications != nil {
-		return errors.New("Helmet and Jacket certifications cannot be supplied together")
+	numCertificationsSupplied := 0
+	if v.Query.HelmetCertifications != nil {
+		numCertificationsSupplied++
+	}
+	if v.Query.JacketCertifications != nil {
+		numCertificationsSupplied++
+	}
+	if v.Query.PantsCertifications != nil {
+		numCertificationsSupplied++
+	}
+	if v.Query.BootsCertifications != nil {
+		numCertificationsSupplied++
+	}
+	if v.Query.GlovesCertifications != nil {
+		numCertificationsSupplied++
+	}
+
+	if numCertificationsSupplied > 1 {
+		return errors.New("Certifications for more than one type of product cannot be supplied together")
+	}
+
+	return nil
+}
+
+// GarmentClass ensures that the EN 17092 garment class is one that we know how to compare against other classes
+func GarmentClass(value interface{}) error {
+	garmentClass := value.(string)
+	if garmentClass != "" && entities.GetGarmentClassRank(garmentClass) == 0 {
+		return errors.New("The garment class that was specified is not recognized")
+	}
+
+	return nil
+}
+
+// SNELLStandard ensures that the SNELL standard is one that we know how to compare against other standards
+func SNELLStandard(value interface{}) error {
+	standard := value.(string)
+	if standard != "" && entities.GetSNELLStandardRank(standard) == 0 {
+		return errors.New("The SNELL standard that was specified is not recognized")
+	}
+
+	return nil
+}
+
+// ECEVersion ensures that the ECE version is one that we know how to compare against other versions
+func ECEVersion(value interface{}) error {
+	version := value.(string)
+	if version != "" && entities.GetECEVersionRank(version) == 0 {
+		return errors.New("The ECE version that was specified is not recognized")
+	}
+
+	return nil
+}
+
+// FIMStandard ensures that the FIM standard is one that we know how to compare against other standards
+func FIMStandard(value interface{}) error {
+	standard := value.(string)
+	if standard != "" && entities.GetFIMStandardRank(standard) == 0 {
+		return errors.New("The FIM standard that was specified is not recognized")
+	}
+
+	return nil
+}
+
+// Currency ensures that the currency is a three letter ISO 4217 code i.e. USD
+func Currency(value interface{}) error {
+	currency := value.(string)
+	if currency != "" && !currencyRegexp.MatchString(currency) {
+		return errors.New("The currency must be a three letter currency code")
+	}
+
+	return nil
+}
+
+// Region ensures that the region is one that products can be marked as sold in
+func Region(value interface{}) error {
+	region := value.(string)
+	if region != "" && entities.NormalizeRegion(region) == "" {
+		return errors.New("The region that was specified is not recognized")
+	}
+
+	return nil
+}
+
+// Sizes ensures that every size that products must be available in is non-empty, and that there aren't an unreasonable number of them
+func Sizes(value interface{}) error {
+	sizes := value.([]string)
+	if len(sizes) > 20 {
+		return errors.New("No more than 20 sizes can be specified")
+	}
+
+	for _, size := range sizes {
+		if entities.NormalizeSize(size) == "" {
+			return errors.New("The sizes cannot be empty")
+		}
 	}
 
 	return nil
@@ -0,0 +1,8 @@
+package queries
+
+// GlovesCertificationsQueryParams represents parameters that can be used to filter Gloves-related certifications
+type GlovesCertificationsQueryParams struct {
+	MinimumLevel         int  `json:"minimumLevel"` // the minimum EN 13594 level (1 or 2), or 0 to not filter on the level
+	HasKnuckleProtection bool `json:"hasKnuckleProtection"`
+	HasPalmSlider        bool `json:"hasPalmSlider"`
+}
@@ -2,8 +2,12 @@ package queries
 
 // HelmetCertificationsQueryParams represents parameters that can be used to filter Helmet-related certifications
 type HelmetCertificationsQueryParams struct {
-	SHARP *SHARPCertificationQueryParams `json:"SHARP"`
-	SNELL bool                           `json:"SNELL"`
-	ECE   bool                           `json:"ECE"`
-	DOT   bool                           `json:"DOT"`
+	SHARP                *SHARPCertificationQueryParams `json:"SHARP"`
+	SNELL                bool                           `json:"SNELL"`
+	MinimumSNELLStandard string                         `json:"minimumSNELLStandard"` // i.e. "M2020D" also matches "M2020R", but not "M2015"
+	ECE                  bool                           `json:"ECE"`
+	MinimumECEVersion    string                         `json:"minimumECEVersion"` // i.e. "22.05" also matches "22.06"
+	FIM                  bool                           `json:"FIM"`
+	MinimumFIMStandard   string                         `json:"minimumFIMStandard"` // i.e. "FRHPhe-01" also matches "FRHPhe-02"
+	DOT                  bool                           `json:"DOT"`
 }
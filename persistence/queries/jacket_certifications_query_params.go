@@ -2,9 +2,10 @@ package queries
 
 // JacketCertificationsQueryParams represents parameters that can be used to filter Jacket-related certifications
 type JacketCertificationsQueryParams struct {
-	Shoulder   *CEImpactZoneQueryParams `json:"shoulder"`
-	Elbow      *CEImpactZoneQueryParams `json:"elbow"`
-	Back       *CEImpactZoneQueryParams `json:"back"`
-	Chest      *CEImpactZoneQueryParams `json:"chest"`
-	FitsAirbag bool                     `json:"fitsAirbag"`
+	Shoulder            *CEImpactZoneQueryParams `json:"shoulder"`
+	Elbow               *CEImpactZoneQueryParams `json:"elbow"`
+	Back                *CEImpactZoneQueryParams `json:"back"`
+	Chest               *CEImpactZoneQueryParams `json:"chest"`
+	FitsAirbag          bool                     `json:"fitsAirbag"`
+	MinimumGarmentClass string                   `json:"minimumGarmentClass"` // i.e. "AA" also matches "AAA", but not "A"
 }
@@ -0,0 +1,9 @@
+package queries
+
+// PantsCertificationsQueryParams represents parameters that can be used to filter Pants-related certifications
+type PantsCertificationsQueryParams struct {
+	Knee                *CEImpactZoneQueryParams `json:"knee"`
+	Hip                 *CEImpactZoneQueryParams `json:"hip"`
+	Tailbone            *CEImpactZoneQueryParams `json:"tailbone"`
+	MinimumGarmentClass string                   `json:"minimumGarmentClass"` // i.e. "AA" also matches "AAA", but not "A"
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AdvisoryLock is a Postgres session level advisory lock, which is held for as long as the connection that took it stays open. It is used to make sure that only one worker instance does something at a time. It is safe to use from multiple goroutines at once.
+type AdvisoryLock struct {
+	DB   *sqlx.DB
+	Name string
+
+	mutex sync.Mutex
+	conn  *sql.Conn
+}
+
+// TryLock takes the lock without waiting, returning true if it is now held by this process. Calling it while the lock is already held checks that the connection holding it is still alive.
+func (l *AdvisoryLock) TryLock(ctx context.Context) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err != nil {
+			// The lock was released along with the connection, so another instance may hold it by now
+			l.closeConn()
+			return false, err
+		}
+		return true, nil
+	}
+
+	conn, err := l.DB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	isLocked := false
+	if err := conn.QueryRowContext(ctx, "select pg_try_advisory_lock($1)", l.getKey()).Scan(&isLocked); err != nil {
+		conn.Close()
+		return false, err
+	}
+
+	if !isLocked {
+		conn.Close()
+		return false, nil
+	}
+
+	l.conn = conn
+	return true, nil
+}
+
+// Unlock releases the lock if it is held by this process
+func (l *AdvisoryLock) Unlock(ctx context.Context) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+
+	_, err := l.conn.ExecContext(ctx, "select pg_advisory_unlock($1)", l.getKey())
+	l.closeConn()
+	return err
+}
+
+// IsHeld returns true if the lock was held by this process the last time it was checked
+func (l *AdvisoryLock) IsHeld() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.conn != nil
+}
+
+func (l *AdvisoryLock) closeConn() {
+	l.conn.Close()
+	l.conn = nil
+}
+
+// getKey hashes the name of the lock into the 64 bit key that Postgres expects
+func (l *AdvisoryLock) getKey() int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(l.Name))
+	return int64(hash.Sum64())
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"atgatt-backend/persistence/entities"
+	"encoding/json"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ChangeReportRepository contains functions that are used to save and read the change reports produced by dry runs of jobs
+type ChangeReportRepository struct {
+	DB *sqlx.DB
+}
+
+// CreateChangeReport saves the given change report by dumping it as json into a column in the DB
+func (r *ChangeReportRepository) CreateChangeReport(changeReport *entities.ChangeReport) error {
+	if changeReport == nil {
+		return errors.New("changeReport must be defined")
+	}
+
+	changeReportJSONBytes, err := json.Marshal(changeReport)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.DB.NamedExec("insert into change_reports (uuid, job_name, document, created_at_utc) values (:uuid, :job_name, :document, (now() at time zone 'utc'))", map[string]interface{}{
+		"uuid":     changeReport.UUID,
+		"job_name": changeReport.JobName,
+		"document": string(changeReportJSONBytes),
+	})
+	return err
+}
+
+// GetByUUID returns a single change report where the UUID matches
+func (r *ChangeReportRepository) GetByUUID(uuid string) (*entities.ChangeReport, error) {
+	changeReports, err := r.selectChangeReports("select document from change_reports where uuid = :uuid", map[string]interface{}{
+		"uuid": uuid,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changeReports) == 0 {
+		return nil, ErrEntityNotFound
+	}
+
+	return changeReports[0], nil
+}
+
+// GetLatest returns the most recent change reports, newest first. Only reports for the given job are returned unless the job name is empty.
+func (r *ChangeReportRepository) GetLatest(jobName string, limit int) ([]*entities.ChangeReport, error) {
+	return r.selectChangeReports(`select document from change_reports
+								where (cast(:job_name as text) = '' or job_name = :job_name)
+								order by created_at_utc desc, id desc
+								limit :limit`, map[string]interface{}{
+		"job_name": jobName,
+		"limit":    limit,
+	})
+}
+
+func (r *ChangeReportRepository) selectChangeReports(query string, queryParams map[string]interface{}) ([]*entities.ChangeReport, error) {
+	rows, err := r.DB.NamedQuery(query, queryParams)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changeReports := []*entities.ChangeReport{}
+	for rows.Next() {
+		changeReportJSONBytes := []byte{}
+		if err := rows.Scan(&changeReportJSONBytes); err != nil {
+			return nil, err
+		}
+
+		changeReport := &entities.ChangeReport{}
+		if err := json.Unmarshal(changeReportJSONBytes, changeReport); err != nil {
+			return nil, err
+		}
+		changeReports = append(changeReports, changeReport)
+	}
+
+	return changeReports, rows.Err()
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"atgatt-backend/persistence/entities"
+	"context"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ExchangeRateRepository contains functions that are used to read and replace currency exchange rates in the database
+type ExchangeRateRepository struct {
+	DB *sqlx.DB
+}
+
+type exchangeRateRow struct {
+	Currency string  `db:"currency"`
+	Rate     float64 `db:"rate"`
+}
+
+// GetByBaseCurrency returns all of the exchange rates relative to the given base currency. Only the base currency can be converted when no rates have been loaded.
+func (r *ExchangeRateRepository) GetByBaseCurrency(ctx context.Context, baseCurrency string) (*entities.ExchangeRates, error) {
+	normalizedBaseCurrency := entities.NormalizeCurrency(baseCurrency)
+
+	rows := []exchangeRateRow{}
+	err := r.DB.SelectContext(ctx, &rows, r.DB.Rebind("select currency, rate from exchange_rates where base_currency = ?"), normalizedBaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	exchangeRates := &entities.ExchangeRates{BaseCurrency: normalizedBaseCurrency, Rates: map[string]float64{}}
+	for _, row := range rows {
+		exchangeRates.Rates[row.Currency] = row.Rate
+	}
+
+	return exchangeRates, nil
+}
+
+// UpsertExchangeRates creates or updates each of the given exchange rates
+func (r *ExchangeRateRepository) UpsertExchangeRates(ctx context.Context, exchangeRates *entities.ExchangeRates) error {
+	if exchangeRates == nil {
+		return errors.New("exchangeRates must be defined")
+	}
+
+	for currency, rate := range exchangeRates.Rates {
+		_, err := r.DB.NamedExecContext(ctx, `insert into exchange_rates (base_currency, currency, rate, updated_at_utc) 
+								values (:base_currency, :currency, :rate, (now() at time zone 'utc')) 
+								on conflict (base_currency, currency) do update set 
+									rate = excluded.rate, 
+									updated_at_utc = excluded.updated_at_utc`, map[string]interface{}{
+			"base_currency": exchangeRates.GetBaseCurrency(),
+			"currency":      entities.NormalizeCurrency(currency),
+			"rate":          rate,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
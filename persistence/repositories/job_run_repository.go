@@ -0,0 +1,210 @@
+package repositories
+
+import (
+	"atgatt-backend/persistence/entities"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// JobRunRepository contains functions that are used to save and read the runs of background jobs
+type JobRunRepository struct {
+	DB *sqlx.DB
+}
+
+// CreateJobRun saves the given job run by dumping it as json into a column in the DB, along with the fields that runs are filtered and sorted by
+func (r *JobRunRepository) CreateJobRun(jobRun *entities.JobRun) error {
+	if jobRun == nil {
+		return errors.New("jobRun must be defined")
+	}
+
+	jobRunParams, err := getJobRunParams(jobRun)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.DB.NamedExec(`insert into job_runs (uuid, job_name, trigger_source, status, queued_at_utc, started_at_utc, finished_at_utc, document)
+							values (:uuid, :job_name, :trigger_source, :status, :queued_at_utc, :started_at_utc, :finished_at_utc, :document)`, jobRunParams)
+	return err
+}
+
+// UpdateJobRun overwrites the saved job run with the given one, which is used to record progress as the run starts and finishes
+func (r *JobRunRepository) UpdateJobRun(jobRun *entities.JobRun) error {
+	if jobRun == nil {
+		return errors.New("jobRun must be defined")
+	}
+
+	jobRunParams, err := getJobRunParams(jobRun)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.DB.NamedExec(`update job_runs set status = :status, started_at_utc = :started_at_utc, finished_at_utc = :finished_at_utc, document = :document
+								where uuid = :uuid`, jobRunParams)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrEntityNotFound
+	}
+	return nil
+}
+
+// GetByUUID returns a single job run where the UUID matches
+func (r *JobRunRepository) GetByUUID(uuid string) (*entities.JobRun, error) {
+	jobRuns, err := r.selectJobRuns("select document from job_runs where uuid = :uuid", map[string]interface{}{
+		"uuid": uuid,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(jobRuns) == 0 {
+		return nil, ErrEntityNotFound
+	}
+
+	return jobRuns[0], nil
+}
+
+// GetLatest returns the most recently queued job runs, newest first. Only runs of the given job are returned unless the job name is empty.
+func (r *JobRunRepository) GetLatest(jobName string, limit int) ([]*entities.JobRun, error) {
+	return r.selectJobRuns(`select document from job_runs
+							where (cast(:job_name as text) = '' or job_name = :job_name)
+							order by queued_at_utc desc, id desc
+							limit :limit`, map[string]interface{}{
+		"job_name": jobName,
+		"limit":    limit,
+	})
+}
+
+// GetLastQueuedAt returns the last time that a run of the given job was queued by the given trigger, or nil if it never has been
+func (r *JobRunRepository) GetLastQueuedAt(jobName string, trigger string) (*time.Time, error) {
+	rows, err := r.DB.NamedQuery("select max(queued_at_utc) from job_runs where job_name = :job_name and trigger_source = :trigger_source", map[string]interface{}{
+		"job_name":       jobName,
+		"trigger_source": trigger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lastQueuedAtUTC *time.Time
+	if rows.Next() {
+		if err := rows.Scan(&lastQueuedAtUTC); err != nil {
+			return nil, err
+		}
+	}
+
+	if lastQueuedAtUTC != nil {
+		utc := lastQueuedAtUTC.UTC()
+		lastQueuedAtUTC = &utc
+	}
+	return lastQueuedAtUTC, rows.Err()
+}
+
+// RequestCancelByJobName asks every running run of the given job to stop, and returns how many runs were asked. The worker running each one stops it once it sees the request.
+func (r *JobRunRepository) RequestCancelByJobName(jobName string) (int64, error) {
+	result, err := r.DB.NamedExec(`update job_runs set cancel_requested_at_utc = (now() at time zone 'utc')
+								where job_name = :job_name and status = :status and cancel_requested_at_utc is null`, map[string]interface{}{
+		"job_name": jobName,
+		"status":   entities.JobRunStatusRunning,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// RequestCancelByUUID asks the job run with the given UUID to stop if it is queued or running, and returns false if it has already finished. The worker running it stops it once it sees the request.
+func (r *JobRunRepository) RequestCancelByUUID(uuid string) (bool, error) {
+	result, err := r.DB.NamedExec(`update job_runs set cancel_requested_at_utc = coalesce(cancel_requested_at_utc, (now() at time zone 'utc'))
+								where uuid = :uuid and status in (:queued_status, :running_status)`, map[string]interface{}{
+		"uuid":           uuid,
+		"queued_status":  entities.JobRunStatusQueued,
+		"running_status": entities.JobRunStatusRunning,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	numUpdated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return numUpdated > 0, nil
+}
+
+// IsCancelRequested returns true if the job run with the given UUID has been asked to stop
+func (r *JobRunRepository) IsCancelRequested(uuid string) (bool, error) {
+	rows, err := r.DB.NamedQuery("select cancel_requested_at_utc is not null from job_runs where uuid = :uuid", map[string]interface{}{
+		"uuid": uuid,
+	})
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	isCancelRequested := false
+	if rows.Next() {
+		if err := rows.Scan(&isCancelRequested); err != nil {
+			return false, err
+		}
+	}
+	return isCancelRequested, rows.Err()
+}
+
+func getJobRunParams(jobRun *entities.JobRun) (map[string]interface{}, error) {
+	jobRunJSONBytes, err := json.Marshal(jobRun)
+	if err != nil {
+		return nil, err
+	}
+
+	// The document is decoded rather than reading the fields off the job run directly, since the run may still be recording items while it is saved
+	savedJobRun := &entities.JobRun{}
+	if err := json.Unmarshal(jobRunJSONBytes, savedJobRun); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"uuid":            savedJobRun.UUID,
+		"job_name":        savedJobRun.JobName,
+		"trigger_source":  savedJobRun.Trigger,
+		"status":          savedJobRun.Status,
+		"queued_at_utc":   savedJobRun.QueuedAtUTC,
+		"started_at_utc":  savedJobRun.StartedAtUTC,
+		"finished_at_utc": savedJobRun.FinishedAtUTC,
+		"document":        string(jobRunJSONBytes),
+	}, nil
+}
+
+func (r *JobRunRepository) selectJobRuns(query string, queryParams map[string]interface{}) ([]*entities.JobRun, error) {
+	rows, err := r.DB.NamedQuery(query, queryParams)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobRuns := []*entities.JobRun{}
+	for rows.Next() {
+		jobRunJSONBytes := []byte{}
+		if err := rows.Scan(&jobRunJSONBytes); err != nil {
+			return nil, err
+		}
+
+		jobRun := &entities.JobRun{}
+		if err := json.Unmarshal(jobRunJSONBytes, jobRun); err != nil {
+			return nil, err
+		}
+		jobRuns = append(jobRuns, jobRun)
+	}
+
+	return jobRuns, rows.Err()
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/jmoiron/sqlx"
 )
 
@@ -10,8 +12,8 @@ type ManufacturerRepository struct {
 }
 
 // GetAll returns all of the product manufacturer names from the database - this should be refactored to not return everything at once once we have > 100 manufacturers
-func (r *ManufacturerRepository) GetAll() ([]string, error) {
-	rows, err := r.DB.Queryx("select name from manufacturers")
+func (r *ManufacturerRepository) GetAll(ctx context.Context) ([]string, error) {
+	rows, err := r.DB.QueryxContext(ctx, "select name from manufacturers")
 	if err != nil {
 		return nil, err
 	}
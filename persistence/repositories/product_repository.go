@@ -3,20 +3,40 @@ package repositories
 import (
 	"atgatt-backend/persistence/entities"
 	"atgatt-backend/persistence/queries"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 // ProductRepository contains functions that are used to do CRUD operations on Products in the database
 type ProductRepository struct {
-	DB *sqlx.DB
+	DB           *sqlx.DB
+	BaseCurrency string // the currency that search prices are normalized to, defaults to USD when empty
 }
 
 func getOneProductFromRows(rows *sqlx.Rows) (*entities.Product, error) {
+	productDocuments, err := getProductsFromRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(productDocuments) == 0 {
+		return nil, ErrEntityNotFound
+	}
+
+	if len(productDocuments) > 1 {
+		return nil, errors.New("An unexpected number of products were returned")
+	}
+
+	return productDocuments[0], nil
+}
+
+func getProductsFromRows(rows *sqlx.Rows) ([]*entities.Product, error) {
 	defer rows.Close()
 
 	productDocuments := []*entities.Product{}
@@ -38,20 +58,12 @@ func getOneProductFromRows(rows *sqlx.Rows) (*entities.Product, error) {
 		productDocuments = append(productDocuments, productDocument)
 	}
 
-	if len(productDocuments) == 0 {
-		return nil, ErrEntityNotFound
-	}
-
-	if len(productDocuments) > 1 {
-		return nil, errors.New("An unexpected number of products were returned")
-	}
-
-	return productDocuments[0], nil
+	return productDocuments, rows.Err()
 }
 
 // GetByExternalID returns a single product where the external ID matches
-func (r *ProductRepository) GetByExternalID(externalID string) (*entities.Product, error) {
-	rows, err := r.DB.NamedQuery("select id, document from products where document->>'externalID' = :externalID", map[string]interface{}{
+func (r *ProductRepository) GetByExternalID(ctx context.Context, externalID string) (*entities.Product, error) {
+	rows, err := r.DB.NamedQueryContext(ctx, "select id, document from products where document->>'externalID' = :externalID", map[string]interface{}{
 		"externalID": externalID,
 	})
 	if err != nil {
@@ -62,8 +74,8 @@ func (r *ProductRepository) GetByExternalID(externalID string) (*entities.Produc
 }
 
 // GetByUUID returns a single product where the UUID matches
-func (r *ProductRepository) GetByUUID(uuid string) (*entities.Product, error) {
-	rows, err := r.DB.NamedQuery("select id, document from products where document->>'uuid' = :uuid", map[string]interface{}{
+func (r *ProductRepository) GetByUUID(ctx context.Context, uuid string) (*entities.Product, error) {
+	rows, err := r.DB.NamedQueryContext(ctx, "select id, document from products where document->>'uuid' = :uuid", map[string]interface{}{
 		"uuid": uuid,
 	})
 	if err != nil {
@@ -74,8 +86,8 @@ func (r *ProductRepository) GetByUUID(uuid string) (*entities.Product, error) {
 }
 
 // GetByModel returns a single product where the manufacturer and model matches
-func (r *ProductRepository) GetByModel(manufacturer string, model string, productType string) (*entities.Product, error) {
-	rows, err := r.DB.NamedQuery("select id, document from products where document->>'manufacturer' = :manufacturer and document->>'model' = :model and document->>'type' = :type", map[string]interface{}{
+func (r *ProductRepository) GetByModel(ctx context.Context, manufacturer string, model string, productType string) (*entities.Product, error) {
+	rows, err := r.DB.NamedQueryContext(ctx, "select id, document from products where document->>'manufacturer' = :manufacturer and document->>'model' = :model and document->>'type' = :type", map[string]interface{}{
 		"manufacturer": manufacturer,
 		"model":        model,
 		"type":         productType,
@@ -87,24 +99,62 @@ func (r *ProductRepository) GetByModel(manufacturer string, model string, produc
 	return getOneProductFromRows(rows)
 }
 
-// GetAllPaged queries the database for all products without prices, within the range of start and limit.
-func (r *ProductRepository) GetAllPaged(start int, limit int) ([]entities.Product, error) {
-	query := &queries.FilterProductsQuery{Start: start, Limit: limit}
-	query.Order.Field = "id"
+// GetAllBySource returns every product of the given type that has been listed by the given source at least once
+func (r *ProductRepository) GetAllBySource(ctx context.Context, source string, productType string) ([]*entities.Product, error) {
+	rows, err := r.DB.NamedQueryContext(ctx, "select id, document from products where document->>'type' = :type and jsonb_typeof(document->'sources'->cast(:source as text)) = 'object'", map[string]interface{}{
+		"source": source,
+		"type":   productType,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	filteredProducts, err := r.FilterProducts(query)
+	return getProductsFromRows(rows)
+}
+
+// GetPageAfterID returns up to limit products with an ID greater than afterID, ordered by ID, so that every product can be visited in pages that stay stable while products are added. When syncedBeforeUTC is set, products that the given job has synced since then are left out.
+func (r *ProductRepository) GetPageAfterID(ctx context.Context, afterID int, limit int, jobName string, syncedBeforeUTC *time.Time) ([]*entities.Product, error) {
+	queryParams := map[string]interface{}{
+		"after_id": afterID,
+		"limit":    limit,
+	}
+
+	syncedFilter := ""
+	if syncedBeforeUTC != nil {
+		syncedFilter = `and not exists (select 1 from product_syncs
+								where product_syncs.product_id = products.id and product_syncs.job_name = :job_name and product_syncs.synced_at_utc >= :synced_before_utc)`
+		queryParams["job_name"] = jobName
+		queryParams["synced_before_utc"] = syncedBeforeUTC.UTC()
+	}
+
+	rows, err := r.DB.NamedQueryContext(ctx, fmt.Sprintf(`select id, document from products
+								where id > :after_id %s
+								order by id
+								limit :limit`, syncedFilter), queryParams)
 	if err != nil {
 		return nil, err
 	}
 
-	return filteredProducts, nil
+	return getProductsFromRows(rows)
+}
+
+// MarkSynced records that the given job has just synced the product with the given ID
+func (r *ProductRepository) MarkSynced(ctx context.Context, productID int, jobName string) error {
+	_, err := r.DB.NamedExecContext(ctx, `insert into product_syncs (product_id, job_name, synced_at_utc)
+								values (:product_id, :job_name, (now() at time zone 'utc'))
+								on conflict (product_id, job_name) do update set
+									synced_at_utc = excluded.synced_at_utc`, map[string]interface{}{
+		"product_id": productID,
+		"job_name":   jobName,
+	})
+	return err
 }
 
 // GetAllModelAliases returns all the model aliases in the database
-func (r *ProductRepository) GetAllModelAliases() ([]*entities.ProductModelAlias, error) {
+func (r *ProductRepository) GetAllModelAliases(ctx context.Context) ([]*entities.ProductModelAlias, error) {
 
 	productModelAliases := []*entities.ProductModelAlias{}
-	err := r.DB.Select(&productModelAliases, "select manufacturer, model, model_alias as modelalias, is_for_display as isfordisplay from product_model_aliases")
+	err := r.DB.SelectContext(ctx, &productModelAliases, "select manufacturer, model, model_alias as modelalias, is_for_display as isfordisplay, coalesce(region, '') as region from product_model_aliases")
 	if err != nil {
 		return nil, err
 	}
@@ -113,9 +163,9 @@ func (r *ProductRepository) GetAllModelAliases() ([]*entities.ProductModelAlias,
 }
 
 // GetAllManufacturerAliases returns all the manufacturer aliases in the database
-func (r *ProductRepository) GetAllManufacturerAliases() ([]entities.ProductManufacturerAlias, error) {
+func (r *ProductRepository) GetAllManufacturerAliases(ctx context.Context) ([]entities.ProductManufacturerAlias, error) {
 	productManufacturerAliases := []entities.ProductManufacturerAlias{}
-	err := r.DB.Select(&productManufacturerAliases, "select manufacturer, manufacturer_alias as manufactureralias from product_manufacturer_aliases")
+	err := r.DB.SelectContext(ctx, &productManufacturerAliases, "select manufacturer, manufacturer_alias as manufactureralias from product_manufacturer_aliases")
 	if err != nil {
 		return nil, err
 	}
@@ -123,19 +173,29 @@ func (r *ProductRepository) GetAllManufacturerAliases() ([]entities.ProductManuf
 	return productManufacturerAliases, nil
 }
 
-// UpdateProduct replaces the product in the DB with the supplied product, where the product's UUID matches the one supplied
-func (r *ProductRepository) UpdateProduct(product *entities.Product) error {
+// GetExchangeRates returns the exchange rates that search prices are normalized to the base currency with
+func (r *ProductRepository) GetExchangeRates(ctx context.Context) (*entities.ExchangeRates, error) {
+	return (&ExchangeRateRepository{DB: r.DB}).GetByBaseCurrency(ctx, r.BaseCurrency)
+}
+
+// UpdateProduct replaces the product in the DB with the supplied product, where the product's UUID matches the one supplied, and writes its offers to product_offers in the same transaction. The search price is normalized with the given exchange rates, which callers load once per job with GetExchangeRates.
+func (r *ProductRepository) UpdateProduct(ctx context.Context, product *entities.Product, exchangeRates *entities.ExchangeRates) error {
 	if product == nil {
 		return errors.New("product must be defined")
 	}
 
-	product.UpdateSearchPrice()
+	product.UpdateSearchPrice(exchangeRates)
 	productJSONBytes, err := json.Marshal(product)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.DB.NamedExec(`update products set 
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExecContext(ctx, `update products set 
 								document = :document, 
 								updated_at_utc = (now() at time zone 'utc') 
 							where uuid = :uuid`, map[string]interface{}{
@@ -144,34 +204,101 @@ func (r *ProductRepository) UpdateProduct(product *entities.Product) error {
 	})
 
 	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = upsertProductOffers(ctx, tx, product)
+	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-// CreateProduct creates a product with the given fields by first converting it to json, and then dumping the json into a column in the DB.
-func (r *ProductRepository) CreateProduct(product *entities.Product) error {
+// CreateProduct creates a product with the given fields by first converting it to json, and then dumping the json into a column in the DB, and writes its offers to product_offers in the same transaction. The search price is normalized with the given exchange rates, which callers load once per job with GetExchangeRates.
+func (r *ProductRepository) CreateProduct(ctx context.Context, product *entities.Product, exchangeRates *entities.ExchangeRates) error {
 	if product == nil {
 		return errors.New("product must be defined")
 	}
 
-	product.UpdateSearchPrice()
+	product.UpdateSearchPrice(exchangeRates)
 	productJSONBytes, err := json.Marshal(product)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.DB.NamedExec("insert into products (uuid, document, created_at_utc, updated_at_utc) values (:uuid, :document, (now() at time zone 'utc'), null);", map[string]interface{}{
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExecContext(ctx, "insert into products (uuid, document, created_at_utc, updated_at_utc) values (:uuid, :document, (now() at time zone 'utc'), null);", map[string]interface{}{
 		"document": string(productJSONBytes),
 		"uuid":     product.UUID,
 	})
 
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	return nil
+	err = upsertProductOffers(ctx, tx, product)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// upsertProductOffers mirrors the offers on the product document into the product_offers table, removing offers from retailers that no longer sell the product
+func upsertProductOffers(ctx context.Context, tx *sqlx.Tx, product *entities.Product) error {
+	retailers := []interface{}{}
+	for _, offer := range product.Offers {
+		if offer == nil {
+			continue
+		}
+
+		_, err := tx.NamedExecContext(ctx, `insert into product_offers 
+								(product_id, retailer, price_cents, currency, buy_url, is_in_stock, last_seen_at_utc, created_at_utc, updated_at_utc) 
+								select id, :retailer, :price_cents, :currency, :buy_url, :is_in_stock, :last_seen_at_utc, (now() at time zone 'utc'), null 
+								from products where uuid = :uuid 
+								on conflict (product_id, retailer) do update set 
+									price_cents = excluded.price_cents, 
+									currency = excluded.currency, 
+									buy_url = excluded.buy_url, 
+									is_in_stock = excluded.is_in_stock, 
+									last_seen_at_utc = excluded.last_seen_at_utc, 
+									updated_at_utc = (now() at time zone 'utc')`, map[string]interface{}{
+			"uuid":             product.UUID,
+			"retailer":         offer.Retailer,
+			"price_cents":      offer.PriceCents,
+			"currency":         offer.Currency,
+			"buy_url":          offer.BuyURL,
+			"is_in_stock":      offer.IsInStock,
+			"last_seen_at_utc": offer.LastSeenAtUTC,
+		})
+		if err != nil {
+			return err
+		}
+
+		retailers = append(retailers, offer.Retailer)
+	}
+
+	if len(retailers) == 0 {
+		_, err := tx.ExecContext(ctx, tx.Rebind("delete from product_offers where product_id in (select id from products where uuid = ?)"), product.UUID)
+		return err
+	}
+
+	deleteStatement, args, err := sqlx.In("delete from product_offers where product_id in (select id from products where uuid = ?) and retailer not in (?)", product.UUID, retailers)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, tx.Rebind(deleteStatement), args...)
+	return err
 }
 
 func applyCEImpactZoneParams(zoneKey string, ceImpactZoneParams *queries.CEImpactZoneQueryParams, whereCriteria *strings.Builder) {
@@ -187,11 +314,26 @@ func applyCEImpactZoneParams(zoneKey string, ceImpactZoneParams *queries.CEImpac
 		if ceImpactZoneParams.IsEmpty {
 			(*whereCriteria).WriteString(fmt.Sprintf("and document->%s->>'isEmpty' = 'true' ", zoneKey))
 		}
+
+		// Armor certified for a different zone (i.e. limb armor in a back slot) shouldn't satisfy a filter on the armor's level or approval
+		if ceImpactZoneParams.IsLevel2 || ceImpactZoneParams.IsApproved {
+			(*whereCriteria).WriteString(fmt.Sprintf("and coalesce(document->%s->>'isMismatched', 'false') = 'false' ", zoneKey))
+		}
+	}
+}
+
+func applyEN13634AttributeParam(attributeKey string, minimumRating int, queryParams map[string]interface{}, whereCriteria *strings.Builder) {
+	if minimumRating <= 0 {
+		return
 	}
+
+	paramName := fmt.Sprintf("minimum_boots_%s", strings.ToLower(attributeKey))
+	queryParams[paramName] = minimumRating
+	(*whereCriteria).WriteString(fmt.Sprintf("and to_number((document->'bootsCertifications'->'EN13634'->>'%s'), '9') >= :%s ", attributeKey, paramName))
 }
 
 // FilterProducts is a method that ANDs a bunch of query parameters together and returns a list of matching products, or an error if there was a problem executing the query.
-func (r *ProductRepository) FilterProducts(query *queries.FilterProductsQuery) ([]entities.Product, error) {
+func (r *ProductRepository) FilterProducts(ctx context.Context, query *queries.FilterProductsQuery) ([]entities.Product, error) {
 	queryParams := make(map[string]interface{})
 	var whereCriteria strings.Builder
 	whereCriteria.WriteString("where 1=1 ")
@@ -218,8 +360,10 @@ func (r *ProductRepository) FilterProducts(query *queries.FilterProductsQuery) (
 	}
 
 	if len(query.UsdPriceRange) == 2 {
-		lowPrice := query.UsdPriceRange[0]
-		highPrice := query.UsdPriceRange[1]
+		lowPrice, highPrice, err := r.getBaseCurrencyPriceRange(ctx, query.UsdPriceRange)
+		if err != nil {
+			return nil, err
+		}
 		queryParams["low_price"] = lowPrice
 		queryParams["high_price"] = highPrice
 		whereCriteria.WriteString("and cast((document->>'searchPriceCents') as int) between :low_price and :high_price ")
@@ -292,10 +436,31 @@ func (r *ProductRepository) FilterProducts(query *queries.FilterProductsQuery) (
 			whereCriteria.WriteString("and document->'helmetCertifications'->>'SNELL' = 'true' ")
 		}
 
+		if query.HelmetCertifications.MinimumSNELLStandard != "" {
+			queryParams["snell_standards"] = entities.GetSNELLStandardsAtLeast(query.HelmetCertifications.MinimumSNELLStandard)
+			queryParams["legacy_snell_standard"] = entities.LegacySNELLStandard
+			whereCriteria.WriteString("and document->'helmetCertifications'->>'SNELL' = 'true' and coalesce(nullif(document->'helmetCertifications'->>'SNELLStandard', ''), :legacy_snell_standard) in (:snell_standards) ")
+		}
+
 		if query.HelmetCertifications.ECE {
 			whereCriteria.WriteString("and document->'helmetCertifications'->>'ECE' = 'true' ")
 		}
 
+		if query.HelmetCertifications.FIM {
+			whereCriteria.WriteString("and document->'helmetCertifications'->>'FIM' is not null ")
+		}
+
+		if query.HelmetCertifications.MinimumFIMStandard != "" {
+			queryParams["fim_standards"] = entities.GetFIMStandardsAtLeast(query.HelmetCertifications.MinimumFIMStandard)
+			whereCriteria.WriteString("and document->'helmetCertifications'->'FIM'->>'standard' in (:fim_standards) ")
+		}
+
+		if query.HelmetCertifications.MinimumECEVersion != "" {
+			queryParams["ece_versions"] = entities.GetECEVersionsAtLeast(query.HelmetCertifications.MinimumECEVersion)
+			queryParams["legacy_ece_version"] = entities.LegacyECEVersion
+			whereCriteria.WriteString("and document->'helmetCertifications'->>'ECE' = 'true' and coalesce(nullif(document->'helmetCertifications'->>'ECEVersion', ''), :legacy_ece_version) in (:ece_versions) ")
+		}
+
 		if query.HelmetCertifications.DOT {
 			whereCriteria.WriteString("and document->'helmetCertifications'->>'DOT' = 'true' ")
 		}
@@ -308,8 +473,63 @@ func (r *ProductRepository) FilterProducts(query *queries.FilterProductsQuery) (
 		applyCEImpactZoneParams("'jacketCertifications'->'chest'", query.JacketCertifications.Chest, &whereCriteria)
 
 		if query.JacketCertifications.FitsAirbag {
-			whereCriteria.WriteString("and document->'jacketCertifications'->>'fitsAirbag' = 'true'")
+			whereCriteria.WriteString("and document->'jacketCertifications'->>'fitsAirbag' = 'true' ")
+		}
+
+		if query.JacketCertifications.MinimumGarmentClass != "" {
+			queryParams["jacket_garment_classes"] = entities.GetGarmentClassesAtLeast(query.JacketCertifications.MinimumGarmentClass)
+			whereCriteria.WriteString("and document->'jacketCertifications'->>'garmentClass' in (:jacket_garment_classes) ")
+		}
+	}
+
+	if query.PantsCertifications != nil {
+		applyCEImpactZoneParams("'pantsCertifications'->'knee'", query.PantsCertifications.Knee, &whereCriteria)
+		applyCEImpactZoneParams("'pantsCertifications'->'hip'", query.PantsCertifications.Hip, &whereCriteria)
+		applyCEImpactZoneParams("'pantsCertifications'->'tailbone'", query.PantsCertifications.Tailbone, &whereCriteria)
+
+		if query.PantsCertifications.MinimumGarmentClass != "" {
+			queryParams["pants_garment_classes"] = entities.GetGarmentClassesAtLeast(query.PantsCertifications.MinimumGarmentClass)
+			whereCriteria.WriteString("and document->'pantsCertifications'->>'garmentClass' in (:pants_garment_classes) ")
+		}
+	}
+
+	if query.BootsCertifications != nil {
+		applyEN13634AttributeParam("height", query.BootsCertifications.MinimumHeight, queryParams, &whereCriteria)
+		applyEN13634AttributeParam("abrasion", query.BootsCertifications.MinimumAbrasion, queryParams, &whereCriteria)
+		applyEN13634AttributeParam("impactCut", query.BootsCertifications.MinimumImpactCut, queryParams, &whereCriteria)
+		applyEN13634AttributeParam("transverseRigidity", query.BootsCertifications.MinimumTransverseRigidity, queryParams, &whereCriteria)
+	}
+
+	if query.GlovesCertifications != nil {
+		if query.GlovesCertifications.MinimumLevel > 0 {
+			queryParams["minimum_gloves_level"] = query.GlovesCertifications.MinimumLevel
+			whereCriteria.WriteString("and to_number((document->'glovesCertifications'->'EN13594'->>'level'), '9') >= :minimum_gloves_level ")
+		}
+
+		if query.GlovesCertifications.HasKnuckleProtection {
+			whereCriteria.WriteString("and document->'glovesCertifications'->'EN13594'->>'hasKnuckleProtection' = 'true' ")
+		}
+
+		if query.GlovesCertifications.HasPalmSlider {
+			whereCriteria.WriteString("and document->'glovesCertifications'->'EN13594'->>'hasPalmSlider' = 'true' ")
+		}
+	}
+
+	// Products that haven't been marked with any regions are assumed to be sold everywhere
+	if query.Region != "" {
+		queryParams["region"] = entities.NormalizeRegion(query.Region)
+		whereCriteria.WriteString("and (jsonb_typeof(document->'regions') is distinct from 'array' or jsonb_array_length(document->'regions') = 0 or document->'regions' @> jsonb_build_array(cast(:region as text))) ")
+	}
+
+	// Only variants that can currently be bought count towards a size being available
+	if len(query.AvailableSizes) > 0 {
+		availableSizes := []string{}
+		for _, size := range query.AvailableSizes {
+			availableSizes = append(availableSizes, entities.NormalizeSize(size))
 		}
+		queryParams["available_sizes"] = availableSizes
+		whereCriteria.WriteString(`and exists (select 1 from jsonb_array_elements(case when jsonb_typeof(document->'variants') = 'array' then document->'variants' else '[]' end) as variant
+											  where variant->>'size' in (:available_sizes) and variant->>'isInStock' = 'true' and cast(variant->>'priceCents' as integer) > 0) `)
 	}
 
 	if query.ExcludeDiscontinued {
@@ -337,7 +557,7 @@ func (r *ProductRepository) FilterProducts(query *queries.FilterProductsQuery) (
 
 	// Converts ? arguments back to positional ($0, $1, $2, etc) arguments so that they can be executed in the DB.
 	preProcessedSQLQueryString = r.DB.Rebind(preProcessedSQLQueryString)
-	rows, err := r.DB.Query(preProcessedSQLQueryString, args...)
+	rows, err := r.DB.QueryContext(ctx, preProcessedSQLQueryString, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -359,3 +579,27 @@ func (r *ProductRepository) FilterProducts(query *queries.FilterProductsQuery) (
 
 	return productDocuments, nil
 }
+
+// getBaseCurrencyPriceRange converts a price range in US cents to the base currency that search prices are stored in
+func (r *ProductRepository) getBaseCurrencyPriceRange(ctx context.Context, usdPriceRange []int) (int, int, error) {
+	if entities.NormalizeCurrency(r.BaseCurrency) == entities.CurrencyUSD {
+		return usdPriceRange[0], usdPriceRange[1], nil
+	}
+
+	exchangeRates, err := r.GetExchangeRates(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	lowPrice, err := exchangeRates.ConvertCents(usdPriceRange[0], entities.CurrencyUSD, r.BaseCurrency)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	highPrice, err := exchangeRates.ConvertCents(usdPriceRange[1], entities.CurrencyUSD, r.BaseCurrency)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return lowPrice, highPrice, nil
+}
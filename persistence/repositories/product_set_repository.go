@@ -86,13 +86,17 @@ func (r *ProductSetRepository) GetProductSetProductsByUUID(uuidToFind uuid.UUID)
 								pjacket.document, 
 								ppants.document,
 								pboots.document, 
-								pgloves.document
+								pgloves.document,
+								pairbag.document,
+								pprotector.document
 							from product_sets ps
 							left join products phelmet on phelmet.id = ps.helmet_product_id 
 							left join products pjacket on pjacket.id = ps.jacket_product_id
 							left join products ppants on ppants.id = ps.pants_product_id
 							left join products pboots on pboots.id = ps.boots_product_id
 							left join products pgloves on pgloves.id = ps.gloves_product_id
+							left join products pairbag on pairbag.id = ps.airbag_product_id
+							left join products pprotector on pprotector.id = ps.protector_product_id
 							where ps.uuid = :uuid`, map[string]interface{}{
 		"uuid": uuidToFind,
 	})
@@ -113,8 +117,10 @@ func (r *ProductSetRepository) GetProductSetProductsByUUID(uuidToFind uuid.UUID)
 		pantsProductJSONBytes := []byte{}
 		bootsProductJSONBytes := []byte{}
 		glovesProductJSONBytes := []byte{}
+		airbagProductJSONBytes := []byte{}
+		protectorProductJSONBytes := []byte{}
 
-		err := rows.Scan(&uuidFound, &helmetProductJSONBytes, &jacketProductJSONBytes, &pantsProductJSONBytes, &bootsProductJSONBytes, &glovesProductJSONBytes)
+		err := rows.Scan(&uuidFound, &helmetProductJSONBytes, &jacketProductJSONBytes, &pantsProductJSONBytes, &bootsProductJSONBytes, &glovesProductJSONBytes, &airbagProductJSONBytes, &protectorProductJSONBytes)
 		if err != nil {
 			return nil, err
 		}
@@ -144,13 +150,25 @@ func (r *ProductSetRepository) GetProductSetProductsByUUID(uuidToFind uuid.UUID)
 			return nil, err
 		}
 
+		airbagProduct, err := jsonBytesToProduct(airbagProductJSONBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		protectorProduct, err := jsonBytesToProduct(protectorProductJSONBytes)
+		if err != nil {
+			return nil, err
+		}
+
 		productSets = append(productSets, &dtos.ProductSetProductsDTO{
-			UUID:          uuidFound,
-			HelmetProduct: helmetProduct,
-			JacketProduct: jacketProduct,
-			PantsProduct:  pantsProduct,
-			BootsProduct:  bootsProduct,
-			GlovesProduct: glovesProduct,
+			UUID:             uuidFound,
+			HelmetProduct:    helmetProduct,
+			JacketProduct:    jacketProduct,
+			PantsProduct:     pantsProduct,
+			BootsProduct:     bootsProduct,
+			GlovesProduct:    glovesProduct,
+			AirbagProduct:    airbagProduct,
+			ProtectorProduct: protectorProduct,
 		})
 	}
 
@@ -176,7 +194,9 @@ func (r *ProductSetRepository) GetByUUID(uuid uuid.UUID) (*entities.ProductSet,
 				jacket_product_id jacketProductID, 
 				pants_product_id pantsProductID, 
 				boots_product_id bootsProductID, 
-				gloves_product_id glovesProductID
+				gloves_product_id glovesProductID, 
+				airbag_product_id airbagProductID, 
+				protector_product_id protectorProductID
 			from product_sets 
 			where uuid = :uuid
 		`, map[string]interface{}{
@@ -193,11 +213,13 @@ func (r *ProductSetRepository) GetByUUID(uuid uuid.UUID) (*entities.ProductSet,
 // GetMatchingProductSetUUID gets the product set's UUID with the exact same set of products if it exists, otherwise null
 func (r *ProductSetRepository) GetMatchingProductSetUUID(productSet *entities.ProductSet) (uuid.UUID, error) {
 	paramsMap := map[string]interface{}{
-		"helmet_product_id": productSet.HelmetProductID,
-		"jacket_product_id": productSet.JacketProductID,
-		"pants_product_id":  productSet.PantsProductID,
-		"boots_product_id":  productSet.BootsProductID,
-		"gloves_product_id": productSet.GlovesProductID,
+		"helmet_product_id":    productSet.HelmetProductID,
+		"jacket_product_id":    productSet.JacketProductID,
+		"pants_product_id":     productSet.PantsProductID,
+		"boots_product_id":     productSet.BootsProductID,
+		"gloves_product_id":    productSet.GlovesProductID,
+		"airbag_product_id":    productSet.AirbagProductID,
+		"protector_product_id": productSet.ProtectorProductID,
 	}
 
 	rows, err := r.DB.NamedQuery(`select uuid from product_sets 
@@ -206,7 +228,9 @@ func (r *ProductSetRepository) GetMatchingProductSetUUID(productSet *entities.Pr
 									 jacket_product_id is not distinct from :jacket_product_id and 
 									 pants_product_id is not distinct from :pants_product_id and 
 									 boots_product_id is not distinct from :boots_product_id and 
-									 gloves_product_id is not distinct from :gloves_product_id`, paramsMap)
+									 gloves_product_id is not distinct from :gloves_product_id and 
+									 airbag_product_id is not distinct from :airbag_product_id and 
+									 protector_product_id is not distinct from :protector_product_id`, paramsMap)
 
 	if err != nil {
 		return uuid.Nil, err
@@ -218,20 +242,22 @@ func (r *ProductSetRepository) GetMatchingProductSetUUID(productSet *entities.Pr
 // Create creates the given productset, returning its UUID for the frontend to use.
 func (r *ProductSetRepository) Create(productSet *entities.ProductSet) (uuid.UUID, error) {
 	paramsMap := map[string]interface{}{
-		"uuid":              uuid.New(),
-		"name":              productSet.Name,
-		"description":       productSet.Description,
-		"helmet_product_id": productSet.HelmetProductID,
-		"jacket_product_id": productSet.JacketProductID,
-		"pants_product_id":  productSet.PantsProductID,
-		"boots_product_id":  productSet.BootsProductID,
-		"gloves_product_id": productSet.GlovesProductID,
+		"uuid":                 uuid.New(),
+		"name":                 productSet.Name,
+		"description":          productSet.Description,
+		"helmet_product_id":    productSet.HelmetProductID,
+		"jacket_product_id":    productSet.JacketProductID,
+		"pants_product_id":     productSet.PantsProductID,
+		"boots_product_id":     productSet.BootsProductID,
+		"gloves_product_id":    productSet.GlovesProductID,
+		"airbag_product_id":    productSet.AirbagProductID,
+		"protector_product_id": productSet.ProtectorProductID,
 	}
 
 	rows, err := r.DB.NamedQuery(`insert into product_sets
-							(uuid, "name", description, helmet_product_id, jacket_product_id, pants_product_id, boots_product_id, gloves_product_id, created_at_utc, created_by) 
+							(uuid, "name", description, helmet_product_id, jacket_product_id, pants_product_id, boots_product_id, gloves_product_id, airbag_product_id, protector_product_id, created_at_utc, created_by) 
 							values 
-							(:uuid, :name, :description, :helmet_product_id, :jacket_product_id, :pants_product_id, :boots_product_id, :gloves_product_id, (now() at time zone 'utc'), 'SYSTEM_USER')
+							(:uuid, :name, :description, :helmet_product_id, :jacket_product_id, :pants_product_id, :boots_product_id, :gloves_product_id, :airbag_product_id, :protector_product_id, (now() at time zone 'utc'), 'SYSTEM_USER')
 							returning uuid`, paramsMap)
 	if err != nil {
 		return uuid.Nil, err
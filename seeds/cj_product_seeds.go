@@ -0,0 +1,24 @@
+package seeds
+
+import (
+	"atgatt-backend/persistence/entities"
+	"net/url"
+)
+
+// GetCJProductSeeds returns the products in RevZilla's CJ product feed that match the realistic product seeds, which the fake CJ server searches so that syncing helmets can be tested without a real CJ API key
+func GetCJProductSeeds() []entities.CJProduct {
+	return []entities.CJProduct{
+		// Matches the Shoei X Spirit lll by its X-14 alias
+		newCJProductSeed("Shoei X-14 Helmet", "699.99", "Motorcycle Helmets", "The Shoei X-14 is DOT and SNELL M2015 certified."),
+		// Matches the Shoei X Spirit lll's alias as well, but is an accessory rather than a helmet
+		newCJProductSeed("Shoei CWR-1 Shield for X-14 Helmet", "249.99", "Motorcycle Helmet Accessories", "A replacement shield for the Shoei X-14."),
+		newCJProductSeed("Bell Star Helmet", "499.95", "Motorcycle Helmets", "The Bell Star is DOT and SNELL M2020 certified."),
+	}
+}
+
+func newCJProductSeed(name string, price string, category string, description string) entities.CJProduct {
+	product := entities.CJProduct{Name: name, Categories: []string{category}, Description: description}
+	product.Price.Amount = price
+	product.LinkCode.ClickURL = "https://www.anrdoezrs.net/links/8505854/type/dlg/https://www.revzilla.com/search?query=" + url.QueryEscape(name)
+	return product
+}
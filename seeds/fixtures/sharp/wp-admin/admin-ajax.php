@@ -0,0 +1,18 @@
+<tr class="helmet-row">
+	<td class="helmet-image"><a href="/helmets/hjc-rpha-11/"><img src="https://sharp.dft.gov.uk/wp-content/uploads/2017/03/hjc-rpha-11-150x150.jpg" alt="HJC RPHA 11" /></a></td>
+	<td class="helmet-name"><a href="/helmets/hjc-rpha-11/">HJC RPHA 11</a></td>
+	<td class="helmet-rating"><img src="https://sharp.dft.gov.uk/wp-content/themes/sharp2016/img/rating-star-5.png" alt="5 stars" /></td>
+</tr>
+<tr class="helmet-row">
+	<td class="helmet-image"><a href="/helmets/schuberth-c4-pro/"><img src="https://sharp.dft.gov.uk/wp-content/uploads/2019/06/schuberth-c4-pro-150x150.jpg" alt="Schuberth C4 Pro" /></a></td>
+	<td class="helmet-name"><a href="/helmets/schuberth-c4-pro/">Schuberth C4 Pro</a></td>
+	<td class="helmet-rating"><img src="https://sharp.dft.gov.uk/wp-content/themes/sharp2016/img/rating-star-4.png" alt="4 stars" /></td>
+</tr>
+<tr class="helmet-row">
+	<td class="helmet-image"><a href="/helmets/caberg-drift/"><img src="https://sharp.dft.gov.uk/wp-content/uploads/2014/11/caberg-drift-150x150.jpg" alt="Caberg Drift" /></a></td>
+	<td class="helmet-name"><a href="/helmets/caberg-drift/">Caberg Drift</a></td>
+	<td class="helmet-rating"><img src="https://sharp.dft.gov.uk/wp-content/themes/sharp2016/img/rating-star-3.png" alt="3 stars" /></td>
+</tr>
+<tr class="helmet-row helmet-row--advert">
+	<td colspan="3"><a href="https://www.example.com/helmets/sponsored/">Sponsored</a> | <a href="/helmet-testing/">How we test helmets</a></td>
+</tr>
@@ -0,0 +1,8 @@
+{"data":[
+{"manufacturer":"HJC Helmets","model":"RPHA 11","size":"XS,S,M,L,XL,XXL","standard":"M2020D","helmettype":"Motorcycle","faceconfig":"Full Face"},
+{"manufacturer":"Bell Helmets","model":"Race Star Flex DLX","size":"XS,S,M,L,XL,XXL","standard":"M2020D","helmettype":"Motorcycle","faceconfig":"Full Face"},
+{"manufacturer":"Arai Helmet","model":"Corsair-X","size":"XS,S,M","standard":"M2020D","helmettype":"Motorcycle","faceconfig":"Full Face"},
+{"manufacturer":"Arai Helmet","model":"Corsair-X","size":"L,XL,XXL","standard":"M2020R","helmettype":"Motorcycle","faceconfig":"Full Face"},
+{"manufacturer":"Shoei","model":"Neotec II","size":"XS,S,M,L,XL,XXL","standard":" m2015 ","helmettype":"Motorcycle","faceconfig":"Modular"},
+{"manufacturer":"Bell Helmets","model":"HP7","size":"S,M,L,XL","standard":"SA2020","helmettype":"Special Application","faceconfig":"Full Face"}
+]}
@@ -4,6 +4,7 @@ import (
 	"atgatt-backend/persistence/entities"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	golinq "github.com/ahmetb/go-linq"
 	"github.com/google/uuid"
@@ -11,6 +12,8 @@ import (
 
 const mockHelmetImageURL = "https://sharp.dft.gov.uk/wp-content/uploads/2017/03/shoei-x-spirit-lll.jpg"
 
+var mockOfferLastSeenAtUTC = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
 // GetProductSeedsSQLStatements returns an array of INSERT statements that target each of the product seed structs. Used to import test data into the database for automated tests, local development.
 func GetProductSeedsSQLStatements(productSeeds []*entities.Product) ([]string, error) {
 	statements := []string{}
@@ -22,6 +25,12 @@ func GetProductSeedsSQLStatements(productSeeds []*entities.Product) ([]string, e
 		documentJSONString := string(documentJSONBytes)
 		formattedInsertStatement := fmt.Sprintf("insert into products (uuid, document, created_at_utc, updated_at_utc) values ('%s', '%s', (now() at time zone 'utc'), null);", product.UUID.String(), documentJSONString)
 		statements = append(statements, formattedInsertStatement)
+
+		for _, offer := range product.Offers {
+			formattedOfferInsertStatement := fmt.Sprintf("insert into product_offers (product_id, retailer, price_cents, currency, buy_url, is_in_stock, last_seen_at_utc, created_at_utc, updated_at_utc) select id, '%s', %d, '%s', '%s', %t, '%s', (now() at time zone 'utc'), null from products where uuid = '%s';",
+				offer.Retailer, offer.PriceCents, offer.Currency, offer.BuyURL, offer.IsInStock, offer.LastSeenAtUTC.Format(time.RFC3339), product.UUID.String())
+			statements = append(statements, formattedOfferInsertStatement)
+		}
 	}
 	return statements, nil
 }
@@ -45,6 +54,7 @@ func GetRealisticProductSeeds() []*entities.Product {
 		{
 			ModelAlias:   "X-14",
 			IsForDisplay: true,
+			Region:       entities.RegionUS,
 		},
 	}
 
@@ -67,7 +77,13 @@ func GetRealisticProductSeeds() []*entities.Product {
 	}
 
 	for i := 0; i < len(seeds); i++ {
-		seeds[i].UpdateSearchPrice()
+		if seeds[i].RevzillaPriceCents > 0 {
+			seeds[i].Offers = []*entities.ProductOffer{
+				{Retailer: entities.RetailerRevzilla, PriceCents: seeds[i].RevzillaPriceCents, Currency: entities.CurrencyUSD, BuyURL: seeds[i].RevzillaBuyURL, IsInStock: !seeds[i].IsDiscontinued, LastSeenAtUTC: mockOfferLastSeenAtUTC},
+			}
+		}
+
+		seeds[i].UpdateSearchPrice(nil)
 		seeds[i].UUID = uuid.MustParse(uuids[i])
 	}
 
@@ -79,6 +95,12 @@ func applySeedDataToHelmet(i int, product *entities.Product) {
 	product.RevzillaBuyURL = fmt.Sprintf("http://www.testdata.com/revzilla/%d", i)
 	if i%2 == 0 {
 		product.HelmetCertifications.ECE = true
+		product.HelmetCertifications.ECEVersion = entities.ECEVersion2206
+		product.Regions = []string{entities.RegionUS}
+		product.Variants = []*entities.ProductVariant{
+			{Size: "S", Color: "Black", SKU: fmt.Sprintf("SKU-%d-S", i), PriceCents: product.RevzillaPriceCents, Currency: entities.CurrencyUSD, IsInStock: true},
+			{Size: "XL", Color: "Black Graphic", SKU: fmt.Sprintf("SKU-%d-XL", i), PriceCents: product.RevzillaPriceCents + 5000, Currency: entities.CurrencyUSD, IsInStock: false},
+		}
 		product.HelmetCertifications.DOT = true
 		product.HelmetCertifications.SHARP = &entities.SHARPCertification{}
 		product.HelmetCertifications.SHARP.Stars = 4
@@ -89,8 +111,12 @@ func applySeedDataToHelmet(i int, product *entities.Product) {
 		product.HelmetCertifications.SHARP.ImpactZoneRatings.Top.Front = 3
 		product.HelmetCertifications.SHARP.ImpactZoneRatings.Top.Rear = 5
 		product.HelmetCertifications.SNELL = true
+		product.HelmetCertifications.SNELLStandard = entities.SNELLStandardM2020D
+		product.HelmetCertifications.FIM = &entities.FIMCertification{Standard: entities.FIMStandardFRHPhe02, HomologationNumber: fmt.Sprintf("FIM-%04d", i)}
 	} else if i%3 == 0 {
 		product.HelmetCertifications.ECE = true
+		product.HelmetCertifications.ECEVersion = entities.ECEVersion2205
+		product.Regions = []string{entities.RegionUK, entities.RegionEU}
 		product.HelmetCertifications.DOT = true
 		product.HelmetCertifications.SHARP = &entities.SHARPCertification{}
 		product.HelmetCertifications.SHARP.Stars = 3
@@ -101,6 +127,7 @@ func applySeedDataToHelmet(i int, product *entities.Product) {
 		product.HelmetCertifications.SHARP.ImpactZoneRatings.Top.Front = 2
 		product.HelmetCertifications.SHARP.ImpactZoneRatings.Top.Rear = 3
 		product.HelmetCertifications.SNELL = true
+		product.HelmetCertifications.SNELLStandard = entities.SNELLStandardM2015
 	} else {
 		product.HelmetCertifications.ECE = false
 		product.HelmetCertifications.DOT = false
@@ -121,6 +148,7 @@ func applySeedDataToJacket(i int, product *entities.Product) {
 		product.JacketCertifications.Back = level2Zone
 		product.JacketCertifications.Chest = level2Zone
 		product.JacketCertifications.FitsAirbag = true
+		product.JacketCertifications.GarmentClass = entities.GarmentClassAAA
 	} else if i%3 == 0 {
 		level1Zone := &entities.CEImpactZone{IsLevel2: false, IsApproved: false, IsEmpty: false}
 		product.JacketCertifications.Shoulder = level1Zone
@@ -128,6 +156,7 @@ func applySeedDataToJacket(i int, product *entities.Product) {
 		product.JacketCertifications.Back = level1Zone
 		product.JacketCertifications.Chest = level1Zone
 		product.JacketCertifications.FitsAirbag = true
+		product.JacketCertifications.GarmentClass = entities.GarmentClassA
 	}
 }
 
@@ -139,11 +168,13 @@ func applySeedDataToPants(i int, product *entities.Product) {
 		product.PantsCertifications.Hip = level2Zone
 		product.PantsCertifications.Knee = level2Zone
 		product.PantsCertifications.Tailbone = level2Zone
+		product.PantsCertifications.GarmentClass = entities.GarmentClassAA
 	} else if i%3 == 0 {
 		level1Zone := &entities.CEImpactZone{IsLevel2: false, IsApproved: false, IsEmpty: false}
 		product.PantsCertifications.Hip = level1Zone
 		product.PantsCertifications.Knee = level1Zone
 		product.PantsCertifications.Tailbone = level1Zone
+		product.PantsCertifications.GarmentClass = entities.GarmentClassB
 	}
 }
 
@@ -153,9 +184,11 @@ func applySeedDataToBoots(i int, product *entities.Product) {
 	if i%2 == 0 {
 		level2Zone := &entities.CEImpactZone{IsLevel2: true, IsApproved: true, IsEmpty: false}
 		product.BootsCertifications.Overall = level2Zone
+		product.BootsCertifications.EN13634 = &entities.EN13634Rating{Height: 2, Abrasion: 2, ImpactCut: 2, TransverseRigidity: 2}
 	} else if i%3 == 0 {
 		level1Zone := &entities.CEImpactZone{IsLevel2: false, IsApproved: false, IsEmpty: false}
 		product.BootsCertifications.Overall = level1Zone
+		product.BootsCertifications.EN13634 = &entities.EN13634Rating{Height: 1, Abrasion: 2, ImpactCut: 1, TransverseRigidity: 1}
 	}
 }
 
@@ -165,12 +198,26 @@ func applySeedDataToGloves(i int, product *entities.Product) {
 	if i%2 == 0 {
 		level2Zone := &entities.CEImpactZone{IsLevel2: true, IsApproved: true, IsEmpty: false}
 		product.GlovesCertifications.Overall = level2Zone
+		product.GlovesCertifications.EN13594 = &entities.EN13594Rating{Level: 2, HasKnuckleProtection: true, HasPalmSlider: true}
 	} else if i%3 == 0 {
 		level1Zone := &entities.CEImpactZone{IsLevel2: false, IsApproved: false, IsEmpty: false}
 		product.GlovesCertifications.Overall = level1Zone
+		product.GlovesCertifications.EN13594 = &entities.EN13594Rating{Level: 1, HasKnuckleProtection: false, HasPalmSlider: true}
 	}
 }
 
+func applySeedDataToAirbag(i int, product *entities.Product) {
+	product.RevzillaPriceCents = product.MSRPCents + 10000
+	product.RevzillaBuyURL = fmt.Sprintf("http://www.testdata.com/revzilla/%d", i)
+	product.AirbagCertifications = entities.AirbagCertification{IsCertified: true, IsLevel2: true, IsElectronic: true, ProtectsBack: true, ProtectsChest: true, ProtectsNeck: false}
+}
+
+func applySeedDataToProtector(i int, product *entities.Product) {
+	product.RevzillaPriceCents = product.MSRPCents + 10000
+	product.RevzillaBuyURL = fmt.Sprintf("http://www.testdata.com/revzilla/%d", i)
+	product.ProtectorCertifications.Back = &entities.CEImpactZone{IsLevel2: true, IsApproved: true, IsEmpty: false, Standard: entities.CEStandardEN16212}
+}
+
 // GetProductSeeds returns a sample list of product documents; these documents are used by GetProductSeedsSQLStatements() to seed the database with test data.
 func GetProductSeeds() []*entities.Product {
 	modelAliases := []*entities.ProductModelAlias{
@@ -180,6 +227,7 @@ func GetProductSeeds() []*entities.Product {
 		{
 			ModelAlias:   "RF-1300",
 			IsForDisplay: true,
+			Region:       entities.RegionUS,
 		},
 	}
 
@@ -215,6 +263,8 @@ func GetProductSeeds() []*entities.Product {
 		{UUID: uuid.MustParse("8e798a63-a556-4ee6-b09f-0d68bf605505"), ImageKey: mockHelmetImageURL, Manufacturer: "BootsManu7", Model: "Facturer7", MSRPCents: 99997, Type: "boots", Subtype: "", SafetyPercentage: 7},
 		{UUID: uuid.MustParse("d0842709-3dcc-43df-993f-52fc1c3f7cd6"), ImageKey: mockHelmetImageURL, Manufacturer: "GlovesManu8", Model: "Facturer8", MSRPCents: 109999, Type: "gloves", Subtype: "", SafetyPercentage: 8},
 		{UUID: uuid.MustParse("36de7153-9960-46df-a5eb-66f6e34123e1"), ImageKey: mockHelmetImageURL, Manufacturer: "GlovesManu9", Model: "Facturer9", MSRPCents: 119999, Type: "gloves", Subtype: "", SafetyPercentage: 9},
+		{UUID: uuid.MustParse("5b1f0b8e-7a53-4d49-9a7e-2f6d3c0e8a11"), ImageKey: mockHelmetImageURL, Manufacturer: "AirbagManu10", Model: "Facturer10", MSRPCents: 69999, Type: "airbag", Subtype: "", SafetyPercentage: 10},
+		{UUID: uuid.MustParse("c3a9e5d2-4f81-4b6e-8d27-91e0a6b4f352"), ImageKey: mockHelmetImageURL, Manufacturer: "ProtectorManu11", Model: "Facturer11", MSRPCents: 14999, Type: "protector", Subtype: "", SafetyPercentage: 11},
 		emptyJacket,
 	}
 
@@ -229,9 +279,13 @@ func GetProductSeeds() []*entities.Product {
 			applySeedDataToBoots(i, seeds[i])
 		} else if seeds[i].Type == "gloves" {
 			applySeedDataToGloves(i, seeds[i])
+		} else if seeds[i].Type == "airbag" {
+			applySeedDataToAirbag(i, seeds[i])
+		} else if seeds[i].Type == "protector" {
+			applySeedDataToProtector(i, seeds[i])
 		}
 
-		seeds[i].UpdateSearchPrice()
+		seeds[i].UpdateSearchPrice(nil)
 	}
 
 	emptyJacket.JacketCertifications.Shoulder = nil
@@ -239,6 +293,7 @@ func GetProductSeeds() []*entities.Product {
 	emptyJacket.JacketCertifications.Back = nil
 	emptyJacket.JacketCertifications.Chest = nil
 	emptyJacket.JacketCertifications.FitsAirbag = false
+	emptyJacket.JacketCertifications.GarmentClass = ""
 
 	return seeds
 }
@@ -0,0 +1,416 @@
+package worker
+
+import (
+	"atgatt-backend/persistence/entities"
+	"atgatt-backend/persistence/repositories"
+	"atgatt-backend/worker/jobs"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// jobLockPollInterval is how often a run that is waiting for another run of the same job to finish checks whether it can start
+var jobLockPollInterval = 5 * time.Second
+
+// cancelPollInterval is how often a running job checks whether it has been asked to stop, which can happen from any worker instance
+var cancelPollInterval = 5 * time.Second
+
+// saveProgressInterval is how often a running job's counts and checkpoint are saved, which bounds how much progress is lost if the worker stops unexpectedly
+const saveProgressInterval = 30 * time.Second
+
+// errJobRunInterrupted finishes a run that was left running by a worker that stopped unexpectedly
+var errJobRunInterrupted = errors.New("The worker running the job stopped before it finished")
+
+// jobCannotDryRunError is returned by triggerJob when a dry run is requested for a job that can't be dry run
+type jobCannotDryRunError struct {
+	jobName string
+}
+
+// Error names the job that can't be dry run
+func (e *jobCannotDryRunError) Error() string {
+	return fmt.Sprintf("The job %s cannot be dry run", e.jobName)
+}
+
+// jobLockNamePrefix namespaces the advisory locks that stop runs of the same job from overlapping
+const jobLockNamePrefix = "atgatt-worker-job:"
+
+// triggerJob saves a new run of the job with the given name and dispatches it to the job queue, or runs it straight away when using the synchronous job runner. When resumeFrom is set, the new run picks up from that run's checkpoint. It returns a jobCannotDryRunError when a dry run is requested for a job that doesn't support one.
+func (s *Server) triggerJob(name string, trigger string, isDryRun bool, resumeFrom *entities.JobRun) (*entities.JobRun, error) {
+	job, exists := s.jobs[name]
+	if !exists {
+		return nil, fmt.Errorf("The job %s does not exist", name)
+	}
+
+	var changeReport *entities.ChangeReport
+	if isDryRun {
+		if _, isDryRunnable := job.(jobs.DryRunnableJob); !isDryRunnable {
+			return nil, &jobCannotDryRunError{jobName: name}
+		}
+		changeReport = entities.NewChangeReport(name)
+	}
+
+	jobRun := entities.NewJobRun(name, trigger)
+	jobRun.ResumeFrom(resumeFrom)
+	if changeReport != nil {
+		jobRun.IsDryRun = true
+		jobRun.ChangeReportUUID = &changeReport.UUID
+	}
+
+	jobLogger := logrus.WithFields(logrus.Fields{"jobName": name, "isDryRun": isDryRun, "runID": jobRun.UUID, "trigger": trigger})
+	if err := s.jobRunRepository.CreateJobRun(jobRun); err != nil {
+		jobLogger.WithError(err).Error("Could not save the job run")
+		return nil, err
+	}
+	jobLogger.Info("Triggered, dispatching work to job queue")
+
+	runJobFuncWrapper := func() {
+		_ = s.runJob(job, jobRun, changeReport, jobLogger)
+	}
+
+	if s.Settings.UseSynchronousJobRunner {
+		err := s.runJob(job, jobRun, changeReport, jobLogger)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err := s.jobQueue.Dispatch(runJobFuncWrapper)
+		if err != nil {
+			jobLogger.WithError(err).Error("Could not start job due to an error")
+			jobRun.Finish(err)
+			s.saveJobRun(jobRun, jobLogger)
+			return nil, err
+		}
+	}
+
+	jobLogger.Info("Finished dispatching work to job queue")
+	return jobRun, nil
+}
+
+// jobOverlap tracks a run that is waiting for another run of the same job to finish, across each attempt to take the job's lock
+type jobOverlap struct {
+	hasLoggedWait   bool
+	hasCancelledRun bool
+}
+
+// runJob runs the job once no other run of it is in progress on any worker instance, following the job's overlap policy if one is. The job is given a context that is cancelled when the run is asked to stop and that times out after the job's timeout.
+func (s *Server) runJob(job jobs.Job, jobRun *entities.JobRun, changeReport *entities.ChangeReport, jobLogger *logrus.Entry) error {
+	return s.tryRunJob(job, jobRun, changeReport, jobLogger, &jobOverlap{})
+}
+
+// tryRunJob takes the lock for the job and runs it. If another run holds the lock, this run is skipped if the job's overlap policy is to skip. Otherwise it is tried again after jobLockPollInterval, asking the other run to stop first if the policy is to cancel.
+func (s *Server) tryRunJob(job jobs.Job, jobRun *entities.JobRun, changeReport *entities.ChangeReport, jobLogger *logrus.Entry, overlap *jobOverlap) error {
+	lock := &repositories.AdvisoryLock{DB: s.db, Name: jobLockNamePrefix + jobRun.JobName}
+	isLocked, err := lock.TryLock(context.Background())
+	if err != nil {
+		jobLogger.WithError(err).Error("Could not check whether another run of the job is in progress")
+		jobRun.Finish(err)
+		s.saveJobRun(jobRun, jobLogger)
+		return err
+	}
+
+	if !isLocked {
+		return s.handleJobOverlap(job, jobRun, changeReport, jobLogger, overlap)
+	}
+	defer func() {
+		if err := lock.Unlock(context.Background()); err != nil {
+			jobLogger.WithError(err).Error("Could not release the lock for the job")
+		}
+	}()
+
+	// The run may have been cancelled while it was waiting in the job queue
+	if s.isCancelRequested(jobRun, jobLogger) {
+		jobLogger.Warning("Job was cancelled before it started")
+		jobRun.Finish(entities.ErrJobRunCancelled)
+		s.saveJobRun(jobRun, jobLogger)
+		return nil
+	}
+
+	timeout := s.getJobTimeout(jobRun.JobName)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	jobLogger.WithField("timeout", timeout).Info("Starting Job")
+	jobRun.Start()
+	s.saveJobRun(jobRun, jobLogger)
+	stopWatchingForCancel := s.watchForCancel(jobRun, cancel, jobLogger)
+	stopSavingProgress := s.saveProgressPeriodically(jobRun, jobLogger)
+
+	if changeReport != nil {
+		err = job.(jobs.DryRunnableJob).DryRun(ctx, jobRun, changeReport)
+		changeReport.Finish(err)
+		if saveErr := s.changeReportRepository.CreateChangeReport(changeReport); saveErr != nil {
+			jobLogger.WithError(saveErr).Error("Could not save the change report for the dry run")
+		}
+	} else {
+		err = job.Run(ctx, jobRun)
+	}
+
+	stopWatchingForCancel()
+	stopSavingProgress()
+	err = getJobRunError(ctx, jobRun, err, timeout)
+	jobRun.Finish(err)
+	s.saveJobRun(jobRun, jobLogger)
+	if err == entities.ErrJobRunCancelled {
+		jobLogger.Warning("Job was cancelled")
+		return nil
+	}
+	if err != nil {
+		jobLogger.WithError(err).Error("Job completed with errors")
+		return err
+	}
+	jobLogger.WithField("counts", jobRun.GetCounts()).Info("Job completed successfully")
+	return nil
+}
+
+// handleJobOverlap follows the job's overlap policy when another run of it holds the lock. Runs that wait are tried again after jobLockPollInterval, and are dispatched to the job queue again rather than holding on to a slot in it while they wait.
+func (s *Server) handleJobOverlap(job jobs.Job, jobRun *entities.JobRun, changeReport *entities.ChangeReport, jobLogger *logrus.Entry, overlap *jobOverlap) error {
+	switch s.getOverlapPolicy(jobRun.JobName) {
+	case jobs.OverlapPolicySkip:
+		jobLogger.Warning("Another run of the job is in progress, skipping this one")
+		jobRun.Skip("Another run of the job was in progress")
+		s.saveJobRun(jobRun, jobLogger)
+		return nil
+	case jobs.OverlapPolicyCancel:
+		// The other run may not have been saved as running yet, so keep asking until a run has been asked to stop
+		if !overlap.hasCancelledRun {
+			numCancelled, err := s.jobRunRepository.RequestCancelByJobName(jobRun.JobName)
+			if err != nil {
+				jobLogger.WithError(err).Error("Could not ask the other run of the job to stop")
+				jobRun.Finish(err)
+				s.saveJobRun(jobRun, jobLogger)
+				return err
+			}
+
+			if numCancelled > 0 {
+				jobLogger.WithField("numCancelled", numCancelled).Warning("Another run of the job is in progress, asked it to stop before starting this one")
+				overlap.hasCancelledRun = true
+			}
+		}
+	default:
+		if !overlap.hasLoggedWait {
+			jobLogger.Info("Another run of the job is in progress, waiting for it to finish before starting this one")
+			overlap.hasLoggedWait = true
+		}
+	}
+
+	if s.isCancelRequested(jobRun, jobLogger) {
+		jobLogger.Warning("Job was cancelled while waiting for another run of it to finish")
+		jobRun.Finish(entities.ErrJobRunCancelled)
+		s.saveJobRun(jobRun, jobLogger)
+		return nil
+	}
+
+	retry := func() error {
+		return s.tryRunJob(job, jobRun, changeReport, jobLogger, overlap)
+	}
+	if s.Settings.UseSynchronousJobRunner {
+		time.Sleep(jobLockPollInterval)
+		return retry()
+	}
+
+	err := s.jobQueue.DispatchIn(func() {
+		_ = retry()
+	}, jobLockPollInterval)
+	if err != nil {
+		jobLogger.WithError(err).Error("Could not dispatch the job again while waiting for another run of it to finish")
+		jobRun.Finish(err)
+		s.saveJobRun(jobRun, jobLogger)
+		return err
+	}
+	return nil
+}
+
+// getJobRunError works out how a run finished from the error that its job returned. Jobs stop with the context's error, or an error from a request or query that was interrupted by it, once their context is done. A run that was asked to stop is recorded as cancelled rather than failed, while a run that ran out of time fails with an error that says so.
+func getJobRunError(ctx context.Context, jobRun *entities.JobRun, err error, timeout time.Duration) error {
+	if err == nil {
+		return nil
+	}
+
+	switch ctx.Err() {
+	case context.Canceled:
+		if jobRun.IsCancelRequested() {
+			return entities.ErrJobRunCancelled
+		}
+	case context.DeadlineExceeded:
+		return fmt.Errorf("The job run timed out after %s: %s", timeout, err.Error())
+	}
+	return err
+}
+
+// watchForCancel cancels the job run's context when it is asked to stop, until the returned func is called. Requests made to this worker cancel it straight away, while requests made to another worker instance are picked up by polling.
+func (s *Server) watchForCancel(jobRun *entities.JobRun, cancel context.CancelFunc, jobLogger *logrus.Entry) func() {
+	runID := jobRun.UUID.String()
+	s.runningJobsMutex.Lock()
+	s.runningJobs[runID] = func() {
+		jobRun.RequestCancel()
+		cancel()
+	}
+	s.runningJobsMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(cancelPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			if s.isCancelRequested(jobRun, jobLogger) {
+				jobLogger.Warning("The job run was asked to stop, cancelling it")
+				cancel()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		s.runningJobsMutex.Lock()
+		delete(s.runningJobs, runID)
+		s.runningJobsMutex.Unlock()
+		close(done)
+	}
+}
+
+// saveProgressPeriodically saves the job run every saveProgressInterval until the returned func is called, which waits for any save in progress so that it can't overwrite the run once it has finished
+func (s *Server) saveProgressPeriodically(jobRun *entities.JobRun, jobLogger *logrus.Entry) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(saveProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				s.saveJobRun(jobRun, jobLogger)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// isJobRunInterrupted returns true if the given run is recorded as running but no worker instance holds the lock for its job, which means that the worker running it stopped without finishing it
+func (s *Server) isJobRunInterrupted(jobRun *entities.JobRun) (bool, error) {
+	if jobRun.Status != entities.JobRunStatusRunning {
+		return false, nil
+	}
+
+	lock := &repositories.AdvisoryLock{DB: s.db, Name: jobLockNamePrefix + jobRun.JobName}
+	isLocked, err := lock.TryLock(context.Background())
+	if err != nil || !isLocked {
+		return false, err
+	}
+	return true, lock.Unlock(context.Background())
+}
+
+// cancelRunningJob cancels the context of the job run with the given ID straight away if this worker is running it, and returns false if it isn't
+func (s *Server) cancelRunningJob(runID string) bool {
+	s.runningJobsMutex.Lock()
+	cancel, exists := s.runningJobs[runID]
+	s.runningJobsMutex.Unlock()
+	if exists {
+		cancel()
+	}
+	return exists
+}
+
+// isCancelRequested checks whether the job run has been asked to stop, recording the request on the run if it has. Errors are only logged, so that the run carries on if the database can't be reached.
+func (s *Server) isCancelRequested(jobRun *entities.JobRun, jobLogger *logrus.Entry) bool {
+	isCancelRequested, err := s.jobRunRepository.IsCancelRequested(jobRun.UUID.String())
+	if err != nil {
+		jobLogger.WithError(err).Error("Could not check whether the job run was cancelled")
+		return false
+	}
+
+	if isCancelRequested {
+		jobRun.RequestCancel()
+	}
+	return isCancelRequested
+}
+
+// saveJobRun saves the progress of a job run, only logging errors so that a failure to save the run doesn't fail the job itself
+func (s *Server) saveJobRun(jobRun *entities.JobRun, jobLogger *logrus.Entry) {
+	if err := s.jobRunRepository.UpdateJobRun(jobRun); err != nil {
+		jobLogger.WithError(err).Error("Could not save the job run")
+	}
+}
+
+// initializeOverlapPolicies reads the default overlap policy and the policies for specific jobs from the settings, checking that each policy is for a registered job
+func (s *Server) initializeOverlapPolicies() error {
+	defaultOverlapPolicy, err := jobs.ParseOverlapPolicy(s.Settings.JobOverlap.DefaultPolicy)
+	if err != nil {
+		return err
+	}
+
+	overlapPolicies, err := jobs.ParseOverlapPolicies(s.Settings.JobOverlap.Policies)
+	if err != nil {
+		return err
+	}
+
+	for jobName := range overlapPolicies {
+		if _, exists := s.jobs[jobName]; !exists {
+			return fmt.Errorf("The overlap policy for %s is for a job that does not exist", jobName)
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"defaultOverlapPolicy": defaultOverlapPolicy,
+		"overlapPolicies":      overlapPolicies,
+	}).Info("Initializing the overlap policies for jobs")
+	s.defaultOverlapPolicy = defaultOverlapPolicy
+	s.overlapPolicies = overlapPolicies
+	return nil
+}
+
+// initializeJobTimeouts reads the default timeout and the timeouts for specific jobs from the settings, checking that each timeout is for a registered job
+func (s *Server) initializeJobTimeouts() error {
+	defaultJobTimeout, err := jobs.ParseJobTimeout(s.Settings.JobTimeout.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+
+	jobTimeouts, err := jobs.ParseJobTimeouts(s.Settings.JobTimeout.Timeouts)
+	if err != nil {
+		return err
+	}
+
+	for jobName := range jobTimeouts {
+		if _, exists := s.jobs[jobName]; !exists {
+			return fmt.Errorf("The timeout for %s is for a job that does not exist", jobName)
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"defaultJobTimeout": defaultJobTimeout,
+		"jobTimeouts":       jobTimeouts,
+	}).Info("Initializing the timeouts for jobs")
+	s.defaultJobTimeout = defaultJobTimeout
+	s.jobTimeouts = jobTimeouts
+	return nil
+}
+
+func (s *Server) getJobTimeout(jobName string) time.Duration {
+	if jobTimeout, exists := s.jobTimeouts[jobName]; exists {
+		return jobTimeout
+	}
+	return s.defaultJobTimeout
+}
+
+func (s *Server) getOverlapPolicy(jobName string) string {
+	if overlapPolicy, exists := s.overlapPolicies[jobName]; exists {
+		return overlapPolicy
+	}
+	return s.defaultOverlapPolicy
+}
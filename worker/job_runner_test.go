@@ -0,0 +1,187 @@
+package worker
+
+import (
+	"atgatt-backend/persistence/entities"
+	"atgatt-backend/persistence/repositories"
+	"atgatt-backend/worker/jobs"
+	"atgatt-backend/worker/settings"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/borderstech/artifex"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+	. "github.com/onsi/gomega"
+)
+
+// blockingJob signals each run as it starts, then blocks until it is released or its context is done
+type blockingJob struct {
+	started chan string
+	release chan struct{}
+}
+
+func newBlockingJob() *blockingJob {
+	return &blockingJob{started: make(chan string, 10), release: make(chan struct{})}
+}
+
+func (j *blockingJob) Run(ctx context.Context, jobRun *entities.JobRun) error {
+	j.started <- jobRun.UUID.String()
+	select {
+	case <-j.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newJobRunnerTestServer(jobName string, job jobs.Job, overlapPolicy string, timeout time.Duration) *Server {
+	db := sqlx.MustConnect("pgx", TestDatabaseConnectionString)
+	jobQueue := artifex.NewDispatcher(4, 10)
+	jobQueue.Start()
+
+	return &Server{
+		Settings:               &settings.Settings{},
+		changeReportRepository: &repositories.ChangeReportRepository{DB: db},
+		jobRunRepository:       &repositories.JobRunRepository{DB: db},
+		jobQueue:               jobQueue,
+		jobs:                   map[string]jobs.Job{jobName: job},
+		defaultOverlapPolicy:   overlapPolicy,
+		overlapPolicies:        map[string]string{},
+		defaultJobTimeout:      timeout,
+		jobTimeouts:            map[string]time.Duration{},
+		runningJobs:            map[string]context.CancelFunc{},
+		db:                     db,
+	}
+}
+
+func (s *Server) getSavedJobRun(jobRun *entities.JobRun) *entities.JobRun {
+	savedJobRun, err := s.jobRunRepository.GetByUUID(jobRun.UUID.String())
+	Expect(err).To(BeNil())
+	return savedJobRun
+}
+
+func (s *Server) getJobRunStatus(jobRun *entities.JobRun) func() string {
+	return func() string {
+		savedJobRun, err := s.jobRunRepository.GetByUUID(jobRun.UUID.String())
+		Expect(err).To(BeNil())
+		return savedJobRun.Status
+	}
+}
+
+func Test_runJob_should_skip_a_run_that_overlaps_another_when_the_policy_is_skip(t *testing.T) {
+	RegisterTestingT(t)
+	job := newBlockingJob()
+	s := newJobRunnerTestServer("overlap_skip_job", job, jobs.OverlapPolicySkip, time.Minute)
+
+	firstJobRun, err := s.triggerJob("overlap_skip_job", entities.JobRunTriggerAPI, false, nil)
+	Expect(err).To(BeNil())
+	Eventually(job.started, MaxTimeToWait).Should(Receive(Equal(firstJobRun.UUID.String())))
+
+	secondJobRun, err := s.triggerJob("overlap_skip_job", entities.JobRunTriggerAPI, false, nil)
+	Expect(err).To(BeNil())
+	Eventually(s.getJobRunStatus(secondJobRun), MaxTimeToWait).Should(Equal(entities.JobRunStatusSkipped))
+
+	job.release <- struct{}{}
+	Eventually(s.getJobRunStatus(firstJobRun), MaxTimeToWait).Should(Equal(entities.JobRunStatusSucceeded))
+	Consistently(job.started, 200*time.Millisecond).ShouldNot(Receive())
+}
+
+func Test_runJob_should_run_an_overlapping_run_after_the_first_one_finishes_when_the_policy_is_queue(t *testing.T) {
+	RegisterTestingT(t)
+	job := newBlockingJob()
+	s := newJobRunnerTestServer("overlap_queue_job", job, jobs.OverlapPolicyQueue, time.Minute)
+
+	firstJobRun, err := s.triggerJob("overlap_queue_job", entities.JobRunTriggerAPI, false, nil)
+	Expect(err).To(BeNil())
+	Eventually(job.started, MaxTimeToWait).Should(Receive(Equal(firstJobRun.UUID.String())))
+
+	secondJobRun, err := s.triggerJob("overlap_queue_job", entities.JobRunTriggerAPI, false, nil)
+	Expect(err).To(BeNil())
+	Consistently(job.started, 200*time.Millisecond).ShouldNot(Receive())
+	Expect(s.getJobRunStatus(secondJobRun)()).To(Equal(entities.JobRunStatusQueued))
+
+	job.release <- struct{}{}
+	Eventually(s.getJobRunStatus(firstJobRun), MaxTimeToWait).Should(Equal(entities.JobRunStatusSucceeded))
+	Eventually(job.started, MaxTimeToWait).Should(Receive(Equal(secondJobRun.UUID.String())))
+
+	job.release <- struct{}{}
+	Eventually(s.getJobRunStatus(secondJobRun), MaxTimeToWait).Should(Equal(entities.JobRunStatusSucceeded))
+}
+
+func Test_runJob_should_stop_the_first_run_and_start_the_overlapping_one_when_the_policy_is_cancel(t *testing.T) {
+	RegisterTestingT(t)
+	job := newBlockingJob()
+	s := newJobRunnerTestServer("overlap_cancel_job", job, jobs.OverlapPolicyCancel, time.Minute)
+
+	firstJobRun, err := s.triggerJob("overlap_cancel_job", entities.JobRunTriggerAPI, false, nil)
+	Expect(err).To(BeNil())
+	Eventually(job.started, MaxTimeToWait).Should(Receive(Equal(firstJobRun.UUID.String())))
+
+	secondJobRun, err := s.triggerJob("overlap_cancel_job", entities.JobRunTriggerAPI, false, nil)
+	Expect(err).To(BeNil())
+	Eventually(s.getJobRunStatus(firstJobRun), MaxTimeToWait).Should(Equal(entities.JobRunStatusCancelled))
+	Eventually(job.started, MaxTimeToWait).Should(Receive(Equal(secondJobRun.UUID.String())))
+
+	job.release <- struct{}{}
+	Eventually(s.getJobRunStatus(secondJobRun), MaxTimeToWait).Should(Equal(entities.JobRunStatusSucceeded))
+}
+
+func Test_cancelJobRun_should_stop_a_running_job_and_finish_the_run_as_cancelled(t *testing.T) {
+	RegisterTestingT(t)
+	job := newBlockingJob()
+	s := newJobRunnerTestServer("cancel_endpoint_job", job, jobs.OverlapPolicySkip, time.Minute)
+	e := echo.New()
+	e.POST("/jobs/runs/:id/cancel", s.cancelJobRun)
+
+	jobRun, err := s.triggerJob("cancel_endpoint_job", entities.JobRunTriggerAPI, false, nil)
+	Expect(err).To(BeNil())
+	Eventually(job.started, MaxTimeToWait).Should(Receive(Equal(jobRun.UUID.String())))
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/jobs/runs/"+jobRun.UUID.String()+"/cancel", strings.NewReader("")))
+	Expect(recorder.Code).To(Equal(http.StatusAccepted))
+
+	Eventually(s.getJobRunStatus(jobRun), MaxTimeToWait).Should(Equal(entities.JobRunStatusCancelled))
+	savedJobRun := s.getSavedJobRun(jobRun)
+	Expect(savedJobRun.CancelRequestedAtUTC).ToNot(BeNil())
+	Expect(savedJobRun.Error).To(BeEmpty())
+
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/jobs/runs/"+jobRun.UUID.String()+"/cancel", strings.NewReader("")))
+	Expect(recorder.Code).To(Equal(http.StatusConflict))
+}
+
+func Test_runJob_should_fail_a_run_that_takes_longer_than_the_job_timeout(t *testing.T) {
+	RegisterTestingT(t)
+	job := newBlockingJob()
+	s := newJobRunnerTestServer("timeout_job", job, jobs.OverlapPolicySkip, 100*time.Millisecond)
+
+	jobRun, err := s.triggerJob("timeout_job", entities.JobRunTriggerAPI, false, nil)
+	Expect(err).To(BeNil())
+	Eventually(job.started, MaxTimeToWait).Should(Receive(Equal(jobRun.UUID.String())))
+
+	Eventually(s.getJobRunStatus(jobRun), MaxTimeToWait).Should(Equal(entities.JobRunStatusFailed))
+	Expect(s.getSavedJobRun(jobRun).Error).To(Equal("The job run timed out after 100ms: " + context.DeadlineExceeded.Error()))
+}
+
+func Test_registerJob_should_reject_a_dry_run_of_a_job_that_cannot_be_dry_run(t *testing.T) {
+	RegisterTestingT(t)
+	job := newBlockingJob()
+	s := newJobRunnerTestServer("not_dry_runnable_job", job, jobs.OverlapPolicySkip, time.Minute)
+	e := echo.New()
+	s.registerJob(e, "not_dry_runnable_job", job)
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/jobs/not_dry_runnable_job?dryRun=true", strings.NewReader("{}")))
+	Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+	Consistently(job.started, 100*time.Millisecond).ShouldNot(Receive())
+
+	_, err := s.triggerJob("not_dry_runnable_job", entities.JobRunTriggerAPI, true, nil)
+	cannotDryRunErr := &jobCannotDryRunError{}
+	Expect(errors.As(err, &cannotDryRunErr)).To(BeTrue())
+}
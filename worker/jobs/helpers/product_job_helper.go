@@ -3,13 +3,16 @@ package helpers
 import (
 	"atgatt-backend/application/clients"
 	appEntities "atgatt-backend/application/entities"
-	s3Helpers "atgatt-backend/common/s3"
+	"atgatt-backend/application/parsers"
 	"atgatt-backend/common/text"
 	"atgatt-backend/persistence/entities"
 	"atgatt-backend/persistence/repositories"
+	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
 	"github.com/google/uuid"
@@ -26,36 +29,107 @@ const RevzillaBaseURL string = "https://www.revzilla.com"
 // MinRevzillaProducts represents the minimum number of products that are expected to be returned before an error is thrown (prevents against importing bad HTML when revzilla changes their webpage)
 const MinRevzillaProducts int = 500
 
-// ForEachProduct iterates over all the products in the database and runs the current action on the given product
-func ForEachProduct(productRepository *repositories.ProductRepository, action func(product *entities.Product, productLogger *logrus.Entry) error) error {
-	start := 0
-	limit := 25
-	currProducts, err := productRepository.GetAllPaged(start, limit)
-	if err != nil {
-		return err
+// DefaultMaxRevzillaFailureRatio is the share of products that can fail to import before a RevZilla import fails, which is used when the job doesn't set its own
+const DefaultMaxRevzillaFailureRatio float64 = 0.05
+
+// DefaultMaxConsecutiveRevzillaMisses represents the number of syncs in a row that a product can be missing from RevZilla before it is marked as discontinued (prevents against discontinuing products because of a single bad sync)
+const DefaultMaxConsecutiveRevzillaMisses int = 3
+
+// DefaultMinRevzillaSeenRatio is the share of the products that RevZilla listed before that a listing has to include before the products that it left out are counted as missing
+const DefaultMinRevzillaSeenRatio float64 = 0.5
+
+// MissedProductSettings controls when products that RevZilla stops listing are discontinued
+type MissedProductSettings struct {
+	// MaxConsecutiveMisses is the number of syncs in a row that a product can be missing before it is discontinued, where 0 uses DefaultMaxConsecutiveRevzillaMisses
+	MaxConsecutiveMisses int
+	// MinSeenRatio is the share of the products that were listed before that a listing has to include before any misses are counted, so that a listing that was cut short doesn't discontinue everything that it left out. 0 turns the check off, and nil uses DefaultMinRevzillaSeenRatio.
+	MinSeenRatio *float64
+}
+
+// GetMaxConsecutiveMisses returns the number of syncs in a row that a product can be missing before it is discontinued
+func (s MissedProductSettings) GetMaxConsecutiveMisses() int {
+	if s.MaxConsecutiveMisses <= 0 {
+		return DefaultMaxConsecutiveRevzillaMisses
+	}
+	return s.MaxConsecutiveMisses
+}
+
+// GetMinSeenRatio returns the share of the previously listed products that a listing has to include before any misses are counted
+func (s MissedProductSettings) GetMinSeenRatio() float64 {
+	if s.MinSeenRatio == nil {
+		return DefaultMinRevzillaSeenRatio
+	}
+	return *s.MinSeenRatio
+}
+
+// productPageSize is the number of products that ForEachProduct loads at a time
+const productPageSize int = 25
+
+// ForEachProductOptions controls which products ForEachProduct visits
+type ForEachProductOptions struct {
+	// JobRun gets a checkpoint after each product so that a later run can resume from it, and if it is resuming an earlier run then the products before that run's checkpoint are skipped. It may be nil.
+	JobRun *entities.JobRun
+	// SkipSyncedWithin leaves out products that the job run's job has already synced within the given duration, or visits every product when it is 0
+	SkipSyncedWithin time.Duration
+}
+
+// ForEachProduct iterates over the products in the database in order of ID and runs the current action on each one, stopping with the context's error once it is cancelled or times out. The action returns true if it synced the product, and only those products are recorded as synced by the job run's job, except when dry running, so that products that weren't matched or were left alone are tried again on the next run.
+func ForEachProduct(ctx context.Context, productRepository *repositories.ProductRepository, options ForEachProductOptions, action func(product *entities.Product, productLogger *logrus.Entry) (bool, error)) error {
+	jobRun := options.JobRun
+	jobName := ""
+	if jobRun != nil {
+		jobName = jobRun.JobName
+	}
+	shouldMarkSynced := jobRun != nil && !jobRun.IsDryRun
+
+	lastProductID := 0
+	if checkpoint := jobRun.GetCheckpoint(); checkpoint != nil {
+		lastProductID = checkpoint.LastProductID
+		logrus.WithField("lastProductID", lastProductID).Info("Resuming from the checkpoint of an earlier run")
+	}
+
+	var syncedBeforeUTC *time.Time
+	if options.SkipSyncedWithin > 0 && jobName != "" {
+		skipSyncedAfterUTC := time.Now().UTC().Add(-options.SkipSyncedWithin)
+		syncedBeforeUTC = &skipSyncedAfterUTC
 	}
 
-	for len(currProducts) > 0 {
+	for {
+		currProducts, err := productRepository.GetPageAfterID(ctx, lastProductID, productPageSize, jobName, syncedBeforeUTC)
+		if err != nil {
+			return err
+		}
+
+		if len(currProducts) == 0 {
+			return nil
+		}
+
 		for _, product := range currProducts {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
 			productLogger := logrus.WithFields(
 				logrus.Fields{
 					"productUUID":  product.UUID,
 					"manufacturer": product.Manufacturer,
 					"model":        product.Model,
 				})
-			err := action(&product, productLogger)
+			isSynced, err := action(product, productLogger)
 			if err != nil {
 				return err
 			}
-		}
 
-		start += limit
-		currProducts, err = productRepository.GetAllPaged(start, limit)
-		if err != nil {
-			return err
+			if isSynced && shouldMarkSynced {
+				if err := productRepository.MarkSynced(ctx, product.ID, jobName); err != nil {
+					return err
+				}
+			}
+
+			lastProductID = product.ID
+			jobRun.SetCheckpoint(lastProductID)
 		}
 	}
-	return nil
 }
 
 func getMetaValue(item *goquery.Selection, key string) string {
@@ -82,6 +156,7 @@ func GetRevzillaProductsToScrape(doc *goquery.Document) []*appEntities.RevzillaP
 
 		price := getMetaValue(item, "price")
 		priceCurrency := getMetaValue(item, "priceCurrency")
+		availability := getMetaValue(item, "availability")
 		url := fmt.Sprintf("%s%s", RevzillaBaseURL, urlSuffix)
 		imageURL := getMetaValue(item, "image")
 		name := getMetaValue(item, "name")
@@ -93,6 +168,7 @@ func GetRevzillaProductsToScrape(doc *goquery.Document) []*appEntities.RevzillaP
 			ImageURL:      imageURL,
 			Price:         price,
 			PriceCurrency: priceCurrency,
+			Availability:  availability,
 			Name:          name,
 			URL:           url,
 		})
@@ -108,25 +184,84 @@ func GetRevzillaAffiliateURL(url string) string {
 	return fmt.Sprintf("http://www.anrdoezrs.net/links/8505854/type/dlg/%s", url)
 }
 
-// GetDescriptionPartsForRevzillaProduct returns all of the description text as an array for a given Revzilla product
-func GetDescriptionPartsForRevzillaProduct(revzillaProduct *appEntities.RevzillaProduct, productLogger *logrus.Entry, revzillaClient clients.RevzillaClient) ([]string, error) {
+// GetOfferForRevzillaProduct builds the offer that the given retailer is making for a product found on its listing page. If the detail page listed variants, the offer is priced at the cheapest in-stock variant and is only in stock if at least one variant is.
+func GetOfferForRevzillaProduct(retailer string, revzillaProduct *appEntities.RevzillaProduct) *entities.ProductOffer {
+	offer := &entities.ProductOffer{
+		Retailer:      retailer,
+		PriceCents:    revzillaProduct.GetPriceCents(),
+		Currency:      entities.NormalizeCurrency(revzillaProduct.PriceCurrency),
+		BuyURL:        GetRevzillaAffiliateURL(revzillaProduct.URL),
+		IsInStock:     revzillaProduct.IsInStock(),
+		LastSeenAtUTC: time.Now().UTC(),
+	}
+
+	variants := GetVariantsForRevzillaProduct(revzillaProduct)
+	if offer.IsInStock && len(variants) > 0 {
+		cheapestVariant := entities.GetCheapestInStockVariant(variants)
+		if cheapestVariant == nil {
+			offer.IsInStock = false
+		} else {
+			offer.PriceCents = cheapestVariant.PriceCents
+			offer.Currency = cheapestVariant.Currency
+		}
+	}
+
+	return offer
+}
+
+// GetVariantsForRevzillaProduct converts the variants found on a Revzilla detail page to product variants, using the listing's price and currency for variants that don't have their own
+func GetVariantsForRevzillaProduct(revzillaProduct *appEntities.RevzillaProduct) []*entities.ProductVariant {
+	variants := []*entities.ProductVariant{}
+	for _, revzillaVariant := range revzillaProduct.Variants {
+		variant := &entities.ProductVariant{
+			Size:       entities.NormalizeSize(revzillaVariant.Size),
+			Color:      revzillaVariant.Color,
+			SKU:        revzillaVariant.SKU,
+			PriceCents: revzillaVariant.GetPriceCents(),
+			Currency:   entities.NormalizeCurrency(revzillaVariant.PriceCurrency),
+			IsInStock:  revzillaVariant.IsInStock(),
+		}
+
+		if variant.PriceCents <= 0 {
+			variant.PriceCents = revzillaProduct.GetPriceCents()
+		}
+
+		if revzillaVariant.PriceCurrency == "" {
+			variant.Currency = entities.NormalizeCurrency(revzillaProduct.PriceCurrency)
+		}
+
+		variants = append(variants, variant)
+	}
+
+	return variants
+}
+
+// PopulateRevzillaProductDetails fetches the detail page for a given Revzilla product and fills in its description parts along with the structured data (weight, sizes, colors, rating count, SKU) found on the page
+func PopulateRevzillaProductDetails(ctx context.Context, revzillaProduct *appEntities.RevzillaProduct, productLogger *logrus.Entry, revzillaClient clients.RevzillaClient) error {
 	if revzillaProduct == nil {
-		return nil, errors.New("revzillaProduct must be defined")
+		return errors.New("revzillaProduct must be defined")
 	}
 
 	if productLogger == nil {
-		return nil, errors.New("productLogger must be defined")
+		return errors.New("productLogger must be defined")
 	}
 
 	if revzillaClient == nil {
-		return nil, errors.New("revzillaClient must be defined")
+		return errors.New("revzillaClient must be defined")
 	}
 
-	doc, err := revzillaClient.GetDescriptionPartsHTMLByURL(revzillaProduct.URL)
+	doc, err := revzillaClient.GetDescriptionPartsHTMLByURL(ctx, revzillaProduct.URL)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
+	revzillaProduct.DescriptionParts = getDescriptionPartsFromHTML(doc, productLogger)
+	parsers.ParseRevzillaProductDetails(doc, revzillaProduct)
+	return nil
+}
+
+// getDescriptionPartsFromHTML returns all of the description text as an array for a given Revzilla detail page
+func getDescriptionPartsFromHTML(doc *goquery.Document, productLogger *logrus.Entry) []string {
 	parts := []string{}
 	detailsNode := doc.Find(".product-details__details")
 
@@ -153,19 +288,67 @@ func GetDescriptionPartsForRevzillaProduct(revzillaProduct *appEntities.Revzilla
 	detailsNode.Find("li").Each(func(index int, item *goquery.Selection) {
 		parts = append(parts, item.Text())
 	})
-	return parts, nil
+	return parts
 }
 
-// RunRevzillaImport is a generic function that imports (creates/updates) products found on revzilla.com of the given product type given a doc (goquery HTML doc representing the markup for all of the products in a given category)
+// applyRevzillaProductDetails copies the structured data found on a Revzilla detail page onto the given product, keeping the existing values when nothing was found. The weight and sizes are only filled in when the product doesn't have them yet, since the ones measured by SHARP are more reliable.
+func applyRevzillaProductDetails(product *entities.Product, revzillaProduct *appEntities.RevzillaProduct) {
+	if revzillaProduct.WeightInLbs > 0 && product.WeightInLbs <= 0 {
+		product.WeightInLbs = revzillaProduct.WeightInLbs
+	}
+
+	if len(revzillaProduct.Sizes) > 0 && len(product.Sizes) == 0 {
+		product.Sizes = revzillaProduct.Sizes
+	}
+
+	if len(revzillaProduct.Colors) > 0 {
+		product.Colors = revzillaProduct.Colors
+	}
+
+	if revzillaProduct.RatingCount > 0 {
+		product.RatingCount = revzillaProduct.RatingCount
+	}
+
+	if revzillaProduct.SKU != "" {
+		product.SKU = revzillaProduct.SKU
+	}
+
+	if variants := GetVariantsForRevzillaProduct(revzillaProduct); len(variants) > 0 {
+		existingSizes := product.Sizes
+		product.UpdateVariants(variants)
+		if len(existingSizes) > 0 {
+			product.Sizes = existingSizes
+		}
+	}
+}
+
+// RevzillaImportOptions controls the optional parts of RunRevzillaImport
+type RevzillaImportOptions struct {
+	// JobRun counts the outcome of each product and holds the import report. A job run is created for the import when it is nil.
+	JobRun *entities.JobRun
+	// ChangeReport puts the import in dry run mode when it is set, so that the changes that would have been made are recorded in it instead of being written
+	ChangeReport *entities.ChangeReport
+	// EnableMinProductsCheck fails the import when RevZilla lists fewer than MinRevzillaProducts products, which usually means that its HTML has changed
+	EnableMinProductsCheck bool
+	// MaxFailureRatio fails the import when more than this ratio of the products failed, or DefaultMaxRevzillaFailureRatio when it is nil
+	MaxFailureRatio *float64
+	// MissedProductSettings controls when products that are missing from the listing are discontinued
+	MissedProductSettings MissedProductSettings
+	// IsProductTypeFunc skips listed products that it rejects once their details are known (i.e. limb armor in a category of back protectors), which are treated as unlisted. Every listed product is imported when it is nil.
+	IsProductTypeFunc func(revzillaProduct *appEntities.RevzillaProduct) bool
+}
+
+// RunRevzillaImport is a generic function that imports (creates/updates) products found on revzilla.com of the given product type given a doc (goquery HTML doc representing the markup for all of the products in a given category). The outcome of each product is counted in the job run, with the problems recorded in an import report on it, and the import fails if more than the maximum failure ratio of the products failed. It stops starting new products once the context is done and returns the context's error.
 func RunRevzillaImport(
+	ctx context.Context,
 	productURLPrefix string,
 	productType string,
 	revzillaClient clients.RevzillaClient,
 	productRepository *repositories.ProductRepository,
 	s3Uploader s3manageriface.UploaderAPI,
 	s3Bucket string,
-	enableMinProductsCheck bool,
 	updateCertificationsFunc func(productToPersist *entities.Product, revzillaProduct *appEntities.RevzillaProduct),
+	options RevzillaImportOptions,
 ) error {
 	if productURLPrefix == "" {
 		return errors.New("productURLPrefix cannot be empty")
@@ -191,86 +374,254 @@ func RunRevzillaImport(
 		return errors.New("s3Bucket cannot be empty")
 	}
 
-	doc, err := revzillaClient.GetAllProductOverviewsHTML(productURLPrefix)
+	doc, err := revzillaClient.GetAllProductOverviewsHTML(ctx, productURLPrefix)
 	if err != nil {
 		return err
 	}
 
+	failureRatioLimit := DefaultMaxRevzillaFailureRatio
+	if options.MaxFailureRatio != nil {
+		failureRatioLimit = *options.MaxFailureRatio
+	}
+
+	// The import report reads its counts from the job run, so there has to be one even when the job is run without one
+	jobRun := options.JobRun
+	if jobRun == nil {
+		jobRun = entities.NewJobRun(productType, entities.JobRunTriggerAPI)
+	}
+
 	revzillaProductsToScrape := GetRevzillaProductsToScrape(doc)
-	if enableMinProductsCheck && len(revzillaProductsToScrape) < MinRevzillaProducts {
+	if options.EnableMinProductsCheck && len(revzillaProductsToScrape) < MinRevzillaProducts {
 		return errors.New("Not enough URLs found, check RevZilla's HTML for changes")
 	}
 
+	importReport := entities.NewImportReport(productType, len(revzillaProductsToScrape), failureRatioLimit, jobRun)
+	productWriter := &ProductWriter{ProductRepository: productRepository, S3Uploader: s3Uploader, S3Bucket: s3Bucket, ChangeReport: options.ChangeReport, JobRun: jobRun}
 	sizedWg := sizedwaitgroup.New(4)
+	skippedMutex := sync.Mutex{}
+	skippedExternalIDs := map[string]bool{}
 	for _, revzillaProduct := range revzillaProductsToScrape {
+		if ctx.Err() != nil {
+			break
+		}
+
 		sizedWg.Add()
 		go func(revzillaProduct *appEntities.RevzillaProduct) {
 			defer sizedWg.Done()
-			productLogger := logrus.WithFields(logrus.Fields{
-				"externalID": revzillaProduct.ID,
-				"name":       revzillaProduct.Name,
-			})
-			productLogger.Info("Starting to get a description for a product")
-			revzillaProduct.DescriptionParts, err = GetDescriptionPartsForRevzillaProduct(revzillaProduct, productLogger, revzillaClient)
-			if err != nil {
-				productLogger.WithError(err).Error("Failed to get a description for a product")
+			if !importRevzillaProduct(ctx, revzillaProduct, productType, revzillaClient, productWriter, importReport, options.IsProductTypeFunc, updateCertificationsFunc) {
+				skippedMutex.Lock()
+				skippedExternalIDs[revzillaProduct.ID] = true
+				skippedMutex.Unlock()
 			}
+		}(revzillaProduct)
+	}
 
-			if len(revzillaProduct.DescriptionParts) == 0 {
-				productLogger.Warning("Could not find a description for a product, continuing to the next one")
-			} else {
-				productLogger.Info("Finished getting a description for a product")
-			}
+	sizedWg.Wait()
 
-			existingProduct, err := productRepository.GetByExternalID(revzillaProduct.ID)
-			if err != nil {
-				productLogger.WithError(err).Error(fmt.Sprintf("Could not find a product with externalID: %v", revzillaProduct.ID))
-			}
+	// Products that weren't reached before the run was cancelled would otherwise be counted as missing
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-			if existingProduct != nil {
-				existingProduct.RevzillaPriceCents = revzillaProduct.GetPriceCents()
-				existingProduct.RevzillaBuyURL = GetRevzillaAffiliateURL(revzillaProduct.URL)
-				existingProduct.IsDiscontinued = len(revzillaProduct.DescriptionParts) <= 0
-				existingProduct.UpdateSearchPrice()
-				existingProduct.UpdateSafetyPercentage()
-
-				err = productRepository.UpdateProduct(existingProduct)
-			} else {
-				productToPersist := &entities.Product{
-					OriginalImageURL:   revzillaProduct.ImageURL,
-					Description:        strings.Join(revzillaProduct.DescriptionParts, "<br \\>"),
-					Manufacturer:       revzillaProduct.Brand,
-					Model:              revzillaProduct.GetModel(),
-					RevzillaPriceCents: revzillaProduct.GetPriceCents(),
-					Type:               productType,
-					UUID:               uuid.New(),
-					RevzillaBuyURL:     GetRevzillaAffiliateURL(revzillaProduct.URL),
-					ExternalID:         revzillaProduct.ID,
-				}
+	logrus.WithFields(logrus.Fields{
+		"productType":  productType,
+		"numListed":    len(revzillaProductsToScrape),
+		"counts":       jobRun.GetCounts(),
+		"numWarnings":  importReport.GetNumWarnings(),
+		"failureRatio": importReport.GetFailureRatio(),
+	}).Info("Finished importing products from RevZilla")
 
-				updateCertificationsFunc(productToPersist, revzillaProduct)
+	// Too many failures usually means that something is broken rather than that a few products are bad, so the listing isn't trusted to discontinue anything either
+	if err := importReport.Err(); err != nil {
+		return err
+	}
 
-				if productToPersist.OriginalImageURL != "" {
-					key, err := s3Helpers.CopyImageToS3FromURL(productLogger, s3Uploader, revzillaProduct.ImageURL, s3Bucket)
-					if err != nil {
-						productLogger.WithError(err).Warning("Failed to upload an image to S3, continuing")
-					}
-					productToPersist.ImageKey = key
-				} else {
-					productLogger.Warning("Skipping uploading image to S3 because the URL is empty, continuing")
-				}
+	listedRevzillaProducts := []*appEntities.RevzillaProduct{}
+	for _, revzillaProduct := range revzillaProductsToScrape {
+		if !skippedExternalIDs[revzillaProduct.ID] {
+			listedRevzillaProducts = append(listedRevzillaProducts, revzillaProduct)
+		}
+	}
+	return markMissingRevzillaProducts(ctx, productType, listedRevzillaProducts, productWriter, options.MissedProductSettings)
+}
 
-				productToPersist.UpdateSafetyPercentage()
-				err = productRepository.CreateProduct(productToPersist)
-			}
+// importRevzillaProduct creates or updates the product for a single RevZilla listing, recording its outcome in the import report. It returns false if the listing was skipped because isProductTypeFunc rejected it.
+func importRevzillaProduct(
+	ctx context.Context,
+	revzillaProduct *appEntities.RevzillaProduct,
+	productType string,
+	revzillaClient clients.RevzillaClient,
+	productWriter *ProductWriter,
+	importReport *entities.ImportReport,
+	isProductTypeFunc func(revzillaProduct *appEntities.RevzillaProduct) bool,
+	updateCertificationsFunc func(productToPersist *entities.Product, revzillaProduct *appEntities.RevzillaProduct),
+) bool {
+	productLogger := logrus.WithFields(logrus.Fields{
+		"externalID": revzillaProduct.ID,
+		"name":       revzillaProduct.Name,
+	})
+	productLogger.Info("Starting to get a description for a product")
+	if err := PopulateRevzillaProductDetails(ctx, revzillaProduct, productLogger, revzillaClient); err != nil {
+		productLogger.WithError(err).Error("Failed to get a description for a product")
+		importReport.AddWarning(revzillaProduct.ID, revzillaProduct.Name, entities.ImportStageDetails, err)
+	}
 
-			if err != nil {
-				productLogger.WithError(err).Error("Failed to upsert a product into the database")
+	if len(revzillaProduct.DescriptionParts) == 0 {
+		productLogger.Warning("Could not find a description for a product, continuing to the next one")
+	} else {
+		productLogger.Info("Finished getting a description for a product")
+	}
+
+	if isProductTypeFunc != nil && !isProductTypeFunc(revzillaProduct) {
+		productLogger.WithField("category", revzillaProduct.Category).Info("Skipping a product that isn't a " + productType)
+		productWriter.JobRun.AddSkipped()
+		return false
+	}
+
+	existingProduct, err := productWriter.ProductRepository.GetByExternalID(ctx, revzillaProduct.ID)
+	if err == repositories.ErrEntityNotFound {
+		existingProduct = nil
+	} else if err != nil {
+		// Creating the product anyway could duplicate one that already exists
+		productLogger.WithError(err).Error(fmt.Sprintf("Could not look up a product with externalID: %v", revzillaProduct.ID))
+		failRevzillaProduct(revzillaProduct, importReport, entities.ImportStageLookup, err)
+		return true
+	}
+
+	offer := GetOfferForRevzillaProduct(revzillaClient.GetRetailer(), revzillaProduct)
+	if existingProduct != nil {
+		originalProduct, err := productWriter.CloneForUpdate(existingProduct)
+		if err != nil {
+			productLogger.WithError(err).Error("Failed to clone a product before updating it")
+			failRevzillaProduct(revzillaProduct, importReport, entities.ImportStageUpsert, err)
+			return true
+		}
+
+		existingProduct.RevzillaPriceCents = offer.PriceCents
+		existingProduct.RevzillaBuyURL = GetRevzillaAffiliateURL(revzillaProduct.URL)
+		existingProduct.UpsertOffer(offer)
+		existingProduct.AddRegion(entities.RegionUS)
+		applyRevzillaProductDetails(existingProduct, revzillaProduct)
+		existingProduct.MarkSeen(entities.SourceRevzilla, offer.LastSeenAtUTC)
+		existingProduct.UpdateSafetyPercentage()
+
+		if err := productWriter.UpdateProduct(ctx, originalProduct, existingProduct); err != nil {
+			productLogger.WithError(err).Error("Failed to update a product in the database")
+			failRevzillaProduct(revzillaProduct, importReport, entities.ImportStageUpsert, err)
+			return true
+		}
+
+		return true
+	}
+
+	productToPersist := &entities.Product{
+		OriginalImageURL:   revzillaProduct.ImageURL,
+		Description:        strings.Join(revzillaProduct.DescriptionParts, "<br \\>"),
+		Manufacturer:       revzillaProduct.Brand,
+		Model:              revzillaProduct.GetModel(),
+		RevzillaPriceCents: offer.PriceCents,
+		Type:               productType,
+		UUID:               uuid.New(),
+		RevzillaBuyURL:     GetRevzillaAffiliateURL(revzillaProduct.URL),
+		ExternalID:         revzillaProduct.ID,
+		Offers:             []*entities.ProductOffer{offer},
+		Regions:            []string{entities.RegionUS},
+	}
+
+	productToPersist.MarkSeen(entities.SourceRevzilla, offer.LastSeenAtUTC)
+	applyRevzillaProductDetails(productToPersist, revzillaProduct)
+	updateCertificationsFunc(productToPersist, revzillaProduct)
+
+	if productToPersist.OriginalImageURL != "" {
+		key, err := productWriter.CopyImageToS3(ctx, productLogger, productToPersist)
+		if err != nil {
+			productLogger.WithError(err).Warning("Failed to upload an image to S3, continuing")
+			importReport.AddWarning(revzillaProduct.ID, revzillaProduct.Name, entities.ImportStageImage, err)
+		}
+		productToPersist.ImageKey = key
+	} else {
+		productLogger.Warning("Skipping uploading image to S3 because the URL is empty, continuing")
+	}
+
+	productToPersist.UpdateSafetyPercentage()
+	if err := productWriter.CreateProduct(ctx, productToPersist); err != nil {
+		productLogger.WithError(err).Error("Failed to insert a product into the database")
+		failRevzillaProduct(revzillaProduct, importReport, entities.ImportStageUpsert, err)
+		return true
+	}
+
+	return true
+}
+
+// failRevzillaProduct records a product that couldn't be imported in the import report, which counts it in the job run
+func failRevzillaProduct(revzillaProduct *appEntities.RevzillaProduct, importReport *entities.ImportReport, stage string, err error) {
+	importReport.AddFailed(revzillaProduct.ID, revzillaProduct.Name, stage, err)
+}
+
+// markMissingRevzillaProducts counts a miss against every product of the given type that RevZilla has listed before but didn't list this time, which discontinues the ones that have been missing for too many syncs in a row. Products that are already discontinued are left alone, and no misses are counted when the listing includes too few of the products that were listed before.
+func markMissingRevzillaProducts(ctx context.Context, productType string, revzillaProducts []*appEntities.RevzillaProduct, productWriter *ProductWriter, missedProductSettings MissedProductSettings) error {
+	typeLogger := logrus.WithField("productType", productType)
+	if len(revzillaProducts) == 0 {
+		typeLogger.Warning("Skipping marking missing products because RevZilla didn't list any products, check RevZilla's HTML for changes")
+		return nil
+	}
+
+	listedExternalIDs := map[string]bool{}
+	for _, revzillaProduct := range revzillaProducts {
+		listedExternalIDs[revzillaProduct.ID] = true
+	}
+
+	products, err := productWriter.ProductRepository.GetAllBySource(ctx, entities.SourceRevzilla, productType)
+	if err != nil {
+		return err
+	}
+
+	missingProducts := []*entities.Product{}
+	numPreviouslyListed := 0
+	for _, product := range products {
+		if product.IsDiscontinued {
+			continue
+		}
+
+		numPreviouslyListed++
+		if !listedExternalIDs[product.ExternalID] {
+			missingProducts = append(missingProducts, product)
+		}
+	}
+
+	if numPreviouslyListed > 0 {
+		seenRatio := float64(numPreviouslyListed-len(missingProducts)) / float64(numPreviouslyListed)
+		if seenRatio < missedProductSettings.GetMinSeenRatio() {
+			typeLogger.WithFields(logrus.Fields{
+				"numPreviouslyListed": numPreviouslyListed,
+				"numMissing":          len(missingProducts),
+				"minSeenRatio":        missedProductSettings.GetMinSeenRatio(),
+			}).Warning("Skipping marking missing products because RevZilla listed too few of the products that it listed before, check RevZilla's HTML for changes")
+			return nil
+		}
+	}
+
+	for _, product := range missingProducts {
+		originalProduct, err := productWriter.CloneForUpdate(product)
+		if err != nil {
+			return err
+		}
+
+		productLogger := logrus.WithFields(logrus.Fields{
+			"externalID": product.ExternalID,
+			"uuid":       product.UUID,
+		})
+		if product.MarkMissed(entities.SourceRevzilla, missedProductSettings.GetMaxConsecutiveMisses()) {
+			productLogger.Warning("This product has been missing from RevZilla for too many syncs in a row, marking it as discontinued")
+			if revzillaOffer := product.GetOffer(entities.RetailerRevzilla); revzillaOffer != nil {
+				revzillaOffer.IsInStock = false
 			}
+		}
 
-		}(revzillaProduct)
+		if err := productWriter.UpdateProduct(ctx, originalProduct, product); err != nil {
+			return err
+		}
 	}
 
-	sizedWg.Wait()
 	return nil
 }
@@ -0,0 +1,122 @@
+package helpers
+
+import (
+	s3Helpers "atgatt-backend/common/s3"
+	"atgatt-backend/persistence/entities"
+	"atgatt-backend/persistence/repositories"
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
+	"github.com/sirupsen/logrus"
+)
+
+// ProductWriter saves products to the database and copies their images to S3. When ChangeReport is set it is in dry run mode, so nothing is written and the changes that would have been made are recorded in the report instead. Products that are created or updated are counted in JobRun, which may be nil. The exchange rates that search prices are normalized with are loaded once, when the first product is written.
+type ProductWriter struct {
+	ProductRepository *repositories.ProductRepository
+	S3Uploader        s3manageriface.UploaderAPI
+	S3Bucket          string
+	ChangeReport      *entities.ChangeReport
+	JobRun            *entities.JobRun
+
+	exchangeRatesMutex sync.Mutex
+	exchangeRates      *entities.ExchangeRates
+}
+
+// IsDryRun returns true if changes are only being recorded in the change report
+func (w *ProductWriter) IsDryRun() bool {
+	return w.ChangeReport != nil
+}
+
+// CreateProduct saves a new product
+func (w *ProductWriter) CreateProduct(ctx context.Context, product *entities.Product) error {
+	if w.IsDryRun() {
+		w.ChangeReport.AddCreatedProduct(product)
+		w.JobRun.AddCreated()
+		return nil
+	}
+
+	exchangeRates, err := w.getExchangeRates(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := w.ProductRepository.CreateProduct(ctx, product, exchangeRates); err != nil {
+		return err
+	}
+
+	w.JobRun.AddCreated()
+	return nil
+}
+
+// UpdateProduct saves the changes made to an existing product, where before is a clone of the product from before it was changed. Dry runs only count the product as updated if any of its fields would have changed.
+func (w *ProductWriter) UpdateProduct(ctx context.Context, before *entities.Product, after *entities.Product) error {
+	if w.IsDryRun() {
+		if before == nil {
+			return errors.New("before must be defined when dry running")
+		}
+
+		if !before.IsDiscontinued && after.IsDiscontinued {
+			w.ChangeReport.AddDiscontinuedProduct(after)
+		}
+		isChanged, err := w.ChangeReport.AddUpdatedProduct(before, after)
+		if err != nil {
+			return err
+		}
+
+		if isChanged {
+			w.JobRun.AddUpdated()
+		}
+		return nil
+	}
+
+	exchangeRates, err := w.getExchangeRates(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := w.ProductRepository.UpdateProduct(ctx, after, exchangeRates); err != nil {
+		return err
+	}
+
+	w.JobRun.AddUpdated()
+	return nil
+}
+
+// CopyImageToS3 copies the product's original image to S3 and returns its key. No key is returned when dry running since nothing was uploaded.
+func (w *ProductWriter) CopyImageToS3(ctx context.Context, productLogger *logrus.Entry, product *entities.Product) (string, error) {
+	if w.IsDryRun() {
+		if product.OriginalImageURL == "" {
+			return "", errors.New("url cannot be empty")
+		}
+
+		w.ChangeReport.AddUploadedImage(product, w.S3Bucket, s3Helpers.GetImageKeyForURL(product.OriginalImageURL))
+		return "", nil
+	}
+
+	return s3Helpers.CopyImageToS3FromURL(ctx, productLogger, w.S3Uploader, product.OriginalImageURL, w.S3Bucket)
+}
+
+// CloneForUpdate returns a clone of the product that can be passed to UpdateProduct as the state before any changes, or nil when not dry running since the original isn't needed
+func (w *ProductWriter) CloneForUpdate(product *entities.Product) (*entities.Product, error) {
+	if !w.IsDryRun() || product == nil {
+		return nil, nil
+	}
+
+	return product.Clone()
+}
+
+func (w *ProductWriter) getExchangeRates(ctx context.Context) (*entities.ExchangeRates, error) {
+	w.exchangeRatesMutex.Lock()
+	defer w.exchangeRatesMutex.Unlock()
+
+	if w.exchangeRates == nil {
+		exchangeRates, err := w.ProductRepository.GetExchangeRates(ctx)
+		if err != nil {
+			return nil, err
+		}
+		w.exchangeRates = exchangeRates
+	}
+	return w.exchangeRates, nil
+}
@@ -2,9 +2,10 @@ package jobs
 
 import (
 	"atgatt-backend/application/parsers"
-	s3Helpers "atgatt-backend/common/s3"
 	"atgatt-backend/persistence/entities"
 	"atgatt-backend/persistence/repositories"
+	"atgatt-backend/worker/jobs/helpers"
+	"context"
 	"fmt"
 	"math"
 	"strings"
@@ -16,10 +17,11 @@ import (
 	"github.com/xrash/smetrics"
 )
 
-// ImportHelmetsJob imports all helmet data from SHARP and SNELL into the database. It tries to normalize helmet models and manufacturers while doing this in order to have a clean data set. TODO: Refactor to not upsert if the product already exists, write tests
+// ImportHelmetsJob imports all helmet data from SHARP, SNELL, and the FIM into the database. It tries to normalize helmet models and manufacturers while doing this in order to have a clean data set. TODO: Refactor to not upsert if the product already exists, write tests
 type ImportHelmetsJob struct {
 	ProductRepository      *repositories.ProductRepository
 	SNELLHelmetParser      *parsers.SNELLHelmetParser
+	FIMHelmetParser        *parsers.FIMHelmetParser
 	SHARPHelmetParser      *parsers.SHARPHelmetParser
 	ManufacturerRepository *repositories.ManufacturerRepository
 	S3Uploader             s3manageriface.UploaderAPI
@@ -28,17 +30,29 @@ type ImportHelmetsJob struct {
 
 const helmetType string = "helmet"
 
+// snellStandardsToImport contains every SNELL standard that helmets are still being certified to or sold under
+var snellStandardsToImport = []string{entities.SNELLStandardM2015, entities.SNELLStandardM2020D, entities.SNELLStandardM2020R}
+
 // Run invokes the job and returns an error if any errors occurred while processing the helmet data.
-func (j *ImportHelmetsJob) Run() error {
+func (j *ImportHelmetsJob) Run(ctx context.Context, jobRun *entities.JobRun) error {
+	return j.run(ctx, jobRun, nil)
+}
+
+// DryRun invokes the job without writing to the database or S3, recording the changes that would have been made in the given change report
+func (j *ImportHelmetsJob) DryRun(ctx context.Context, jobRun *entities.JobRun, changeReport *entities.ChangeReport) error {
+	return j.run(ctx, jobRun, changeReport)
+}
+
+func (j *ImportHelmetsJob) run(ctx context.Context, jobRun *entities.JobRun, changeReport *entities.ChangeReport) error {
 	sharpProducts := []*entities.Product{}
 	snellOnlyProducts := []*entities.Product{}
 
-	manufacturers, err := j.ManufacturerRepository.GetAll()
+	manufacturers, err := j.ManufacturerRepository.GetAll(ctx)
 	if err != nil {
 		return err
 	}
 
-	manufacturerAliases, err := j.ProductRepository.GetAllManufacturerAliases()
+	manufacturerAliases, err := j.ProductRepository.GetAllManufacturerAliases(ctx)
 	if err != nil {
 		return err
 	}
@@ -48,13 +62,13 @@ func (j *ImportHelmetsJob) Run() error {
 		manufacturerAliasesMap[manufacturerAlias.Manufacturer] = manufacturerAlias.ManufacturerAlias
 	}
 
-	allModelAliases, err := j.ProductRepository.GetAllModelAliases()
+	allModelAliases, err := j.ProductRepository.GetAllModelAliases(ctx)
 	if err != nil {
 		return err
 	}
 
 	// NOTE: This call blocks for about a minute on average as we need to fetch 400+ HTML files and scrape them for data.
-	sharpHelmets, err := j.SHARPHelmetParser.GetAll()
+	sharpHelmets, err := j.SHARPHelmetParser.GetAll(ctx)
 	if err != nil {
 		return err
 	}
@@ -71,6 +85,7 @@ func (j *ImportHelmetsJob) Run() error {
 			Model:            sharpHelmet.Model,
 			ModelAliases:     matchingModelAliases,
 			MSRPCents:        sharpHelmet.ApproximateMSRPCents,
+			MSRPCurrency:     sharpHelmet.ApproximateMSRPCurrency,
 			RetentionSystem:  sharpHelmet.RetentionSystem,
 			Sizes:            sharpHelmet.Sizes,
 			Subtype:          sharpHelmet.Subtype,
@@ -86,53 +101,103 @@ func (j *ImportHelmetsJob) Run() error {
 			}).Info("Found some aliases for the given model")
 		}
 
+		// SHARP only rates helmets that are sold in the UK, and regional aliases mean that the helmet is sold in that region under another name
+		product.AddRegion(entities.RegionUK)
+		for _, modelAlias := range matchingModelAliases {
+			product.AddRegion(modelAlias.Region)
+		}
+
 		product.HelmetCertifications.SHARP = sharpHelmet.Certifications
-		product.HelmetCertifications.ECE = sharpHelmet.IsECECertified
+		if sharpHelmet.IsECECertified {
+			product.UpdateECECertification(sharpHelmet.ECEVersion)
+		}
 		sharpProducts = append(sharpProducts, product)
 	}
 
-	snellHelmets, err := j.SNELLHelmetParser.GetAllByCertification("M2015")
+	snellHelmets, err := j.SNELLHelmetParser.GetAllByCertifications(ctx, snellStandardsToImport)
 	if err != nil {
 		return err
 	}
 
 	for _, snellHelmet := range snellHelmets {
 		cleanedManufacturer := findCleanedManufacturer(snellHelmet.Manufacturer, manufacturers, manufacturerAliasesMap)
-		matchingSHARPProduct := findMatchingSHARPProduct(cleanedManufacturer, snellHelmet.Model, sharpProducts)
+		matchingSHARPProduct := findMatchingProduct(cleanedManufacturer, snellHelmet.Model, sharpProducts)
 		if matchedAllProducts && matchingSHARPProduct == nil {
 			matchedAllProducts = false
 		}
 
 		if matchingSHARPProduct != nil {
 			logrus.WithFields(logrus.Fields{
-				"manufacturer": matchingSHARPProduct.Manufacturer,
-				"model":        matchingSHARPProduct.Model,
+				"manufacturer":  matchingSHARPProduct.Manufacturer,
+				"model":         matchingSHARPProduct.Model,
+				"snellStandard": snellHelmet.Standard,
 			}).Info("Updated a SHARP helmet to have SNELL and DOT ratings")
-			matchingSHARPProduct.HelmetCertifications.SNELL = true
-			matchingSHARPProduct.HelmetCertifications.DOT = true
-		} else {
-			logrus.WithFields(logrus.Fields{
+			matchingSHARPProduct.UpdateSNELLCertification(snellHelmet.Standard)
+			continue
+		}
+
+		// SNELL lists a helmet once per standard (and sometimes once per shell size), so only keep one product per model
+		existingSNELLOnlyProduct := findProductByModel(cleanedManufacturer, snellHelmet.Model, snellOnlyProducts)
+		if existingSNELLOnlyProduct != nil {
+			existingSNELLOnlyProduct.UpdateSNELLCertification(snellHelmet.Standard)
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"manufacturer":  cleanedManufacturer,
+			"model":         snellHelmet.Model,
+			"snellStandard": snellHelmet.Standard,
+		}).Info("Could not find a matching SHARP helmet, so initializing a helmet with only SNELL and DOT ratings")
+
+		sizes := strings.Split(snellHelmet.Size, ",")
+		snellOnlyProduct := &entities.Product{
+			Manufacturer: cleanedManufacturer,
+			Model:        snellHelmet.Model,
+			UUID:         uuid.New(),
+			Type:         helmetType,
+			Subtype:      snellHelmet.FaceConfig,
+			Sizes:        sizes,
+		}
+		snellOnlyProduct.UpdateSNELLCertification(snellHelmet.Standard)
+		snellOnlyProducts = append(snellOnlyProducts, snellOnlyProduct)
+	}
+
+	combinedProductsList := append(sharpProducts, snellOnlyProducts...)
+
+	// FIM homologated helmets must already be ECE certified, so they should all exist in the SHARP or SNELL data already
+	if j.FIMHelmetParser != nil && j.FIMHelmetParser.Source != "" {
+		fimHelmets, err := j.FIMHelmetParser.GetAll(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, fimHelmet := range fimHelmets {
+			cleanedManufacturer := findCleanedManufacturer(fimHelmet.Manufacturer, manufacturers, manufacturerAliasesMap)
+			fimLogger := logrus.WithFields(logrus.Fields{
 				"manufacturer": cleanedManufacturer,
-				"model":        snellHelmet.Model,
-			}).Info("Could not find a matching SHARP helmet, so initializing a helmet with only SNELL and DOT ratings")
-
-			sizes := strings.Split(snellHelmet.Size, ",")
-			snellOnlyProduct := &entities.Product{
-				Manufacturer: cleanedManufacturer,
-				Model:        snellHelmet.Model,
-				UUID:         uuid.New(),
-				Type:         helmetType,
-				Subtype:      snellHelmet.FaceConfig,
-				Sizes:        sizes,
+				"model":        fimHelmet.Model,
+				"fimStandard":  fimHelmet.Standard,
+			})
+
+			matchingProduct := findMatchingProduct(cleanedManufacturer, fimHelmet.Model, combinedProductsList)
+			if matchingProduct == nil {
+				fimLogger.Warning("Could not find a matching helmet for the FIM homologated helmet, skipping it")
+				continue
 			}
-			snellOnlyProduct.HelmetCertifications.SNELL = true
-			snellOnlyProduct.HelmetCertifications.DOT = true
-			snellOnlyProducts = append(snellOnlyProducts, snellOnlyProduct)
+
+			matchingProduct.UpdateFIMCertification(fimHelmet.Standard, fimHelmet.HomologationNumber)
+			fimLogger.WithField("matchingModel", matchingProduct.Model).Info("Updated a helmet to have an FIM homologation")
 		}
+	} else {
+		logrus.Warning("No FIM helmet list source was configured, skipping FIM homologations")
 	}
 
-	combinedProductsList := append(sharpProducts, snellOnlyProducts...)
+	productWriter := &helpers.ProductWriter{ProductRepository: j.ProductRepository, S3Uploader: j.S3Uploader, S3Bucket: j.S3Bucket, ChangeReport: changeReport, JobRun: jobRun}
 	for _, product := range combinedProductsList {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		productLogger := logrus.WithFields(logrus.Fields{
 			"manufacturer": product.Manufacturer,
 			"model":        product.Model,
@@ -142,10 +207,11 @@ func (j *ImportHelmetsJob) Run() error {
 		validationErr := validator.Validate()
 		if validationErr != nil {
 			productLogger.WithField("validationError", validationErr).Warning("Validation failed, continuing to the next helmet")
+			jobRun.AddFailed(fmt.Sprintf("%s %s", product.Manufacturer, product.Model), validationErr)
 			continue
 		}
 
-		existingProduct, err := j.ProductRepository.GetByModel(product.Manufacturer, product.Model, "helmet")
+		existingProduct, err := j.ProductRepository.GetByModel(ctx, product.Manufacturer, product.Model, "helmet")
 		if err == repositories.ErrEntityNotFound {
 			productLogger.WithError(err).Error(fmt.Sprintf("Could not find a product with manufacturer: %s, model: %s, type: %s",
 				product.Manufacturer,
@@ -157,7 +223,7 @@ func (j *ImportHelmetsJob) Run() error {
 		}
 
 		if product.OriginalImageURL != "" && existingProduct == nil {
-			key, err := s3Helpers.CopyImageToS3FromURL(productLogger, j.S3Uploader, product.OriginalImageURL, j.S3Bucket)
+			key, err := productWriter.CopyImageToS3(ctx, productLogger, product)
 			if err != nil {
 				productLogger.Warning("Could not upload image to S3, saving the product to the DB anyway")
 			} else {
@@ -169,13 +235,26 @@ func (j *ImportHelmetsJob) Run() error {
 
 		product.UpdateSafetyPercentage()
 
+		originalProduct, err := productWriter.CloneForUpdate(existingProduct)
+		if err != nil {
+			return err
+		}
+
 		if existingProduct == nil {
-			err := j.ProductRepository.CreateProduct(product)
+			err := productWriter.CreateProduct(ctx, product)
+			if err != nil {
+				return err
+			}
+		} else if updateExistingHelmetCertifications(existingProduct, product) {
+			productLogger.WithField("existingUUID", existingProduct.UUID).Info("Product already exists, updating its certifications")
+			existingProduct.UpdateSafetyPercentage()
+			err := productWriter.UpdateProduct(ctx, originalProduct, existingProduct)
 			if err != nil {
 				return err
 			}
 		} else {
 			productLogger.WithField("existingUUID", existingProduct.UUID).Warning("Product already exists, skipping it")
+			jobRun.AddSkipped()
 		}
 
 		productLogger.Info("Successfully finished upserting the product")
@@ -184,6 +263,36 @@ func (j *ImportHelmetsJob) Run() error {
 	return nil
 }
 
+// updateExistingHelmetCertifications copies certifications that are newer than the ones on the existing product from the imported product, and returns true if anything changed
+func updateExistingHelmetCertifications(existingProduct *entities.Product, importedProduct *entities.Product) bool {
+	updated := false
+	if importedProduct.HelmetCertifications.SNELL {
+		updated = existingProduct.UpdateSNELLCertification(importedProduct.HelmetCertifications.SNELLStandard) || updated
+	}
+	if importedProduct.HelmetCertifications.ECE {
+		updated = existingProduct.UpdateECECertification(importedProduct.HelmetCertifications.ECEVersion) || updated
+	}
+	if importedProduct.HelmetCertifications.FIM != nil {
+		updated = existingProduct.UpdateFIMCertification(importedProduct.HelmetCertifications.FIM.Standard, importedProduct.HelmetCertifications.FIM.HomologationNumber) || updated
+	}
+	for _, region := range importedProduct.Regions {
+		if !existingProduct.IsSoldInRegion(region) {
+			existingProduct.AddRegion(region)
+			updated = true
+		}
+	}
+	return updated
+}
+
+func findProductByModel(manufacturer string, model string, products []*entities.Product) *entities.Product {
+	for _, product := range products {
+		if strings.EqualFold(product.Manufacturer, manufacturer) && strings.EqualFold(product.Model, model) {
+			return product
+		}
+	}
+	return nil
+}
+
 func findAliasesForModel(allAliases []*entities.ProductModelAlias, manufacturer string, model string) []*entities.ProductModelAlias {
 	matchingAliases := []*entities.ProductModelAlias{}
 	for _, alias := range allAliases {
@@ -197,55 +306,56 @@ func findAliasesForModel(allAliases []*entities.ProductModelAlias, manufacturer
 const boostThreshold float64 = 0.7
 const prefixSize int = 4
 
-func findMatchingSHARPProduct(cleanedSNELLManufacturer string, rawSNELLModel string, sharpProducts []*entities.Product) *entities.Product {
-	possibleSHARPHelmets := []*entities.Product{}
-	for _, sharpHelmet := range sharpProducts {
-		if sharpHelmet.Manufacturer == cleanedSNELLManufacturer {
-			possibleSHARPHelmets = append(possibleSHARPHelmets, sharpHelmet)
+// findMatchingProduct finds the product whose model or model aliases most closely match the given raw model, or returns nil if no product matches closely enough
+func findMatchingProduct(cleanedManufacturer string, rawModel string, products []*entities.Product) *entities.Product {
+	possibleProducts := []*entities.Product{}
+	for _, product := range products {
+		if product.Manufacturer == cleanedManufacturer {
+			possibleProducts = append(possibleProducts, product)
 		}
 	}
 
-	if len(possibleSHARPHelmets) <= 0 {
+	if len(possibleProducts) <= 0 {
 		logrus.WithFields(logrus.Fields{
-			"manufacturer": cleanedSNELLManufacturer,
-			"model":        rawSNELLModel,
+			"manufacturer": cleanedManufacturer,
+			"model":        rawModel,
 		}).Warn("No helmets found for the given manufacturer")
 		return nil
 	}
 
 	confidenceMap := make(map[string]float64)
-	orderedSHARPHelmets := []*entities.Product{}
-	lowerSNELLModel := strings.ToLower(rawSNELLModel)
-	golinq.From(possibleSHARPHelmets).OrderByDescendingT(func(helmet *entities.Product) interface{} {
+	orderedProducts := []*entities.Product{}
+	lowerRawModel := strings.ToLower(rawModel)
+	golinq.From(possibleProducts).OrderByDescendingT(func(helmet *entities.Product) interface{} {
 		var maxConfidence float64
 		for _, alias := range helmet.ModelAliases {
 			lowerAlias := strings.ToLower(alias.ModelAlias)
-			aliasConfidence := smetrics.JaroWinkler(lowerAlias, lowerSNELLModel, boostThreshold, prefixSize)
+			aliasConfidence := smetrics.JaroWinkler(lowerAlias, lowerRawModel, boostThreshold, prefixSize)
 			maxConfidence = math.Max(maxConfidence, aliasConfidence)
 		}
 
-		modelConfidence := smetrics.JaroWinkler(strings.ToLower(helmet.Model), lowerSNELLModel, boostThreshold, prefixSize)
+		modelConfidence := smetrics.JaroWinkler(strings.ToLower(helmet.Model), lowerRawModel, boostThreshold, prefixSize)
 		maxConfidence = math.Max(maxConfidence, modelConfidence)
 		confidenceMap[helmet.Model] = maxConfidence
 		return maxConfidence
-	}).ToSlice(&orderedSHARPHelmets)
+	}).ToSlice(&orderedProducts)
 
-	mostLikelySHARPHelmet := orderedSHARPHelmets[0]
-	confidence := confidenceMap[mostLikelySHARPHelmet.Model]
+	mostLikelyProduct := orderedProducts[0]
+	confidence := confidenceMap[mostLikelyProduct.Model]
 	logEntry := logrus.WithFields(logrus.Fields{
-		"rawSNELLModel":               rawSNELLModel,
-		"mostLikelySHARPModel":        mostLikelySHARPHelmet.Model,
-		"mostLikelySHARPModelAliases": mostLikelySHARPHelmet.ModelAliases,
-		"confidence":                  confidence,
+		"rawModel":               rawModel,
+		"mostLikelyModel":        mostLikelyProduct.Model,
+		"mostLikelyModelAliases": mostLikelyProduct.ModelAliases,
+		"confidence":             confidence,
 	})
 
 	// if we're 90% confident that the model matches, use the value
 	if confidence >= 0.9 {
-		logEntry.Info("High confidence: found matching SHARP model using Jaro-Winkler algorithm")
-		return mostLikelySHARPHelmet
+		logEntry.Info("High confidence: found matching model using Jaro-Winkler algorithm")
+		return mostLikelyProduct
 	}
 
-	logEntry.Warn("Low confidence: SHARP match found, but confidence too low. Ignoring.")
+	logEntry.Warn("Low confidence: match found, but confidence too low. Ignoring.")
 	return nil
 }
 
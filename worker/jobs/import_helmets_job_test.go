@@ -0,0 +1,68 @@
+package jobs_test
+
+import (
+	"atgatt-backend/application/parsers"
+	testHelpers "atgatt-backend/common/testing"
+	"atgatt-backend/persistence/entities"
+	"atgatt-backend/persistence/repositories"
+	"atgatt-backend/worker/jobs"
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	. "github.com/onsi/gomega"
+)
+
+func findProductChange(productChanges []*entities.ProductChange, manufacturer string, model string) *entities.ProductChange {
+	for _, productChange := range productChanges {
+		if productChange.Manufacturer == manufacturer && productChange.Model == model {
+			return productChange
+		}
+	}
+	return nil
+}
+
+func Test_DryRun_should_import_helmets_from_the_recorded_sharp_and_snell_fixtures(t *testing.T) {
+	RegisterTestingT(t)
+	sharpServer := testHelpers.StartFixturesServer("../../seeds/fixtures/sharp")
+	defer sharpServer.Close()
+	snellServer := testHelpers.StartFixturesServer("../../seeds/fixtures/snell")
+	defer snellServer.Close()
+
+	db := sqlx.MustConnect("pgx", TestDatabaseConnectionString)
+	defer db.Close()
+	job := &jobs.ImportHelmetsJob{
+		ProductRepository:      &repositories.ProductRepository{DB: db},
+		SHARPHelmetParser:      &parsers.SHARPHelmetParser{Limit: -1, BaseURL: sharpServer.URL, HTTPClient: sharpServer.Client(), MinHelmets: 3},
+		SNELLHelmetParser:      &parsers.SNELLHelmetParser{BaseURL: snellServer.URL, HTTPClient: snellServer.Client(), MinHelmets: 1},
+		ManufacturerRepository: &repositories.ManufacturerRepository{DB: db},
+		S3Bucket:               "junk",
+	}
+
+	jobRun := entities.NewJobRun("import_helmets", entities.JobRunTriggerAPI)
+	changeReport := entities.NewChangeReport("import_helmets")
+	err := job.DryRun(context.Background(), jobRun, changeReport)
+	Expect(err).To(BeNil())
+	Expect(changeReport.CreatedProducts).To(HaveLen(6))
+	Expect(jobRun.GetCounts().Created).To(Equal(6))
+	Expect(changeReport.UploadedImages).To(HaveLen(3))
+
+	sharpAndSNELLHelmet := findProductChange(changeReport.CreatedProducts, "HJC", "RPHA 11")
+	Expect(sharpAndSNELLHelmet).ToNot(BeNil())
+	Expect(sharpAndSNELLHelmet.Product.HelmetCertifications.SHARP.Stars).To(Equal(5))
+	Expect(sharpAndSNELLHelmet.Product.HelmetCertifications.SNELL).To(BeTrue())
+	Expect(sharpAndSNELLHelmet.Product.HelmetCertifications.SNELLStandard).To(Equal(entities.SNELLStandardM2020D))
+	Expect(sharpAndSNELLHelmet.Product.HelmetCertifications.ECE).To(BeTrue())
+	Expect(sharpAndSNELLHelmet.Product.IsSoldInRegion(entities.RegionUK)).To(BeTrue())
+
+	snellOnlyHelmet := findProductChange(changeReport.CreatedProducts, "Arai", "Corsair-X")
+	Expect(snellOnlyHelmet).ToNot(BeNil())
+	Expect(snellOnlyHelmet.Product.HelmetCertifications.SHARP).To(BeNil())
+	Expect(snellOnlyHelmet.Product.HelmetCertifications.SNELLStandard).To(Equal(entities.SNELLStandardM2020R))
+	Expect(findProductChange(changeReport.CreatedProducts, "Bell", "HP7")).To(BeNil())
+
+	_, err = job.ProductRepository.GetByModel(context.Background(), "HJC", "RPHA 11", "helmet")
+	Expect(err).To(Equal(repositories.ErrEntityNotFound))
+}
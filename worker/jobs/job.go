@@ -1,6 +1,17 @@
 package jobs
 
-// Job defines a generic background task that can either run successfully or return an error
+import (
+	"atgatt-backend/persistence/entities"
+	"context"
+)
+
+// Job defines a generic background task that can either run successfully or return an error. The items that it processes are counted in the given job run, which may be nil. The context is passed to every HTTP request, query, and upload that the job makes, and a job whose context is cancelled or times out stops early and returns the context's error.
 type Job interface {
-	Run() error
+	Run(ctx context.Context, jobRun *entities.JobRun) error
+}
+
+// DryRunnableJob defines a Job that can also run without writing to the database or S3, recording the changes that it would have made in a change report instead
+type DryRunnableJob interface {
+	Job
+	DryRun(ctx context.Context, jobRun *entities.JobRun, changeReport *entities.ChangeReport) error
 }
@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultJobTimeout is how long a job can run for when it doesn't have a timeout configured. It is long enough for the slowest RevZilla sync, so it only stops runs that are stuck.
+const DefaultJobTimeout = 6 * time.Hour
+
+// ParseJobTimeout parses a Go duration such as 90m or 2h, returning the default timeout when it is empty
+func ParseJobTimeout(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return DefaultJobTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("The job timeout %s must be a duration such as 90m or 2h", value)
+	}
+
+	if timeout <= 0 {
+		return 0, fmt.Errorf("The job timeout %s must be greater than zero", value)
+	}
+	return timeout, nil
+}
+
+// ParseJobTimeouts parses a comma separated list of timeouts for specific jobs in the form job=duration, i.e. import_helmets=30m,sync_revzilla_jackets=3h
+func ParseJobTimeouts(value string) (map[string]time.Duration, error) {
+	timeouts := map[string]time.Duration{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		jobAndTimeout := strings.SplitN(entry, "=", 2)
+		if len(jobAndTimeout) != 2 || strings.TrimSpace(jobAndTimeout[0]) == "" || strings.TrimSpace(jobAndTimeout[1]) == "" {
+			return nil, fmt.Errorf("The job timeout %s must be in the form job=duration", entry)
+		}
+
+		timeout, err := ParseJobTimeout(jobAndTimeout[1])
+		if err != nil {
+			return nil, err
+		}
+		timeouts[strings.TrimSpace(jobAndTimeout[0])] = timeout
+	}
+	return timeouts, nil
+}
@@ -0,0 +1,31 @@
+package jobs_test
+
+import (
+	"atgatt-backend/worker/jobs"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_ParseJobTimeouts_should_parse_a_list_of_jobs_and_timeouts(t *testing.T) {
+	RegisterTestingT(t)
+
+	timeouts, err := jobs.ParseJobTimeouts("import_helmets=30m, sync_revzilla_jackets=3h")
+	Expect(err).To(BeNil())
+	Expect(timeouts).To(Equal(map[string]time.Duration{
+		"import_helmets":        30 * time.Minute,
+		"sync_revzilla_jackets": 3 * time.Hour,
+	}))
+
+	timeout, err := jobs.ParseJobTimeout("")
+	Expect(err).To(BeNil())
+	Expect(timeout).To(Equal(jobs.DefaultJobTimeout))
+
+	_, err = jobs.ParseJobTimeouts("import_helmets")
+	Expect(err).ToNot(BeNil())
+	_, err = jobs.ParseJobTimeouts("import_helmets=soon")
+	Expect(err).ToNot(BeNil())
+	_, err = jobs.ParseJobTimeouts("import_helmets=-5m")
+	Expect(err).ToNot(BeNil())
+}
@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"atgatt-backend/application/parsers"
+	"atgatt-backend/persistence/entities"
+	"atgatt-backend/persistence/repositories"
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LoadExchangeRatesJob loads the latest currency exchange rates into the database, rebased to the configured base currency, so that prices in other currencies can be normalized
+type LoadExchangeRatesJob struct {
+	ExchangeRatesParser    *parsers.ExchangeRatesParser
+	ExchangeRateRepository *repositories.ExchangeRateRepository
+	BaseCurrency           string
+}
+
+// Run executes the job
+func (j *LoadExchangeRatesJob) Run(ctx context.Context, jobRun *entities.JobRun) error {
+	if j.ExchangeRatesParser.Source == "" {
+		logrus.Warn("Skipping loading exchange rates because no exchange rates source was configured")
+		return nil
+	}
+
+	exchangeRates, err := j.ExchangeRatesParser.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	rebasedExchangeRates, err := exchangeRates.Rebase(j.BaseCurrency)
+	if err != nil {
+		return err
+	}
+
+	existingExchangeRates, err := j.ExchangeRateRepository.GetByBaseCurrency(ctx, rebasedExchangeRates.GetBaseCurrency())
+	if err != nil {
+		return err
+	}
+
+	err = j.ExchangeRateRepository.UpsertExchangeRates(ctx, rebasedExchangeRates)
+	if err != nil {
+		return err
+	}
+
+	// Only count the rates that are new or changed, since every rate is rewritten on every run
+	for currency, rate := range rebasedExchangeRates.Rates {
+		existingRate, exists := existingExchangeRates.Rates[entities.NormalizeCurrency(currency)]
+		if !exists {
+			jobRun.AddCreated()
+		} else if existingRate != rate {
+			jobRun.AddUpdated()
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"baseCurrency": rebasedExchangeRates.BaseCurrency,
+		"numRates":     len(rebasedExchangeRates.Rates),
+	}).Info("Finished loading exchange rates")
+	return nil
+}
@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OverlapPolicySkip means that a job triggered while another run of it is in progress is skipped
+const OverlapPolicySkip = "skip"
+
+// OverlapPolicyQueue means that a job triggered while another run of it is in progress waits for that run to finish
+const OverlapPolicyQueue = "queue"
+
+// OverlapPolicyCancel means that a job triggered while another run of it is in progress cancels that run, then waits for it to stop
+const OverlapPolicyCancel = "cancel"
+
+// DefaultOverlapPolicy is used for jobs that don't have a policy configured
+const DefaultOverlapPolicy = OverlapPolicySkip
+
+// ParseOverlapPolicy checks that the given policy is skip, queue, or cancel, returning the default policy when it is empty
+func ParseOverlapPolicy(value string) (string, error) {
+	policy := strings.ToLower(strings.TrimSpace(value))
+	switch policy {
+	case "":
+		return DefaultOverlapPolicy, nil
+	case OverlapPolicySkip, OverlapPolicyQueue, OverlapPolicyCancel:
+		return policy, nil
+	}
+	return "", fmt.Errorf("The overlap policy %s must be one of %s, %s, or %s", value, OverlapPolicySkip, OverlapPolicyQueue, OverlapPolicyCancel)
+}
+
+// ParseOverlapPolicies parses a comma separated list of overlap policies for specific jobs in the form job=policy, i.e. import_helmets=queue,sync_revzilla_jackets=cancel
+func ParseOverlapPolicies(value string) (map[string]string, error) {
+	policies := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		jobAndPolicy := strings.SplitN(entry, "=", 2)
+		if len(jobAndPolicy) != 2 || strings.TrimSpace(jobAndPolicy[0]) == "" || strings.TrimSpace(jobAndPolicy[1]) == "" {
+			return nil, fmt.Errorf("The overlap policy %s must be in the form job=policy", entry)
+		}
+
+		policy, err := ParseOverlapPolicy(jobAndPolicy[1])
+		if err != nil {
+			return nil, err
+		}
+		policies[strings.TrimSpace(jobAndPolicy[0])] = policy
+	}
+	return policies, nil
+}
@@ -0,0 +1,28 @@
+package jobs_test
+
+import (
+	"atgatt-backend/worker/jobs"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_ParseOverlapPolicies_should_parse_a_list_of_jobs_and_policies(t *testing.T) {
+	RegisterTestingT(t)
+
+	policies, err := jobs.ParseOverlapPolicies("import_helmets=queue, sync_revzilla_jackets=Cancel")
+	Expect(err).To(BeNil())
+	Expect(policies).To(Equal(map[string]string{
+		"import_helmets":        jobs.OverlapPolicyQueue,
+		"sync_revzilla_jackets": jobs.OverlapPolicyCancel,
+	}))
+
+	policy, err := jobs.ParseOverlapPolicy("")
+	Expect(err).To(BeNil())
+	Expect(policy).To(Equal(jobs.OverlapPolicySkip))
+
+	_, err = jobs.ParseOverlapPolicies("import_helmets")
+	Expect(err).ToNot(BeNil())
+	_, err = jobs.ParseOverlapPolicies("import_helmets=wait")
+	Expect(err).ToNot(BeNil())
+}
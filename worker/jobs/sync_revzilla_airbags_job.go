@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"atgatt-backend/application/clients"
+	appEntities "atgatt-backend/application/entities"
+	"atgatt-backend/persistence/entities"
+	"atgatt-backend/persistence/repositories"
+	"atgatt-backend/worker/jobs/helpers"
+	"context"
+
+	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
+)
+
+// SyncRevzillaAirbagsJob scrapes all of RevZilla's airbag vest data
+type SyncRevzillaAirbagsJob struct {
+	ProductRepository      *repositories.ProductRepository
+	RevzillaClient         clients.RevzillaClient
+	S3Uploader             s3manageriface.UploaderAPI
+	S3Bucket               string
+	EnableMinProductsCheck bool
+	MaxFailureRatio        *float64
+	MissedProductSettings  helpers.MissedProductSettings
+}
+
+// Run executes the job
+func (j *SyncRevzillaAirbagsJob) Run(ctx context.Context, jobRun *entities.JobRun) error {
+	return j.run(ctx, jobRun, nil)
+}
+
+// DryRun executes the job without writing to the database or S3, recording the changes that would have been made in the given change report
+func (j *SyncRevzillaAirbagsJob) DryRun(ctx context.Context, jobRun *entities.JobRun, changeReport *entities.ChangeReport) error {
+	return j.run(ctx, jobRun, changeReport)
+}
+
+func (j *SyncRevzillaAirbagsJob) run(ctx context.Context, jobRun *entities.JobRun, changeReport *entities.ChangeReport) error {
+	updateCertsFunc := func(productToPersist *entities.Product, revzillaProduct *appEntities.RevzillaProduct) {
+		productToPersist.UpdateAirbagCertificationsByDescriptionParts(revzillaProduct.DescriptionParts)
+		productToPersist.UpdateGenericSubtypeByDescriptionParts(revzillaProduct.DescriptionParts)
+	}
+
+	// The airbag category also lists cartridges, chargers and airbag-ready jackets, which mention airbags without being one
+	isAirbagFunc := func(revzillaProduct *appEntities.RevzillaProduct) bool {
+		return revzillaProduct.IsInCategory("airbag vest", "airbag system")
+	}
+
+	return helpers.RunRevzillaImport(ctx, "motorcycle-airbag-vests", entities.ProductTypeAirbag, j.RevzillaClient, j.ProductRepository, j.S3Uploader, j.S3Bucket, updateCertsFunc, helpers.RevzillaImportOptions{
+		JobRun:                 jobRun,
+		ChangeReport:           changeReport,
+		EnableMinProductsCheck: j.EnableMinProductsCheck,
+		MaxFailureRatio:        j.MaxFailureRatio,
+		MissedProductSettings:  j.MissedProductSettings,
+		IsProductTypeFunc:      isAirbagFunc,
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"atgatt-backend/persistence/entities"
 	"atgatt-backend/persistence/repositories"
 	"atgatt-backend/worker/jobs/helpers"
+	"context"
 
 	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
 )
@@ -17,17 +18,35 @@ type SyncRevzillaBootsJob struct {
 	S3Uploader             s3manageriface.UploaderAPI
 	S3Bucket               string
 	EnableMinProductsCheck bool
+	MaxFailureRatio        *float64
+	MissedProductSettings  helpers.MissedProductSettings
 }
 
 // Run executes the job
-func (j *SyncRevzillaBootsJob) Run() error {
+func (j *SyncRevzillaBootsJob) Run(ctx context.Context, jobRun *entities.JobRun) error {
+	return j.run(ctx, jobRun, nil)
+}
+
+// DryRun executes the job without writing to the database or S3, recording the changes that would have been made in the given change report
+func (j *SyncRevzillaBootsJob) DryRun(ctx context.Context, jobRun *entities.JobRun, changeReport *entities.ChangeReport) error {
+	return j.run(ctx, jobRun, changeReport)
+}
+
+func (j *SyncRevzillaBootsJob) run(ctx context.Context, jobRun *entities.JobRun, changeReport *entities.ChangeReport) error {
 	updateCertsFunc := func(productToPersist *entities.Product, revzillaProduct *appEntities.RevzillaProduct) {
 		updated, newZone := productToPersist.UpdateSingleZoneCertificationsByDescriptionParts(productToPersist.BootsCertifications.Overall, revzillaProduct.DescriptionParts)
 		if updated {
 			productToPersist.BootsCertifications.Overall = newZone
 		}
+		productToPersist.UpdateBootsCertificationsByDescriptionParts(revzillaProduct.DescriptionParts)
 		productToPersist.UpdateGenericSubtypeByDescriptionParts(revzillaProduct.DescriptionParts)
 	}
 
-	return helpers.RunRevzillaImport("motorcycle-boots", "boots", j.RevzillaClient, j.ProductRepository, j.S3Uploader, j.S3Bucket, j.EnableMinProductsCheck, updateCertsFunc)
+	return helpers.RunRevzillaImport(ctx, "motorcycle-boots", "boots", j.RevzillaClient, j.ProductRepository, j.S3Uploader, j.S3Bucket, updateCertsFunc, helpers.RevzillaImportOptions{
+		JobRun:                 jobRun,
+		ChangeReport:           changeReport,
+		EnableMinProductsCheck: j.EnableMinProductsCheck,
+		MaxFailureRatio:        j.MaxFailureRatio,
+		MissedProductSettings:  j.MissedProductSettings,
+	})
 }
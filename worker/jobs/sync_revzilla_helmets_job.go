@@ -1,35 +1,51 @@
 package jobs
 
 import (
-	httpHelpers "atgatt-backend/common/http"
+	"atgatt-backend/application/clients"
 	"atgatt-backend/persistence/entities"
 	"atgatt-backend/persistence/repositories"
 	"atgatt-backend/worker/jobs/helpers"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"strings"
 	"time"
 
 	golinq "github.com/ahmetb/go-linq"
-	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	"github.com/sirupsen/logrus"
 	"github.com/xrash/smetrics"
 )
 
-// SyncRevzillaHelmetsJob syncs revzilla price and buy urls by calling the CJ Affiliate API and pointing it at RevZilla's advertiser ID
+// SyncRevzillaHelmetsJob syncs revzilla price and buy urls by searching RevZilla's product feed with the CJ Affiliate API. When SkipSyncedWithin is set, products that were synced more recently than that are left alone. MaxConsecutiveMisses defaults to helpers.DefaultMaxConsecutiveRevzillaMisses when it isn't set.
 type SyncRevzillaHelmetsJob struct {
-	ProductRepository *repositories.ProductRepository
-	CJAPIKey          string
+	ProductRepository    *repositories.ProductRepository
+	CJClient             clients.CJClient
+	SkipSyncedWithin     time.Duration
+	MaxConsecutiveMisses int
 }
 
 const bestMatchConfidenceThreshold float64 = 0.8
 
+// maxCJRateLimitRetries is the number of times that a product is searched for again after CJ rate limits the search, before the run is stopped
+const maxCJRateLimitRetries int = 3
+
+// defaultCJRateLimitWait is how long to wait before searching again when CJ rate limits a search without saying how long to wait
+const defaultCJRateLimitWait time.Duration = time.Minute
+
+// maxCJRateLimitWait is the longest that CJ's rate limit is waited out for, since a run that would wait longer is better resumed later
+const maxCJRateLimitWait time.Duration = 15 * time.Minute
+
+// minRevzillaHelmetPrice leaves out cheap helmet accessories that would otherwise match a helmet's name
+const minRevzillaHelmetPrice float64 = 200
+
 // Run executes the job
-func (j *SyncRevzillaHelmetsJob) Run() error {
-	pooledClient := cleanhttp.DefaultPooledClient()
-	return helpers.ForEachProduct(j.ProductRepository, func(product *entities.Product, productLogger *logrus.Entry) error {
+func (j *SyncRevzillaHelmetsJob) Run(ctx context.Context, jobRun *entities.JobRun) error {
+	exchangeRates, err := j.ProductRepository.GetExchangeRates(ctx)
+	if err != nil {
+		return err
+	}
+
+	return helpers.ForEachProduct(ctx, j.ProductRepository, helpers.ForEachProductOptions{JobRun: jobRun, SkipSyncedWithin: j.SkipSyncedWithin}, func(product *entities.Product, productLogger *logrus.Entry) (bool, error) {
 		modelsToTry := []string{product.Model}
 		modelAliasStrings := []string{}
 		golinq.From(product.ModelAliases).SelectT(func(modelAlias *entities.ProductModelAlias) string {
@@ -38,9 +54,12 @@ func (j *SyncRevzillaHelmetsJob) Run() error {
 		modelsToTry = append(modelsToTry, modelAliasStrings...)
 		var highestConfidenceProductMatch *productMatch = nil
 		for _, modelToTry := range modelsToTry {
-			currProductMatch, err := j.getBestMatchForProduct(pooledClient, product, modelToTry, productLogger)
+			currProductMatch, err := j.getBestMatchForProductWithRetries(ctx, product, modelToTry, productLogger)
+			if errors.Is(err, clients.ErrCJRateLimited) {
+				return false, fmt.Errorf("Stopped syncing helmets because CJ is rate limiting requests, resume the run once the limit resets: %s", err.Error())
+			}
 			if err != nil {
-				return err
+				return false, err
 			}
 
 			if currProductMatch == nil {
@@ -54,112 +73,121 @@ func (j *SyncRevzillaHelmetsJob) Run() error {
 
 		if highestConfidenceProductMatch == nil {
 			productLogger.Info("Could not find a matching product on revzilla, continuing to the next product")
-			return nil
+			jobRun.AddSkipped()
+			return false, j.markProductMissed(ctx, product, exchangeRates, productLogger)
 		}
 
-		err := j.updateProduct(product, highestConfidenceProductMatch, productLogger)
+		err := j.updateProduct(ctx, product, highestConfidenceProductMatch, exchangeRates, productLogger)
 		if err != nil {
-			return err
+			return false, err
 		}
+		jobRun.AddUpdated()
 
 		productLogger.Info("Successfully synced product")
-		return nil
+		return true, nil
 	})
 }
 
-func (j *SyncRevzillaHelmetsJob) updateProduct(product *entities.Product, productMatch *productMatch, productLogger *logrus.Entry) error {
+func (j *SyncRevzillaHelmetsJob) updateProduct(ctx context.Context, product *entities.Product, productMatch *productMatch, exchangeRates *entities.ExchangeRates, productLogger *logrus.Entry) error {
 	confidenceLogFields := logrus.Fields{
 		"matchConfidence":             productMatch.ConfidenceScore,
 		"matchingRevzillaProductName": productMatch.CJProduct.Name,
-		"isDiscontinued":              productMatch.IsDiscontinued,
 		"manufacturer":                product.Manufacturer,
 		"modelToTry":                  product.Model,
 	}
 
-	if productMatch.IsDiscontinued {
-		productLogger.WithFields(confidenceLogFields).Warning("This product is discontinued, updating the discontinued flag and continuing to the next product")
-		product.IsDiscontinued = true
-		err := j.ProductRepository.UpdateProduct(product)
-		if err != nil {
-			return err
-		}
-	} else {
-		product.RevzillaBuyURL = productMatch.CJProduct.LinkCode.ClickURL
-		product.RevzillaPriceCents = int(productMatch.CJProduct.GetPrice() * 100)
-		product.IsDiscontinued = false
-		product.UpdateHelmetCertificationsByDescription(productMatch.CJProduct.Description)
-		product.UpdateSearchPrice()
-		product.UpdateSafetyPercentage()
-		product.Description = productMatch.CJProduct.Description
-		productLogger.WithFields(confidenceLogFields).Info("Set new price and buy URL from RevZilla")
-		err := j.ProductRepository.UpdateProduct(product)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	seenAtUTC := time.Now().UTC()
+	product.RevzillaBuyURL = productMatch.CJProduct.LinkCode.ClickURL
+	product.RevzillaPriceCents = int(productMatch.CJProduct.GetPrice() * 100)
+	product.MarkSeen(entities.SourceCJ, seenAtUTC)
+	product.AddRegion(entities.RegionUS)
+	product.UpsertOffer(&entities.ProductOffer{
+		Retailer:      entities.RetailerRevzilla,
+		PriceCents:    product.RevzillaPriceCents,
+		Currency:      entities.CurrencyUSD,
+		BuyURL:        product.RevzillaBuyURL,
+		IsInStock:     true,
+		LastSeenAtUTC: seenAtUTC,
+	})
+	product.UpdateHelmetCertificationsByDescription(productMatch.CJProduct.Description)
+	product.UpdateSafetyPercentage()
+	product.Description = productMatch.CJProduct.Description
+	productLogger.WithFields(confidenceLogFields).Info("Set new price and buy URL from RevZilla")
+	return j.ProductRepository.UpdateProduct(ctx, product, exchangeRates)
 }
 
-func (j *SyncRevzillaHelmetsJob) searchCJProducts(pooledClient *http.Client, manufacturer string, model string) (*entities.CJProductsResponseWrapper, error) {
-	// Adding a delay to each request here because CJ has an absurdly low threshold for requests per minute
-	time.Sleep(3 * time.Second)
-	req, err := http.NewRequest(http.MethodPost, "https://ads.api.cj.com/query", strings.NewReader(fmt.Sprintf(`{
-		shoppingProducts(companyId: "5023498", partnerIds: ["3318586"], keywords: ["%s", "%s", "helmet"], offset: 1, limit: 100, lowPrice: 200) {
-		  resultList {
-			linkCode(pid: "8505854") {
-			  clickUrl
-			},
-			title,
-			price {
-			  amount
-			},
-			imageLink,
-			productType,
-			description
-		  }
+// markProductMissed counts a miss against a product that CJ has matched before, which discontinues it once it has been missing for too many syncs in a row. Products that are already discontinued are left alone.
+func (j *SyncRevzillaHelmetsJob) markProductMissed(ctx context.Context, product *entities.Product, exchangeRates *entities.ExchangeRates, productLogger *logrus.Entry) error {
+	if _, exists := product.Sources[entities.SourceCJ]; !exists || product.IsDiscontinued {
+		return nil
+	}
+
+	maxConsecutiveMisses := helpers.MissedProductSettings{MaxConsecutiveMisses: j.MaxConsecutiveMisses}.GetMaxConsecutiveMisses()
+	if product.MarkMissed(entities.SourceCJ, maxConsecutiveMisses) {
+		productLogger.Warning("This product has been missing from RevZilla for too many syncs in a row, marking it as discontinued")
+		if revzillaOffer := product.GetOffer(entities.RetailerRevzilla); revzillaOffer != nil {
+			revzillaOffer.IsInStock = false
 		}
-	  }`, manufacturer, model)))
-	if err != nil {
-		return nil, err
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", j.CJAPIKey))
+	return j.ProductRepository.UpdateProduct(ctx, product, exchangeRates)
+}
 
-	resp, err := pooledClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+// getBestMatchForProductWithRetries waits out CJ's rate limit and searches for the product again when a search is rate limited, so that one rate limited search doesn't stop the run
+func (j *SyncRevzillaHelmetsJob) getBestMatchForProductWithRetries(ctx context.Context, product *entities.Product, modelToTry string, productLogger *logrus.Entry) (*productMatch, error) {
+	for numRetries := 0; ; numRetries++ {
+		currProductMatch, err := j.getBestMatchForProduct(ctx, product, modelToTry, productLogger)
+		apiErr := &clients.CJAPIError{}
+		if !errors.Is(err, clients.ErrCJRateLimited) || !errors.As(err, &apiErr) || numRetries >= maxCJRateLimitRetries {
+			return currProductMatch, err
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("CJ API returned a status code of %d but expected 200", resp.StatusCode)
-	}
+		retryAfter := apiErr.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = defaultCJRateLimitWait
+		}
+		if retryAfter > maxCJRateLimitWait {
+			return nil, err
+		}
 
-	responseBodyBytes, _ := ioutil.ReadAll(resp.Body)
-	cjResp := &entities.CJProductsResponseWrapper{}
-	if err = json.Unmarshal(responseBodyBytes, cjResp); err != nil {
-		return nil, err
+		productLogger.WithFields(logrus.Fields{"retryAfter": retryAfter.String(), "numRetries": numRetries + 1}).Warning("CJ is rate limiting requests, waiting before searching for this product again")
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryAfter):
+		}
 	}
-	return cjResp, nil
 }
 
 type productMatch struct {
 	CJProduct       *entities.CJProduct
 	ConfidenceScore float64
-	IsDiscontinued  bool
 }
 
-func (j *SyncRevzillaHelmetsJob) getBestMatchForProduct(pooledClient *http.Client, product *entities.Product, modelToTry string, productLogger *logrus.Entry) (*productMatch, error) {
-	cjResp, err := j.searchCJProducts(pooledClient, product.Manufacturer, modelToTry)
+func (j *SyncRevzillaHelmetsJob) getBestMatchForProduct(ctx context.Context, product *entities.Product, modelToTry string, productLogger *logrus.Entry) (*productMatch, error) {
+	search := &clients.CJProductSearch{
+		PartnerIDs: []string{clients.CJRevzillaAdvertiserID},
+		Keywords:   []string{product.Manufacturer, modelToTry, "helmet"},
+		LowPrice:   minRevzillaHelmetPrice,
+	}
+	searchResult, err := j.CJClient.SearchProducts(ctx, search)
 	if err != nil {
 		return nil, err
 	}
 
+	if searchResult.IsTruncated() {
+		productLogger.WithFields(logrus.Fields{
+			"keywords":    search.Keywords,
+			"numReturned": len(searchResult.Products),
+			"totalCount":  searchResult.TotalCount,
+		}).Warning("CJ matched more products than a search returns, so the best match may have been left out")
+	}
+
 	var matchingRevzillaProductsSlice []entities.CJProduct
 	confidenceMap := make(map[string]float64)
 	expectedLowerProductName := strings.ToLower(fmt.Sprintf("%s %s", product.Manufacturer, modelToTry))
 
-	golinq.From(cjResp.Data.ShoppingProducts.ResultList).WhereT(func(product entities.CJProduct) bool {
+	golinq.From(searchResult.Products).WhereT(func(product entities.CJProduct) bool {
 		return product.IsHelmet()
 	}).OrderByDescendingT(func(product entities.CJProduct) interface{} {
 		lowerProductName := strings.ToLower(product.Name)
@@ -178,23 +206,15 @@ func (j *SyncRevzillaHelmetsJob) getBestMatchForProduct(pooledClient *http.Clien
 
 	bestMatchRevzillaProduct := &matchingRevzillaProductsSlice[0]
 	bestMatchConfidence := confidenceMap[strings.ToLower(bestMatchRevzillaProduct.Name)]
-	buyURLContents, err := httpHelpers.GetContentsAtURL(bestMatchRevzillaProduct.LinkCode.ClickURL)
-	if err != nil {
-		return nil, err
-	}
-
-	// If we don't have a product summary, it means we couldn't find the product
-	isDiscontinued := !strings.Contains(strings.ToLower(buyURLContents), "product-show-summary")
 	if bestMatchConfidence < bestMatchConfidenceThreshold {
 		productLogger.WithFields(logrus.Fields{
 			"matchConfidence":             bestMatchConfidence,
 			"matchingRevzillaProductName": bestMatchRevzillaProduct.Name,
-			"isDiscontinued":              isDiscontinued,
 			"manufacturer":                product.Manufacturer,
 			"modelToTry":                  modelToTry,
 		}).Warning("Could not find a price or buy URL from RevZilla because the best match had a low confidence score")
 		return nil, nil
 	}
 
-	return &productMatch{CJProduct: bestMatchRevzillaProduct, ConfidenceScore: bestMatchConfidence, IsDiscontinued: isDiscontinued}, nil
+	return &productMatch{CJProduct: bestMatchRevzillaProduct, ConfidenceScore: bestMatchConfidence}, nil
 }
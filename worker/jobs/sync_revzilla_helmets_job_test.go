@@ -1,12 +1,21 @@
 package jobs_test
 
 import (
+	"atgatt-backend/application/clients"
+	testHelpers "atgatt-backend/common/testing"
+	"atgatt-backend/persistence/entities"
 	"atgatt-backend/persistence/repositories"
+	"atgatt-backend/seeds"
+	"atgatt-backend/worker/jobs"
+	"atgatt-backend/worker/jobs/helpers"
+	"context"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
 
 	_ "github.com/jackc/pgx/v4/stdlib"
 	. "github.com/onsi/gomega"
@@ -20,15 +29,15 @@ func Test_sync_revzilla_data_should_sync_revzilla_data_for_discontinued_and_acti
 	Expect(resp.StatusCode).To(Equal(http.StatusOK))
 
 	productRepository := &repositories.ProductRepository{DB: sqlx.MustOpen("pgx", TestDatabaseConnectionString)}
-	activeAliasProduct, err := productRepository.GetByModel("Shoei", "X Spirit lll", "helmet")
+	activeAliasProduct, err := productRepository.GetByModel(context.Background(), "Shoei", "X Spirit lll", "helmet")
 	Expect(err).To(BeNil())
 	Expect(activeAliasProduct).ToNot(BeNil())
 	Expect(activeAliasProduct.SearchPriceCents).To(BeNumerically(">", 0))
-	Expect(activeAliasProduct.RevzillaBuyURL).ToNot(BeEmpty())
+	Expect(activeAliasProduct.RevzillaBuyURL).To(ContainSubstring("Shoei+X-14+Helmet")) // the best match from the fake CJ server, rather than the shield for it
 	Expect(activeAliasProduct.IsDiscontinued).To(BeFalse())
 	Expect(activeAliasProduct.Description).ToNot(BeEmpty())
 
-	activeNormalProduct, err := productRepository.GetByModel("Bell", "Star", "helmet")
+	activeNormalProduct, err := productRepository.GetByModel(context.Background(), "Bell", "Star", "helmet")
 	Expect(err).To(BeNil())
 	Expect(activeNormalProduct).ToNot(BeNil())
 	Expect(activeNormalProduct.SearchPriceCents).To(BeNumerically(">", 0))
@@ -37,7 +46,7 @@ func Test_sync_revzilla_data_should_sync_revzilla_data_for_discontinued_and_acti
 	Expect(activeNormalProduct.Description).ToNot(BeEmpty())
 
 	/* TODO: renable test when discontinued logic improves
-	discontinuedProduct, err := productRepository.GetByModel("Shoei", "X-12", "helmet")
+	discontinuedProduct, err := productRepository.GetByModel(context.Background(), "Shoei", "X-12", "helmet")
 	Expect(err).To(BeNil())
 	Expect(discontinuedProduct).ToNot(BeNil())
 	Expect(discontinuedProduct.SearchPriceCents).To(Equal(0)) // make sure we didn't change the price for a discontinued product
@@ -45,7 +54,7 @@ func Test_sync_revzilla_data_should_sync_revzilla_data_for_discontinued_and_acti
 	Expect(discontinuedProduct.IsDiscontinued).To(BeTrue()) // make sure we set discontinued to true
 	*/
 
-	notFoundProduct, err := productRepository.GetByModel("IAMNOTREAL", "IDONOTEXIST", "helmet")
+	notFoundProduct, err := productRepository.GetByModel(context.Background(), "IAMNOTREAL", "IDONOTEXIST", "helmet")
 	Expect(err).To(BeNil())
 	Expect(notFoundProduct).ToNot(BeNil())
 	Expect(notFoundProduct.SearchPriceCents).To(Equal(0)) // make sure we didn't change the price for a nonexistent product
@@ -53,3 +62,103 @@ func Test_sync_revzilla_data_should_sync_revzilla_data_for_discontinued_and_acti
 	Expect(notFoundProduct.IsDiscontinued).To(BeFalse()) // make sure we didn't mark a nonexistent product as discontinued
 	Expect(notFoundProduct.Description).To(BeEmpty())
 }
+
+func Test_sync_revzilla_helmets_should_wait_out_the_CJ_rate_limit_and_retry_the_product(t *testing.T) {
+	RegisterTestingT(t)
+	cjServer := testHelpers.StartFakeCJServer(FakeCJAPIKey, seeds.GetCJProductSeeds())
+	defer cjServer.Close()
+	cjServer.RateLimitNextRequests(1, 1)
+
+	productRepository := &repositories.ProductRepository{DB: sqlx.MustOpen("pgx", TestDatabaseConnectionString)}
+	job := &jobs.SyncRevzillaHelmetsJob{
+		ProductRepository: productRepository,
+		CJClient:          clients.NewHTTPCJClient(http.DefaultClient, clients.HTTPCJClientOptions{APIURL: cjServer.URL, APIKey: FakeCJAPIKey}),
+	}
+	jobRun := entities.NewJobRun("sync_revzilla_helmets_rate_limit_test", entities.JobRunTriggerAPI)
+	Expect(job.Run(context.Background(), jobRun)).To(BeNil())
+	Expect(jobRun.GetCounts().Updated).To(BeNumerically(">", 0))
+
+	activeNormalProduct, err := productRepository.GetByModel(context.Background(), "Bell", "Star", "helmet")
+	Expect(err).To(BeNil())
+	Expect(activeNormalProduct.RevzillaBuyURL).ToNot(BeEmpty())
+}
+
+func Test_UpdateProduct_should_keep_one_product_offers_row_per_retailer_in_the_product_document(t *testing.T) {
+	RegisterTestingT(t)
+	productRepository := &repositories.ProductRepository{DB: sqlx.MustOpen("pgx", TestDatabaseConnectionString)}
+	getOfferRetailers := func(product *entities.Product) []string {
+		retailers := []string{}
+		err := productRepository.DB.Select(&retailers, productRepository.DB.Rebind("select o.retailer from product_offers o join products p on p.id = o.product_id where p.uuid = ? order by o.retailer"), product.UUID)
+		Expect(err).To(BeNil())
+		return retailers
+	}
+
+	product, err := productRepository.GetByModel(context.Background(), "IAMNOTREAL", "IDONOTEXIST", "helmet")
+	Expect(err).To(BeNil())
+	exchangeRates, err := productRepository.GetExchangeRates(context.Background())
+	Expect(err).To(BeNil())
+
+	product.UpsertOffer(&entities.ProductOffer{Retailer: entities.RetailerRevzilla, PriceCents: 49999, Currency: entities.CurrencyUSD, BuyURL: "https://www.revzilla.com", IsInStock: true, LastSeenAtUTC: time.Now().UTC()})
+	product.UpsertOffer(&entities.ProductOffer{Retailer: "sportsbikeshop", PriceCents: 39999, Currency: entities.CurrencyGBP, BuyURL: "https://www.sportsbikeshop.co.uk", IsInStock: true, LastSeenAtUTC: time.Now().UTC()})
+	Expect(productRepository.UpdateProduct(context.Background(), product, exchangeRates)).To(BeNil())
+	Expect(getOfferRetailers(product)).To(Equal([]string{entities.RetailerRevzilla, "sportsbikeshop"}))
+
+	product.Offers = nil
+	Expect(productRepository.UpdateProduct(context.Background(), product, exchangeRates)).To(BeNil())
+	Expect(getOfferRetailers(product)).To(BeEmpty())
+}
+
+func Test_ForEachProduct_should_resume_after_the_last_product_in_the_checkpoint(t *testing.T) {
+	RegisterTestingT(t)
+	productRepository := &repositories.ProductRepository{DB: sqlx.MustOpen("pgx", TestDatabaseConnectionString)}
+	getVisitedProductIDs := func(jobRun *entities.JobRun) []int {
+		productIDs := []int{}
+		err := helpers.ForEachProduct(context.Background(), productRepository, helpers.ForEachProductOptions{JobRun: jobRun}, func(product *entities.Product, productLogger *logrus.Entry) (bool, error) {
+			productIDs = append(productIDs, product.ID)
+			return false, nil
+		})
+		Expect(err).To(BeNil())
+		return productIDs
+	}
+
+	allProductIDs := getVisitedProductIDs(nil)
+	Expect(len(allProductIDs)).To(BeNumerically(">", 2))
+
+	previousJobRun := entities.NewJobRun("for_each_product_resume_test", entities.JobRunTriggerAPI)
+	previousJobRun.SetCheckpoint(allProductIDs[1])
+	jobRun := entities.NewJobRun("for_each_product_resume_test", entities.JobRunTriggerAPI)
+	jobRun.ResumeFrom(previousJobRun)
+
+	Expect(getVisitedProductIDs(jobRun)).To(Equal(allProductIDs[2:]))
+	Expect(jobRun.GetCheckpoint().LastProductID).To(Equal(allProductIDs[len(allProductIDs)-1]))
+}
+
+func Test_ForEachProduct_should_only_skip_products_that_were_synced_within_the_given_duration(t *testing.T) {
+	RegisterTestingT(t)
+	productRepository := &repositories.ProductRepository{DB: sqlx.MustOpen("pgx", TestDatabaseConnectionString)}
+	jobName := "for_each_product_skip_synced_test"
+
+	// Only every other product is synced, and the rest are left alone as if they weren't matched
+	unsyncedProductIDs := []int{}
+	numVisited := 0
+	firstJobRun := entities.NewJobRun(jobName, entities.JobRunTriggerAPI)
+	err := helpers.ForEachProduct(context.Background(), productRepository, helpers.ForEachProductOptions{JobRun: firstJobRun}, func(product *entities.Product, productLogger *logrus.Entry) (bool, error) {
+		isSynced := numVisited%2 == 0
+		numVisited++
+		if !isSynced {
+			unsyncedProductIDs = append(unsyncedProductIDs, product.ID)
+		}
+		return isSynced, nil
+	})
+	Expect(err).To(BeNil())
+	Expect(unsyncedProductIDs).ToNot(BeEmpty())
+
+	visitedProductIDs := []int{}
+	secondJobRun := entities.NewJobRun(jobName, entities.JobRunTriggerAPI)
+	err = helpers.ForEachProduct(context.Background(), productRepository, helpers.ForEachProductOptions{JobRun: secondJobRun, SkipSyncedWithin: time.Hour}, func(product *entities.Product, productLogger *logrus.Entry) (bool, error) {
+		visitedProductIDs = append(visitedProductIDs, product.ID)
+		return false, nil
+	})
+	Expect(err).To(BeNil())
+	Expect(visitedProductIDs).To(Equal(unsyncedProductIDs))
+}
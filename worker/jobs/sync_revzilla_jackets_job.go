@@ -6,6 +6,7 @@ import (
 	"atgatt-backend/persistence/entities"
 	"atgatt-backend/persistence/repositories"
 	"atgatt-backend/worker/jobs/helpers"
+	"context"
 
 	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
 )
@@ -17,14 +18,31 @@ type SyncRevzillaJacketsJob struct {
 	S3Uploader             s3manageriface.UploaderAPI
 	S3Bucket               string
 	EnableMinProductsCheck bool
+	MaxFailureRatio        *float64
+	MissedProductSettings  helpers.MissedProductSettings
 }
 
 // Run executes the job
-func (j *SyncRevzillaJacketsJob) Run() error {
+func (j *SyncRevzillaJacketsJob) Run(ctx context.Context, jobRun *entities.JobRun) error {
+	return j.run(ctx, jobRun, nil)
+}
+
+// DryRun executes the job without writing to the database or S3, recording the changes that would have been made in the given change report
+func (j *SyncRevzillaJacketsJob) DryRun(ctx context.Context, jobRun *entities.JobRun, changeReport *entities.ChangeReport) error {
+	return j.run(ctx, jobRun, changeReport)
+}
+
+func (j *SyncRevzillaJacketsJob) run(ctx context.Context, jobRun *entities.JobRun, changeReport *entities.ChangeReport) error {
 	updateCertsFunc := func(productToPersist *entities.Product, revzillaProduct *appEntities.RevzillaProduct) {
 		productToPersist.UpdateJacketCertificationsByDescriptionParts(revzillaProduct.DescriptionParts)
 		productToPersist.UpdateGenericSubtypeByDescriptionParts(revzillaProduct.DescriptionParts)
 	}
 
-	return helpers.RunRevzillaImport("motorcycle-jackets-vests", "jacket", j.RevzillaClient, j.ProductRepository, j.S3Uploader, j.S3Bucket, j.EnableMinProductsCheck, updateCertsFunc)
+	return helpers.RunRevzillaImport(ctx, "motorcycle-jackets-vests", "jacket", j.RevzillaClient, j.ProductRepository, j.S3Uploader, j.S3Bucket, updateCertsFunc, helpers.RevzillaImportOptions{
+		JobRun:                 jobRun,
+		ChangeReport:           changeReport,
+		EnableMinProductsCheck: j.EnableMinProductsCheck,
+		MaxFailureRatio:        j.MaxFailureRatio,
+		MissedProductSettings:  j.MissedProductSettings,
+	})
 }
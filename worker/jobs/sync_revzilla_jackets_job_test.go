@@ -2,15 +2,20 @@ package jobs_test
 
 import (
 	"atgatt-backend/application/clients"
+	httpHelpers "atgatt-backend/common/http"
 	"atgatt-backend/persistence/entities"
 	"atgatt-backend/persistence/repositories"
 	"atgatt-backend/worker/jobs"
+	"atgatt-backend/worker/jobs/helpers"
+	"context"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"testing"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -22,19 +27,23 @@ import (
 	. "github.com/onsi/gomega"
 )
 
-var httpRevzillaClient = clients.NewHTTPRevzillaClient()
+var httpRevzillaClient = clients.NewHTTPRevzillaClient(httpHelpers.DefaultCrawler)
 
 type mockRevzillaClient struct {
 	overviewsHTML string
 }
 
-func (r *mockRevzillaClient) GetAllProductOverviewsHTML(productURLPrefix string) (*goquery.Document, error) {
+func (r *mockRevzillaClient) GetRetailer() string {
+	return entities.RetailerRevzilla
+}
+
+func (r *mockRevzillaClient) GetAllProductOverviewsHTML(ctx context.Context, productURLPrefix string) (*goquery.Document, error) {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(r.overviewsHTML))
 	return doc, err
 }
 
-func (r *mockRevzillaClient) GetDescriptionPartsHTMLByURL(url string) (*goquery.Document, error) {
-	return httpRevzillaClient.GetDescriptionPartsHTMLByURL(url)
+func (r *mockRevzillaClient) GetDescriptionPartsHTMLByURL(ctx context.Context, url string) (*goquery.Document, error) {
+	return httpRevzillaClient.GetDescriptionPartsHTMLByURL(ctx, url)
 }
 
 func (r *mockRevzillaClient) SetOverviewsHTML(htmlFilePath string) {
@@ -56,9 +65,9 @@ func Test_Run_should_create_all_new_jackets_if_none_exist(t *testing.T) {
 
 	s3Uploader := s3manager.NewUploader(sess)
 	job := &jobs.SyncRevzillaJacketsJob{ProductRepository: productRepository, S3Uploader: s3Uploader, S3Bucket: "junk", RevzillaClient: mockRevzillaClient}
-	job.Run()
+	job.Run(context.Background(), nil)
 
-	product, _ := productRepository.GetByExternalID(expectedProductIds[0])
+	product, _ := productRepository.GetByExternalID(context.Background(), expectedProductIds[0])
 	Expect(product).ToNot(BeNil())
 	Expect(product.JacketCertifications.Back).To(Equal(&entities.CEImpactZone{
 		IsApproved: false,
@@ -88,13 +97,165 @@ func Test_Run_should_create_all_new_jackets_if_none_exist(t *testing.T) {
 	Expect(product.Model).To(Equal("Folsom Leather Jacket"))
 	Expect(product.Subtype).To(Equal("leather"))
 
-	product, _ = productRepository.GetByExternalID(expectedProductIds[1])
+	product, _ = productRepository.GetByExternalID(context.Background(), expectedProductIds[1])
 	Expect(product).ToNot(BeNil())
 	Expect(product.Manufacturer).To(Equal("REAX"))
 	Expect(product.Model).To(Equal("Alta Mesh Jacket"))
 	Expect(product.Subtype).To(Equal("textile"))
 }
 
+func Test_Run_should_only_discontinue_jackets_after_they_are_missing_for_several_syncs_and_revive_them_when_they_reappear(t *testing.T) {
+	RegisterTestingT(t)
+	productRepository := &repositories.ProductRepository{DB: sqlx.MustConnect("pgx", TestDatabaseConnectionString)}
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Credentials: credentials.NewEnvCredentials(),
+	}))
+
+	missingProductID := "391141"
+	mockRevzillaClient := &mockRevzillaClient{}
+	mockRevzillaClient.SetOverviewsHTML("../../seeds/mock-jackets-response.html")
+	fullOverviewsHTML := mockRevzillaClient.overviewsHTML
+
+	job := &jobs.SyncRevzillaJacketsJob{ProductRepository: productRepository, S3Uploader: s3manager.NewUploader(sess), S3Bucket: "junk", RevzillaClient: mockRevzillaClient}
+	job.Run(context.Background(), nil)
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fullOverviewsHTML))
+	Expect(err).To(BeNil())
+	doc.Find("[data-product-id='" + missingProductID + "']").Remove()
+	mockRevzillaClient.overviewsHTML, err = doc.Html()
+	Expect(err).To(BeNil())
+
+	for i := 1; i <= helpers.DefaultMaxConsecutiveRevzillaMisses; i++ {
+		job.Run(context.Background(), nil)
+
+		product, err := productRepository.GetByExternalID(context.Background(), missingProductID)
+		Expect(err).To(BeNil())
+		Expect(product.Sources[entities.SourceRevzilla].ConsecutiveMisses).To(Equal(i))
+		Expect(product.IsDiscontinued).To(Equal(i == helpers.DefaultMaxConsecutiveRevzillaMisses))
+	}
+
+	job.Run(context.Background(), nil)
+	product, err := productRepository.GetByExternalID(context.Background(), missingProductID)
+	Expect(err).To(BeNil())
+	Expect(product.Sources[entities.SourceRevzilla].ConsecutiveMisses).To(Equal(helpers.DefaultMaxConsecutiveRevzillaMisses))
+
+	mockRevzillaClient.overviewsHTML = fullOverviewsHTML
+	job.Run(context.Background(), nil)
+
+	product, err = productRepository.GetByExternalID(context.Background(), missingProductID)
+	Expect(err).To(BeNil())
+	Expect(product.IsDiscontinued).To(BeFalse())
+	Expect(product.Sources[entities.SourceRevzilla].ConsecutiveMisses).To(BeZero())
+	Expect(product.Sources[entities.SourceRevzilla].LastSeenAtUTC.IsZero()).To(BeFalse())
+}
+
+func Test_Run_should_not_count_misses_when_RevZilla_lists_too_few_of_the_jackets_that_it_listed_before(t *testing.T) {
+	RegisterTestingT(t)
+	productRepository := &repositories.ProductRepository{DB: sqlx.MustConnect("pgx", TestDatabaseConnectionString)}
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Credentials: credentials.NewEnvCredentials(),
+	}))
+
+	listedProductID := "391141"
+	missingProductID := "391137"
+	minSeenRatio := 0.75
+	mockRevzillaClient := &mockRevzillaClient{}
+	mockRevzillaClient.SetOverviewsHTML("../../seeds/mock-jackets-response.html")
+
+	job := &jobs.SyncRevzillaJacketsJob{ProductRepository: productRepository, S3Uploader: s3manager.NewUploader(sess), S3Bucket: "junk", RevzillaClient: mockRevzillaClient, MissedProductSettings: helpers.MissedProductSettings{MinSeenRatio: &minSeenRatio}}
+	job.Run(context.Background(), nil)
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(mockRevzillaClient.overviewsHTML))
+	Expect(err).To(BeNil())
+	doc.Find("[data-product-id]").Not("[data-product-id='" + listedProductID + "']").Remove()
+	mockRevzillaClient.overviewsHTML, err = doc.Html()
+	Expect(err).To(BeNil())
+	job.Run(context.Background(), nil)
+
+	product, err := productRepository.GetByExternalID(context.Background(), missingProductID)
+	Expect(err).To(BeNil())
+	Expect(product.IsDiscontinued).To(BeFalse())
+	Expect(product.Sources[entities.SourceRevzilla].ConsecutiveMisses).To(BeZero())
+}
+
+func Test_DryRun_should_record_the_changes_to_jackets_without_writing_them(t *testing.T) {
+	RegisterTestingT(t)
+	productRepository := &repositories.ProductRepository{DB: sqlx.MustConnect("pgx", TestDatabaseConnectionString)}
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Credentials: credentials.NewEnvCredentials(),
+	}))
+
+	mockRevzillaClient := &mockRevzillaClient{}
+	mockRevzillaClient.SetOverviewsHTML("../../seeds/mock-jackets-response.html")
+
+	productBeforeDryRun, _ := productRepository.GetByExternalID(context.Background(), "391137")
+	changeReport := entities.NewChangeReport("sync_revzilla_jackets")
+	job := &jobs.SyncRevzillaJacketsJob{ProductRepository: productRepository, S3Uploader: s3manager.NewUploader(sess), S3Bucket: "junk", RevzillaClient: mockRevzillaClient}
+	err := job.DryRun(context.Background(), nil, changeReport)
+	Expect(err).To(BeNil())
+	Expect(len(changeReport.CreatedProducts) + len(changeReport.UpdatedProducts)).To(BeNumerically(">", 0))
+
+	productAfterDryRun, _ := productRepository.GetByExternalID(context.Background(), "391137")
+	Expect(productAfterDryRun).To(Equal(productBeforeDryRun))
+	for _, createdProduct := range changeReport.CreatedProducts {
+		Expect(createdProduct.Product.ImageKey).To(BeEmpty())
+	}
+}
+
+func Test_DryRun_should_not_count_jackets_that_would_not_change_as_updated(t *testing.T) {
+	RegisterTestingT(t)
+	productRepository := &repositories.ProductRepository{DB: sqlx.MustConnect("pgx", TestDatabaseConnectionString)}
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Credentials: credentials.NewEnvCredentials(),
+	}))
+
+	mockRevzillaClient := &mockRevzillaClient{}
+	mockRevzillaClient.SetOverviewsHTML("../../seeds/mock-jackets-response.html")
+	job := &jobs.SyncRevzillaJacketsJob{ProductRepository: productRepository, S3Uploader: s3manager.NewUploader(sess), S3Bucket: "junk", RevzillaClient: mockRevzillaClient}
+	Expect(job.Run(context.Background(), nil)).To(BeNil())
+
+	jobRun := entities.NewJobRun("sync_revzilla_jackets", entities.JobRunTriggerAPI)
+	changeReport := entities.NewChangeReport("sync_revzilla_jackets")
+	Expect(job.DryRun(context.Background(), jobRun, changeReport)).To(BeNil())
+	Expect(changeReport.UpdatedProducts).To(BeEmpty())
+	Expect(jobRun.GetCounts().Updated).To(BeZero())
+}
+
+func Test_sync_revzilla_jackets_should_save_a_change_report_when_dry_run(t *testing.T) {
+	RegisterTestingT(t)
+
+	resp, err := http.Post(APIBaseURL+"/jobs/sync_revzilla_jackets?dryRun=true", "application/json", strings.NewReader("{}"))
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	dryRunResponse := &struct {
+		ChangeReportUUID string `json:"changeReportUUID"`
+	}{}
+	Expect(json.NewDecoder(resp.Body).Decode(dryRunResponse)).To(BeNil())
+	Expect(dryRunResponse.ChangeReportUUID).ToNot(BeEmpty())
+
+	resp, err = http.Get(APIBaseURL + "/jobs/change_reports/" + dryRunResponse.ChangeReportUUID)
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	changeReport := &entities.ChangeReport{}
+	Expect(json.NewDecoder(resp.Body).Decode(changeReport)).To(BeNil())
+	Expect(changeReport.JobName).To(Equal("sync_revzilla_jackets"))
+	Expect(changeReport.FinishedAtUTC.IsZero()).To(BeFalse())
+}
+
+func Test_jobs_that_cannot_be_dry_run_should_return_bad_request_when_dry_run(t *testing.T) {
+	RegisterTestingT(t)
+
+	resp, err := http.Post(APIBaseURL+"/jobs/load_exchange_rates?dryRun=true", "application/json", strings.NewReader("{}"))
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+}
+
 func Test_sync_revzilla_jackets_should_complete_successfully_with_a_full_set_of_data(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -102,3 +263,131 @@ func Test_sync_revzilla_jackets_should_complete_successfully_with_a_full_set_of_
 	Expect(err).To(BeNil())
 	Expect(resp.StatusCode).To(Equal(http.StatusOK))
 }
+
+func Test_sync_revzilla_jackets_should_record_a_job_run(t *testing.T) {
+	RegisterTestingT(t)
+
+	resp, err := http.Post(APIBaseURL+"/jobs/sync_revzilla_jackets", "application/json", strings.NewReader("{}"))
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	jobResponse := &struct {
+		RunID string `json:"runID"`
+	}{}
+	Expect(json.NewDecoder(resp.Body).Decode(jobResponse)).To(BeNil())
+	Expect(jobResponse.RunID).ToNot(BeEmpty())
+
+	resp, err = http.Get(APIBaseURL + "/jobs/runs/" + jobResponse.RunID)
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	jobRun := &entities.JobRun{}
+	Expect(json.NewDecoder(resp.Body).Decode(jobRun)).To(BeNil())
+	Expect(jobRun.JobName).To(Equal("sync_revzilla_jackets"))
+	Expect(jobRun.Trigger).To(Equal(entities.JobRunTriggerAPI))
+	Expect(jobRun.Status).To(Equal(entities.JobRunStatusSucceeded))
+	Expect(jobRun.StartedAtUTC).ToNot(BeNil())
+	Expect(jobRun.FinishedAtUTC).ToNot(BeNil())
+	Expect(jobRun.Counts.Created + jobRun.Counts.Updated).To(BeNumerically(">", 0))
+	Expect(jobRun.ImportReport).ToNot(BeNil())
+	Expect(jobRun.ImportReport.ProductType).To(Equal("jacket"))
+	Expect(jobRun.ImportReport.NumListed).To(BeNumerically(">", 0))
+
+	resp, err = http.Get(APIBaseURL + "/jobs/runs?jobName=sync_revzilla_jackets")
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	jobRuns := []*entities.JobRun{}
+	Expect(json.NewDecoder(resp.Body).Decode(&jobRuns)).To(BeNil())
+	Expect(jobRuns).ToNot(BeEmpty())
+	Expect(jobRuns[0].UUID.String()).To(Equal(jobResponse.RunID))
+}
+
+func Test_Run_should_stop_without_discontinuing_jackets_when_the_context_is_cancelled(t *testing.T) {
+	RegisterTestingT(t)
+	productRepository := &repositories.ProductRepository{DB: sqlx.MustConnect("pgx", TestDatabaseConnectionString)}
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Credentials: credentials.NewEnvCredentials(),
+	}))
+
+	mockRevzillaClient := &mockRevzillaClient{}
+	mockRevzillaClient.SetOverviewsHTML("../../seeds/mock-jackets-response.html")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	jobRun := entities.NewJobRun("sync_revzilla_jackets", entities.JobRunTriggerAPI)
+	job := &jobs.SyncRevzillaJacketsJob{ProductRepository: productRepository, S3Uploader: s3manager.NewUploader(sess), S3Bucket: "junk", RevzillaClient: mockRevzillaClient}
+	err := job.Run(ctx, jobRun)
+	Expect(err).To(Equal(context.Canceled))
+	Expect(jobRun.GetCounts()).To(Equal(entities.JobRunCounts{}))
+}
+
+func Test_Run_should_fail_with_a_summary_when_too_many_jackets_fail_to_import(t *testing.T) {
+	RegisterTestingT(t)
+	// Every lookup fails once the database is closed
+	db := sqlx.MustConnect("pgx", TestDatabaseConnectionString)
+	Expect(db.Close()).To(BeNil())
+	productRepository := &repositories.ProductRepository{DB: db}
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Credentials: credentials.NewEnvCredentials(),
+	}))
+
+	mockRevzillaClient := &mockRevzillaClient{}
+	mockRevzillaClient.SetOverviewsHTML("../../seeds/mock-jackets-response.html")
+
+	jobRun := entities.NewJobRun("sync_revzilla_jackets", entities.JobRunTriggerAPI)
+	job := &jobs.SyncRevzillaJacketsJob{ProductRepository: productRepository, S3Uploader: s3manager.NewUploader(sess), S3Bucket: "junk", RevzillaClient: mockRevzillaClient}
+	err := job.Run(context.Background(), jobRun)
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(ContainSubstring("jacket products (100.0%) failed to import"))
+
+	Expect(jobRun.ImportReport).ToNot(BeNil())
+	Expect(jobRun.ImportReport.Failures[0].Stage).To(Equal(entities.ImportStageLookup))
+	Expect(jobRun.GetCounts().Failed).To(Equal(jobRun.ImportReport.NumListed))
+}
+
+func Test_cancelling_a_job_run_should_only_cancel_runs_that_have_not_finished(t *testing.T) {
+	RegisterTestingT(t)
+
+	resp, err := http.Post(APIBaseURL+"/jobs/runs/"+uuid.New().String()+"/cancel", "application/json", strings.NewReader("{}"))
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+
+	resp, err = http.Post(APIBaseURL+"/jobs/load_exchange_rates", "application/json", strings.NewReader("{}"))
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	jobResponse := &struct {
+		RunID string `json:"runID"`
+	}{}
+	Expect(json.NewDecoder(resp.Body).Decode(jobResponse)).To(BeNil())
+
+	// The synchronous job runner has already finished the run by the time it responds
+	resp, err = http.Post(APIBaseURL+"/jobs/runs/"+jobResponse.RunID+"/cancel", "application/json", strings.NewReader("{}"))
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusConflict))
+}
+
+func Test_resuming_a_job_run_should_only_resume_runs_that_stopped_early(t *testing.T) {
+	RegisterTestingT(t)
+
+	resp, err := http.Post(APIBaseURL+"/jobs/runs/"+uuid.New().String()+"/resume", "application/json", strings.NewReader("{}"))
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+
+	resp, err = http.Post(APIBaseURL+"/jobs/load_exchange_rates", "application/json", strings.NewReader("{}"))
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	jobResponse := &struct {
+		RunID string `json:"runID"`
+	}{}
+	Expect(json.NewDecoder(resp.Body).Decode(jobResponse)).To(BeNil())
+
+	// The run succeeded, so there is nothing to resume
+	resp, err = http.Post(APIBaseURL+"/jobs/runs/"+jobResponse.RunID+"/resume", "application/json", strings.NewReader("{}"))
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusConflict))
+}
@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"atgatt-backend/application/clients"
+	appEntities "atgatt-backend/application/entities"
+	"atgatt-backend/persistence/entities"
+	"atgatt-backend/persistence/repositories"
+	"atgatt-backend/worker/jobs/helpers"
+	"context"
+
+	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
+)
+
+// SyncRevzillaProtectorsJob scrapes all of RevZilla's standalone back and chest protector data
+type SyncRevzillaProtectorsJob struct {
+	ProductRepository      *repositories.ProductRepository
+	RevzillaClient         clients.RevzillaClient
+	S3Uploader             s3manageriface.UploaderAPI
+	S3Bucket               string
+	EnableMinProductsCheck bool
+	MaxFailureRatio        *float64
+	MissedProductSettings  helpers.MissedProductSettings
+}
+
+// Run executes the job
+func (j *SyncRevzillaProtectorsJob) Run(ctx context.Context, jobRun *entities.JobRun) error {
+	return j.run(ctx, jobRun, nil)
+}
+
+// DryRun executes the job without writing to the database or S3, recording the changes that would have been made in the given change report
+func (j *SyncRevzillaProtectorsJob) DryRun(ctx context.Context, jobRun *entities.JobRun, changeReport *entities.ChangeReport) error {
+	return j.run(ctx, jobRun, changeReport)
+}
+
+func (j *SyncRevzillaProtectorsJob) run(ctx context.Context, jobRun *entities.JobRun, changeReport *entities.ChangeReport) error {
+	updateCertsFunc := func(productToPersist *entities.Product, revzillaProduct *appEntities.RevzillaProduct) {
+		productToPersist.UpdateProtectorCertificationsByDescriptionParts(revzillaProduct.DescriptionParts)
+		productToPersist.UpdateGenericSubtypeByDescriptionParts(revzillaProduct.DescriptionParts)
+	}
+
+	// The body armor category also lists elbow, knee, shoulder and hip armor, which belong to jackets and pants rather than standalone protectors
+	isBackOrChestProtectorFunc := func(revzillaProduct *appEntities.RevzillaProduct) bool {
+		return revzillaProduct.IsInCategory("back protector", "chest protector", "back armor", "chest armor")
+	}
+
+	return helpers.RunRevzillaImport(ctx, "motorcycle-body-armor", entities.ProductTypeProtector, j.RevzillaClient, j.ProductRepository, j.S3Uploader, j.S3Bucket, updateCertsFunc, helpers.RevzillaImportOptions{
+		JobRun:                 jobRun,
+		ChangeReport:           changeReport,
+		EnableMinProductsCheck: j.EnableMinProductsCheck,
+		MaxFailureRatio:        j.MaxFailureRatio,
+		MissedProductSettings:  j.MissedProductSettings,
+		IsProductTypeFunc:      isBackOrChestProtectorFunc,
+	})
+}
@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxYearsToSearch bounds the search for the next fire time, so that expressions that can never fire (i.e. the 30th of February) don't loop forever
+const maxYearsToSearch = 5
+
+// CronExpression is a parsed five field cron expression (minute, hour, day of month, month, day of week) in the same format as cron.yaml. Expressions are always evaluated in UTC.
+type CronExpression struct {
+	expression      string
+	minutes         uint64
+	hours           uint64
+	daysOfMonth     uint64
+	months          uint64
+	daysOfWeek      uint64
+	isAnyDayOfMonth bool
+	isAnyDayOfWeek  bool
+}
+
+type cronField struct {
+	name    string
+	min     int
+	max     int
+	aliases map[string]int
+}
+
+var minuteField = &cronField{name: "minute", min: 0, max: 59}
+var hourField = &cronField{name: "hour", min: 0, max: 23}
+var dayOfMonthField = &cronField{name: "day of month", min: 1, max: 31}
+var monthField = &cronField{name: "month", min: 1, max: 12, aliases: map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}}
+
+// dayOfWeekField allows 7 as well as 0 for Sunday, which is folded into 0 after parsing
+var dayOfWeekField = &cronField{name: "day of week", min: 0, max: 7, aliases: map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}}
+
+// ParseCronExpression parses a five field cron expression. Each field can be *, a number, a range (1-5), a step (*/15 or 1-30/5), or a comma separated list of any of those. Months and days of the week can also be given by their three letter names.
+func ParseCronExpression(expression string) (*CronExpression, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("The cron expression %q must have 5 fields but has %d", expression, len(fields))
+	}
+
+	cronExpression := &CronExpression{expression: expression}
+	var err error
+	if cronExpression.minutes, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if cronExpression.hours, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if cronExpression.daysOfMonth, err = dayOfMonthField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if cronExpression.months, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if cronExpression.daysOfWeek, err = dayOfWeekField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+
+	if cronExpression.daysOfWeek&(1<<7) != 0 {
+		cronExpression.daysOfWeek = (cronExpression.daysOfWeek &^ (1 << 7)) | 1
+	}
+	cronExpression.isAnyDayOfMonth = fields[2] == "*"
+	cronExpression.isAnyDayOfWeek = fields[4] == "*"
+	return cronExpression, nil
+}
+
+// String returns the expression as it was written
+func (e *CronExpression) String() string {
+	return e.expression
+}
+
+// Next returns the first time strictly after the given time that the expression fires, or the zero time if it never fires
+func (e *CronExpression) Next(after time.Time) time.Time {
+	next := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	searchLimit := next.AddDate(maxYearsToSearch, 0, 0)
+	for next.Before(searchLimit) {
+		if !hasBit(e.months, int(next.Month())) {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !e.matchesDay(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !hasBit(e.hours, next.Hour()) {
+			next = next.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+
+		if !hasBit(e.minutes, next.Minute()) {
+			next = next.Add(time.Minute)
+			continue
+		}
+
+		return next
+	}
+
+	return time.Time{}
+}
+
+// matchesDay follows the usual cron rule where a day matches either field when both the day of month and day of week are restricted
+func (e *CronExpression) matchesDay(t time.Time) bool {
+	matchesDayOfMonth := hasBit(e.daysOfMonth, t.Day())
+	matchesDayOfWeek := hasBit(e.daysOfWeek, int(t.Weekday()))
+	if !e.isAnyDayOfMonth && !e.isAnyDayOfWeek {
+		return matchesDayOfMonth || matchesDayOfWeek
+	}
+
+	return matchesDayOfMonth && matchesDayOfWeek
+}
+
+func (f *cronField) parse(value string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		rangeAndStep := strings.SplitN(part, "/", 2)
+		step := 1
+		if len(rangeAndStep) == 2 {
+			parsedStep, err := strconv.Atoi(rangeAndStep[1])
+			if err != nil || parsedStep < 1 {
+				return 0, fmt.Errorf("The %s field %q has an invalid step", f.name, value)
+			}
+			step = parsedStep
+		}
+
+		start, end, err := f.parseRange(rangeAndStep[0], len(rangeAndStep) == 2)
+		if err != nil {
+			return 0, fmt.Errorf("The %s field %q is invalid: %s", f.name, value, err.Error())
+		}
+
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+// parseRange parses *, a single value, or a range of values. A single value with a step (5/15) runs from the value to the end of the field.
+func (f *cronField) parseRange(value string, hasStep bool) (int, int, error) {
+	if value == "*" {
+		return f.min, f.max, nil
+	}
+
+	bounds := strings.SplitN(value, "-", 2)
+	start, err := f.parseValue(bounds[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end := start
+	if len(bounds) == 2 {
+		end, err = f.parseValue(bounds[1])
+		if err != nil {
+			return 0, 0, err
+		}
+	} else if hasStep {
+		end = f.max
+	}
+
+	if end < start {
+		return 0, 0, fmt.Errorf("the range %s ends before it starts", value)
+	}
+	return start, end, nil
+}
+
+func (f *cronField) parseValue(value string) (int, error) {
+	if alias, exists := f.aliases[strings.ToLower(value)]; exists {
+		return alias, nil
+	}
+
+	parsedValue, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", value)
+	}
+
+	if parsedValue < f.min || parsedValue > f.max {
+		return 0, fmt.Errorf("%d must be between %d and %d", parsedValue, f.min, f.max)
+	}
+	return parsedValue, nil
+}
+
+func hasBit(bits uint64, i int) bool {
+	return bits&(1<<uint(i)) != 0
+}
@@ -0,0 +1,76 @@
+package scheduler_test
+
+import (
+	"atgatt-backend/worker/scheduler"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func mustParseTime(value string) time.Time {
+	parsedTime, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return parsedTime
+}
+
+func getNextFireTimes(expression string, after string, count int) []string {
+	cronExpression, err := scheduler.ParseCronExpression(expression)
+	Expect(err).To(BeNil())
+
+	fireTimes := []string{}
+	next := mustParseTime(after)
+	for i := 0; i < count; i++ {
+		next = cronExpression.Next(next)
+		fireTimes = append(fireTimes, next.Format(time.RFC3339))
+	}
+	return fireTimes
+}
+
+func Test_CronExpression_should_find_the_next_fire_times_of_a_daily_schedule(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(getNextFireTimes("30 23 * * *", "2026-12-31T12:00:00Z", 2)).To(Equal([]string{"2026-12-31T23:30:00Z", "2027-01-01T23:30:00Z"}))
+	Expect(getNextFireTimes("0 0 * * *", "2026-03-01T00:00:00Z", 1)).To(Equal([]string{"2026-03-02T00:00:00Z"}))
+	Expect(getNextFireTimes("0 0 * * *", "2026-03-01T00:00:30Z", 1)).To(Equal([]string{"2026-03-02T00:00:00Z"}))
+}
+
+func Test_CronExpression_should_support_lists_ranges_steps_and_names(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(getNextFireTimes("*/20 9-10 * * *", "2026-05-04T08:59:00Z", 7)).To(Equal([]string{
+		"2026-05-04T09:00:00Z", "2026-05-04T09:20:00Z", "2026-05-04T09:40:00Z",
+		"2026-05-04T10:00:00Z", "2026-05-04T10:20:00Z", "2026-05-04T10:40:00Z",
+		"2026-05-05T09:00:00Z",
+	}))
+	Expect(getNextFireTimes("15 4 1,15 jan-feb *", "2026-01-20T00:00:00Z", 3)).To(Equal([]string{"2026-02-01T04:15:00Z", "2026-02-15T04:15:00Z", "2027-01-01T04:15:00Z"}))
+	Expect(getNextFireTimes("0 12 * * MON-FRI", "2026-10-16T13:00:00Z", 2)).To(Equal([]string{"2026-10-19T12:00:00Z", "2026-10-20T12:00:00Z"}))
+	Expect(getNextFireTimes("0 12 * * 7", "2026-10-19T00:00:00Z", 1)).To(Equal([]string{"2026-10-25T12:00:00Z"}))
+	Expect(getNextFireTimes("5/30 0 * * *", "2026-10-19T00:00:00Z", 2)).To(Equal([]string{"2026-10-19T00:05:00Z", "2026-10-19T00:35:00Z"}))
+}
+
+func Test_CronExpression_should_fire_on_either_day_when_both_the_day_of_month_and_day_of_week_are_restricted(t *testing.T) {
+	RegisterTestingT(t)
+
+	// The 1st of November 2026 is a Sunday and the 2nd is a Monday
+	Expect(getNextFireTimes("0 0 1 * 1", "2026-10-28T00:00:00Z", 3)).To(Equal([]string{"2026-11-01T00:00:00Z", "2026-11-02T00:00:00Z", "2026-11-09T00:00:00Z"}))
+}
+
+func Test_CronExpression_should_return_the_zero_time_when_it_never_fires(t *testing.T) {
+	RegisterTestingT(t)
+
+	cronExpression, err := scheduler.ParseCronExpression("0 0 30 2 *")
+	Expect(err).To(BeNil())
+	Expect(cronExpression.Next(mustParseTime("2026-01-01T00:00:00Z")).IsZero()).To(BeTrue())
+}
+
+func Test_ParseCronExpression_should_return_an_error_when_the_expression_is_invalid(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, expression := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *", "* * * smarch *"} {
+		_, err := scheduler.ParseCronExpression(expression)
+		Expect(err).ToNot(BeNil(), expression)
+	}
+}
@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// jobURLPrefix is the prefix of every job's endpoint, which is how cron.yaml refers to the job to run
+const jobURLPrefix = "/jobs/"
+
+// cronFile mirrors the layout of cron.yaml, which was originally written for the Elastic Beanstalk worker tier
+type cronFile struct {
+	Version int              `yaml:"version"`
+	Cron    []*cronFileEntry `yaml:"cron"`
+}
+
+type cronFileEntry struct {
+	Name     string `yaml:"name"`
+	URL      string `yaml:"url"`
+	Schedule string `yaml:"schedule"`
+}
+
+// Schedule runs the job with the given name whenever its cron expression fires
+type Schedule struct {
+	Name       string
+	JobName    string
+	Expression *CronExpression
+}
+
+// LoadSchedules reads the schedules from the cron.yaml file at the given path
+func LoadSchedules(cronFilePath string) ([]*Schedule, error) {
+	contents, err := ioutil.ReadFile(cronFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSchedules(contents)
+}
+
+// ParseSchedules parses the schedules from the contents of a cron.yaml file. The job that each schedule runs is taken from the end of its URL, i.e. /jobs/import_helmets runs import_helmets.
+func ParseSchedules(contents []byte) ([]*Schedule, error) {
+	parsedCronFile := &cronFile{}
+	if err := yaml.UnmarshalStrict(contents, parsedCronFile); err != nil {
+		return nil, err
+	}
+
+	schedules := []*Schedule{}
+	scheduleNames := map[string]bool{}
+	for _, entry := range parsedCronFile.Cron {
+		if entry.Name == "" {
+			return nil, fmt.Errorf("The schedule for %s must have a name", entry.URL)
+		}
+
+		if scheduleNames[entry.Name] {
+			return nil, fmt.Errorf("The schedule %s is defined more than once", entry.Name)
+		}
+		scheduleNames[entry.Name] = true
+
+		if !strings.HasPrefix(entry.URL, jobURLPrefix) || len(entry.URL) == len(jobURLPrefix) {
+			return nil, fmt.Errorf("The schedule %s has the URL %s but it must be in the form %s<job>", entry.Name, entry.URL, jobURLPrefix)
+		}
+
+		expression, err := ParseCronExpression(entry.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("The schedule %s is invalid: %s", entry.Name, err.Error())
+		}
+
+		schedules = append(schedules, &Schedule{
+			Name:       entry.Name,
+			JobName:    strings.TrimPrefix(entry.URL, jobURLPrefix),
+			Expression: expression,
+		})
+	}
+
+	return schedules, nil
+}
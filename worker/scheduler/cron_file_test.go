@@ -0,0 +1,37 @@
+package scheduler_test
+
+import (
+	"atgatt-backend/worker/scheduler"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_LoadSchedules_should_load_every_schedule_in_cron_yaml(t *testing.T) {
+	RegisterTestingT(t)
+
+	schedules, err := scheduler.LoadSchedules("../../cron.yaml")
+	Expect(err).To(BeNil())
+	Expect(len(schedules)).To(BeNumerically(">", 0))
+
+	importHelmetsSchedule := schedules[1]
+	Expect(importHelmetsSchedule.Name).To(Equal("import_helmets"))
+	Expect(importHelmetsSchedule.JobName).To(Equal("import_helmets"))
+	Expect(importHelmetsSchedule.Expression.String()).To(Equal("0 0 * * *"))
+}
+
+func Test_ParseSchedules_should_return_an_error_when_a_schedule_is_invalid(t *testing.T) {
+	RegisterTestingT(t)
+
+	invalidCronFiles := []string{
+		"version: 1\ncron:\n - name: \"import_helmets\"\n   url: \"/import_helmets\"\n   schedule: \"0 0 * * *\"",
+		"version: 1\ncron:\n - name: \"import_helmets\"\n   url: \"/jobs/import_helmets\"\n   schedule: \"0 0 * *\"",
+		"version: 1\ncron:\n - url: \"/jobs/import_helmets\"\n   schedule: \"0 0 * * *\"",
+		"version: 1\ncron:\n - name: \"import_helmets\"\n   url: \"/jobs/import_helmets\"\n   schedule: \"0 0 * * *\"\n - name: \"import_helmets\"\n   url: \"/jobs/import_helmets\"\n   schedule: \"0 1 * * *\"",
+		"version: 1\ncron:\n - name: \"import_helmets\"\n   path: \"/jobs/import_helmets\"\n   schedule: \"0 0 * * *\"",
+	}
+	for _, invalidCronFile := range invalidCronFiles {
+		_, err := scheduler.ParseSchedules([]byte(invalidCronFile))
+		Expect(err).ToNot(BeNil(), invalidCronFile)
+	}
+}
@@ -0,0 +1,216 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultTickInterval is how often the scheduler checks whether it is the leader and whether any schedules are due
+const DefaultTickInterval = 30 * time.Second
+
+// DefaultMaxCatchUp is how far back the scheduler looks for a fire time that was missed while no worker was the leader, i.e. during a deploy
+const DefaultMaxCatchUp = 6 * time.Hour
+
+// LeaderElector decides which worker instance fires the schedules
+type LeaderElector interface {
+	TryLock(ctx context.Context) (bool, error)
+	Unlock(ctx context.Context) error
+}
+
+// LastFireFinder returns the last time that a schedule fired the given job, or nil if it never has
+type LastFireFinder func(jobName string) (*time.Time, error)
+
+// TriggerFunc starts a run of the given job
+type TriggerFunc func(jobName string) error
+
+// Scheduler fires each schedule's job in-process whenever its cron expression is due. Only the worker instance that holds the leader lock fires anything. When an instance becomes the leader it picks up from the last time each job was fired by a schedule, so a fire time that was missed within MaxCatchUp is run once straight away.
+type Scheduler struct {
+	Schedules    []*Schedule
+	Leader       LeaderElector
+	FindLastFire LastFireFinder
+	Trigger      TriggerFunc
+	TickInterval time.Duration
+	MaxCatchUp   time.Duration
+	Now          func() time.Time
+
+	mutex    sync.Mutex
+	isLeader bool
+	// lastFiredAtUTC is the last time that each schedule actually fired, while firesAfterUTC is the time that each schedule's next fire time is searched from
+	lastFiredAtUTC map[string]*time.Time
+	firesAfterUTC  map[string]time.Time
+}
+
+// ScheduleStatus describes when a schedule last fired and when it will fire next
+type ScheduleStatus struct {
+	Name           string     `json:"name"`
+	JobName        string     `json:"jobName"`
+	Schedule       string     `json:"schedule"`
+	LastFiredAtUTC *time.Time `json:"lastFiredAtUTC"`
+	NextFireAtUTC  *time.Time `json:"nextFireAtUTC"`
+}
+
+// Status describes the state of every schedule
+type Status struct {
+	IsEnabled bool              `json:"isEnabled"`
+	IsLeader  bool              `json:"isLeader"`
+	Schedules []*ScheduleStatus `json:"schedules"`
+}
+
+// Start ticks until the given context is done, releasing the leader lock before it returns
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.getTickInterval())
+	defer ticker.Stop()
+
+	for {
+		s.Tick(ctx)
+		select {
+		case <-ctx.Done():
+			if err := s.Leader.Unlock(context.Background()); err != nil {
+				logrus.WithError(err).Error("Could not release the scheduler's leader lock")
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick checks whether this instance is the leader, and if so fires every schedule that has become due since it last fired
+func (s *Scheduler) Tick(ctx context.Context) {
+	isLeader, err := s.Leader.TryLock(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("Could not check whether this worker is the scheduler's leader")
+	}
+
+	now := s.now()
+	if !s.updateLeadership(isLeader, now) {
+		return
+	}
+
+	for _, schedule := range s.Schedules {
+		s.mutex.Lock()
+		nextFireAtUTC := schedule.Expression.Next(s.firesAfterUTC[schedule.Name])
+		s.mutex.Unlock()
+		if nextFireAtUTC.IsZero() || nextFireAtUTC.After(now) {
+			continue
+		}
+
+		scheduleLogger := logrus.WithFields(logrus.Fields{
+			"scheduleName": schedule.Name,
+			"jobName":      schedule.JobName,
+			"fireAtUTC":    nextFireAtUTC,
+		})
+		if now.Sub(nextFireAtUTC) > s.getTickInterval() {
+			scheduleLogger.Warning("Catching up on a scheduled run that was missed")
+		}
+		scheduleLogger.Info("Firing a scheduled job")
+
+		// The schedule counts as fired even if the trigger fails, otherwise a broken job would be retried on every tick
+		s.mutex.Lock()
+		firedAtUTC := now
+		s.lastFiredAtUTC[schedule.Name] = &firedAtUTC
+		s.firesAfterUTC[schedule.Name] = now
+		s.mutex.Unlock()
+		if err := s.Trigger(schedule.JobName); err != nil {
+			scheduleLogger.WithError(err).Error("Could not trigger a scheduled job")
+		}
+	}
+}
+
+// GetStatus returns when each schedule last fired and when it will fire next
+func (s *Scheduler) GetStatus() *Status {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	status := &Status{IsEnabled: true, IsLeader: s.isLeader, Schedules: []*ScheduleStatus{}}
+	for _, schedule := range s.Schedules {
+		scheduleStatus := &ScheduleStatus{Name: schedule.Name, JobName: schedule.JobName, Schedule: schedule.Expression.String()}
+		scheduleStatus.LastFiredAtUTC = s.lastFiredAtUTC[schedule.Name]
+		nextFireAfter, exists := s.firesAfterUTC[schedule.Name]
+		if !exists {
+			nextFireAfter = now
+		}
+
+		// A schedule that is already due fires on the next tick
+		nextFireAtUTC := schedule.Expression.Next(nextFireAfter)
+		if !nextFireAtUTC.IsZero() {
+			scheduleStatus.NextFireAtUTC = &nextFireAtUTC
+		}
+		status.Schedules = append(status.Schedules, scheduleStatus)
+	}
+	return status
+}
+
+// updateLeadership records whether this instance is the leader, loading the last fire times when it becomes the leader so that it carries on where the previous leader left off. It returns true if this instance is the leader.
+func (s *Scheduler) updateLeadership(isLeader bool, now time.Time) bool {
+	s.mutex.Lock()
+	wasLeader := s.isLeader
+	s.isLeader = isLeader
+	s.mutex.Unlock()
+
+	if !isLeader {
+		if wasLeader {
+			logrus.Warning("This worker is no longer the scheduler's leader")
+		}
+		return false
+	}
+
+	if wasLeader {
+		return true
+	}
+
+	logrus.Info("This worker is now the scheduler's leader, loading the last time that each schedule fired")
+	lastFiredAtUTC := map[string]*time.Time{}
+	firesAfterUTC := map[string]time.Time{}
+	for _, schedule := range s.Schedules {
+		scheduleLastFiredAtUTC, err := s.FindLastFire(schedule.JobName)
+		if err != nil {
+			logrus.WithError(err).WithField("scheduleName", schedule.Name).Error("Could not find the last time that a schedule fired, starting from now")
+		}
+		lastFiredAtUTC[schedule.Name] = scheduleLastFiredAtUTC
+		firesAfterUTC[schedule.Name] = s.getFiresAfter(scheduleLastFiredAtUTC, now)
+	}
+
+	s.mutex.Lock()
+	s.lastFiredAtUTC = lastFiredAtUTC
+	s.firesAfterUTC = firesAfterUTC
+	s.mutex.Unlock()
+	return true
+}
+
+// getFiresAfter returns the time to search for a schedule's next fire time from. Schedules that have never fired start from now rather than firing straight away, and fire times older than MaxCatchUp are skipped.
+func (s *Scheduler) getFiresAfter(lastFiredAtUTC *time.Time, now time.Time) time.Time {
+	if lastFiredAtUTC == nil {
+		return now
+	}
+
+	oldestFireToCatchUp := now.Add(-s.getMaxCatchUp())
+	if lastFiredAtUTC.Before(oldestFireToCatchUp) {
+		return oldestFireToCatchUp
+	}
+	return *lastFiredAtUTC
+}
+
+func (s *Scheduler) getTickInterval() time.Duration {
+	if s.TickInterval <= 0 {
+		return DefaultTickInterval
+	}
+	return s.TickInterval
+}
+
+func (s *Scheduler) getMaxCatchUp() time.Duration {
+	if s.MaxCatchUp <= 0 {
+		return DefaultMaxCatchUp
+	}
+	return s.MaxCatchUp
+}
+
+func (s *Scheduler) now() time.Time {
+	if s.Now == nil {
+		return time.Now().UTC()
+	}
+	return s.Now().UTC()
+}
@@ -0,0 +1,153 @@
+package scheduler_test
+
+import (
+	"atgatt-backend/worker/scheduler"
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+type fakeLeaderElector struct {
+	isLeader bool
+}
+
+func (l *fakeLeaderElector) TryLock(ctx context.Context) (bool, error) {
+	return l.isLeader, nil
+}
+
+func (l *fakeLeaderElector) Unlock(ctx context.Context) error {
+	l.isLeader = false
+	return nil
+}
+
+type schedulerTest struct {
+	scheduler      *scheduler.Scheduler
+	leader         *fakeLeaderElector
+	now            time.Time
+	triggeredJobs  []string
+	lastFiredAtUTC map[string]*time.Time
+}
+
+func newSchedulerTest(now string, cronFile string) *schedulerTest {
+	schedules, err := scheduler.ParseSchedules([]byte(cronFile))
+	Expect(err).To(BeNil())
+
+	test := &schedulerTest{leader: &fakeLeaderElector{isLeader: true}, now: mustParseTime(now), lastFiredAtUTC: map[string]*time.Time{}}
+	test.scheduler = &scheduler.Scheduler{
+		Schedules: schedules,
+		Leader:    test.leader,
+		FindLastFire: func(jobName string) (*time.Time, error) {
+			return test.lastFiredAtUTC[jobName], nil
+		},
+		Trigger: func(jobName string) error {
+			test.triggeredJobs = append(test.triggeredJobs, jobName)
+			return nil
+		},
+		MaxCatchUp: 6 * time.Hour,
+		Now: func() time.Time {
+			return test.now
+		},
+	}
+	return test
+}
+
+func (test *schedulerTest) tickAt(now string) {
+	test.now = mustParseTime(now)
+	test.scheduler.Tick(context.Background())
+}
+
+const testCronFile = `
+version: 1
+cron:
+ - name: "import_helmets"
+   url: "/jobs/import_helmets"
+   schedule: "0 0 * * *"
+ - name: "sync_revzilla_jackets"
+   url: "/jobs/sync_revzilla_jackets"
+   schedule: "0 3 * * *"
+`
+
+func Test_Scheduler_should_fire_each_schedule_once_when_it_is_due(t *testing.T) {
+	RegisterTestingT(t)
+	test := newSchedulerTest("2026-10-18T23:59:00Z", testCronFile)
+
+	test.tickAt("2026-10-18T23:59:00Z")
+	Expect(test.triggeredJobs).To(BeEmpty())
+
+	test.tickAt("2026-10-19T00:00:10Z")
+	test.tickAt("2026-10-19T00:00:40Z")
+	Expect(test.triggeredJobs).To(Equal([]string{"import_helmets"}))
+
+	test.tickAt("2026-10-19T03:00:05Z")
+	test.tickAt("2026-10-20T00:00:05Z")
+	Expect(test.triggeredJobs).To(Equal([]string{"import_helmets", "sync_revzilla_jackets", "import_helmets"}))
+}
+
+func Test_Scheduler_should_only_fire_schedules_while_it_is_the_leader(t *testing.T) {
+	RegisterTestingT(t)
+	test := newSchedulerTest("2026-10-18T23:59:00Z", testCronFile)
+	test.leader.isLeader = false
+
+	test.tickAt("2026-10-18T23:59:00Z")
+	test.tickAt("2026-10-19T00:00:10Z")
+	Expect(test.triggeredJobs).To(BeEmpty())
+	Expect(test.scheduler.GetStatus().IsLeader).To(BeFalse())
+}
+
+func Test_Scheduler_should_catch_up_once_on_a_missed_run_when_it_becomes_the_leader(t *testing.T) {
+	RegisterTestingT(t)
+	test := newSchedulerTest("2026-10-19T03:30:00Z", testCronFile)
+
+	// import_helmets last fired the night before, so tonight's run was missed. sync_revzilla_jackets last fired two days ago, but only the run within the max catch up window is made up.
+	lastImportHelmetsFire := mustParseTime("2026-10-18T00:00:05Z")
+	lastSyncRevzillaJacketsFire := mustParseTime("2026-10-17T03:00:05Z")
+	test.lastFiredAtUTC["import_helmets"] = &lastImportHelmetsFire
+	test.lastFiredAtUTC["sync_revzilla_jackets"] = &lastSyncRevzillaJacketsFire
+
+	test.tickAt("2026-10-19T03:30:00Z")
+	test.tickAt("2026-10-19T03:30:30Z")
+	Expect(test.triggeredJobs).To(Equal([]string{"import_helmets", "sync_revzilla_jackets"}))
+}
+
+func Test_Scheduler_should_skip_missed_runs_older_than_the_max_catch_up(t *testing.T) {
+	RegisterTestingT(t)
+	test := newSchedulerTest("2026-10-19T07:00:00Z", testCronFile)
+	lastFire := mustParseTime("2026-10-18T00:00:05Z")
+	test.lastFiredAtUTC["import_helmets"] = &lastFire
+
+	test.tickAt("2026-10-19T07:00:00Z")
+	Expect(test.triggeredJobs).To(BeEmpty())
+}
+
+func Test_Scheduler_should_not_fire_schedules_that_have_never_fired_until_they_are_next_due(t *testing.T) {
+	RegisterTestingT(t)
+	test := newSchedulerTest("2026-10-19T01:00:00Z", testCronFile)
+
+	test.tickAt("2026-10-19T01:00:00Z")
+	Expect(test.triggeredJobs).To(BeEmpty())
+
+	status := test.scheduler.GetStatus()
+	Expect(status.IsLeader).To(BeTrue())
+	Expect(status.Schedules).To(HaveLen(2))
+	Expect(status.Schedules[0].LastFiredAtUTC).To(BeNil())
+	Expect(status.Schedules[0].NextFireAtUTC.Format(time.RFC3339)).To(Equal("2026-10-20T00:00:00Z"))
+	Expect(status.Schedules[1].NextFireAtUTC.Format(time.RFC3339)).To(Equal("2026-10-19T03:00:00Z"))
+}
+
+func Test_Scheduler_should_release_the_leader_lock_when_its_context_is_done(t *testing.T) {
+	RegisterTestingT(t)
+	test := newSchedulerTest("2026-01-01T12:00:00Z", testCronFile)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stopped := make(chan struct{})
+	go func() {
+		test.scheduler.Start(ctx)
+		close(stopped)
+	}()
+	cancel()
+
+	Eventually(stopped).Should(BeClosed())
+	Expect(test.leader.isLeader).To(BeFalse())
+}
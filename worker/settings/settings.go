@@ -11,7 +11,59 @@ type Settings struct {
 	AppEnvironment           string
 	AWS                      awsConfiguration
 	CJAPIKey                 string
+	CJAPIURL                 string
 	UseSynchronousJobRunner  bool
+	FIMHelmetListSource      string
+	ExchangeRatesSource      string
+	BaseCurrency             string
+	RevzillaCache            revzillaCacheConfiguration
+	RevzillaImport           revzillaImportConfiguration
+	RevzillaHelmetsSync      revzillaHelmetsSyncConfiguration
+	Crawler                  crawlerConfiguration
+	Scheduler                schedulerConfiguration
+	JobOverlap               jobOverlapConfiguration
+	JobTimeout               jobTimeoutConfiguration
+}
+
+type jobTimeoutConfiguration struct {
+	DefaultTimeout string
+	Timeouts       string
+}
+
+type jobOverlapConfiguration struct {
+	DefaultPolicy string
+	Policies      string
+}
+
+type schedulerConfiguration struct {
+	IsEnabled    bool
+	CronFilePath string
+	MaxCatchUp   string
+}
+
+type crawlerConfiguration struct {
+	RequestsPerSecond string
+	Burst             string
+	HostLimits        string
+	Timeout           string
+	MaxRetries        string
+	IgnoreRobotsTxt   bool
+}
+
+type revzillaCacheConfiguration struct {
+	Directory string
+	TTL       string
+	Mode      string
+}
+
+type revzillaImportConfiguration struct {
+	MaxFailureRatio      string
+	MaxConsecutiveMisses string
+	MinSeenRatio         string
+}
+
+type revzillaHelmetsSyncConfiguration struct {
+	SkipSyncedWithin string
 }
 
 type awsConfiguration struct {
@@ -33,6 +85,44 @@ func GetSettingsFromEnvironment() *Settings {
 			S3Bucket:      os.Getenv("AWS_S3_BUCKET"),
 			MinioEndpoint: os.Getenv("MINIO_ENDPOINT"),
 		},
-		CJAPIKey: os.Getenv("CJ_API_KEY"),
+		CJAPIKey:            os.Getenv("CJ_API_KEY"),
+		CJAPIURL:            os.Getenv("CJ_API_URL"),
+		FIMHelmetListSource: os.Getenv("FIM_HELMET_LIST_SOURCE"),
+		ExchangeRatesSource: os.Getenv("EXCHANGE_RATES_SOURCE"),
+		BaseCurrency:        os.Getenv("BASE_CURRENCY"),
+		RevzillaCache: revzillaCacheConfiguration{
+			Directory: os.Getenv("REVZILLA_CACHE_DIRECTORY"),
+			TTL:       os.Getenv("REVZILLA_CACHE_TTL"),
+			Mode:      os.Getenv("REVZILLA_CACHE_MODE"),
+		},
+		RevzillaImport: revzillaImportConfiguration{
+			MaxFailureRatio:      os.Getenv("REVZILLA_IMPORT_MAX_FAILURE_RATIO"),
+			MaxConsecutiveMisses: os.Getenv("REVZILLA_MAX_CONSECUTIVE_MISSES"),
+			MinSeenRatio:         os.Getenv("REVZILLA_IMPORT_MIN_SEEN_RATIO"),
+		},
+		RevzillaHelmetsSync: revzillaHelmetsSyncConfiguration{
+			SkipSyncedWithin: os.Getenv("SYNC_REVZILLA_HELMETS_SKIP_SYNCED_WITHIN"),
+		},
+		Crawler: crawlerConfiguration{
+			RequestsPerSecond: os.Getenv("CRAWLER_REQUESTS_PER_SECOND"),
+			Burst:             os.Getenv("CRAWLER_BURST"),
+			HostLimits:        os.Getenv("CRAWLER_HOST_LIMITS"),
+			Timeout:           os.Getenv("CRAWLER_TIMEOUT"),
+			MaxRetries:        os.Getenv("CRAWLER_MAX_RETRIES"),
+			IgnoreRobotsTxt:   os.Getenv("CRAWLER_IGNORE_ROBOTS_TXT") == "true",
+		},
+		JobOverlap: jobOverlapConfiguration{
+			DefaultPolicy: os.Getenv("JOB_OVERLAP_POLICY"),
+			Policies:      os.Getenv("JOB_OVERLAP_POLICIES"),
+		},
+		JobTimeout: jobTimeoutConfiguration{
+			DefaultTimeout: os.Getenv("JOB_TIMEOUT"),
+			Timeouts:       os.Getenv("JOB_TIMEOUTS"),
+		},
+		Scheduler: schedulerConfiguration{
+			IsEnabled:    os.Getenv("SCHEDULER_ENABLED") == "true",
+			CronFilePath: os.Getenv("SCHEDULER_CRON_FILE_PATH"),
+			MaxCatchUp:   os.Getenv("SCHEDULER_MAX_CATCH_UP"),
+		},
 	}
 }
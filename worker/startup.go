@@ -3,15 +3,28 @@ package worker
 import (
 	"atgatt-backend/application/clients"
 	"atgatt-backend/application/parsers"
+	httpHelpers "atgatt-backend/common/http"
 	loggingHelpers "atgatt-backend/common/logging"
+	"atgatt-backend/persistence/entities"
 	"atgatt-backend/persistence/repositories"
 	"atgatt-backend/worker/jobs"
+	"atgatt-backend/worker/jobs/helpers"
+	"atgatt-backend/worker/scheduler"
 	"atgatt-backend/worker/settings"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/borderstech/artifex"
+	"github.com/google/uuid"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -29,6 +42,9 @@ import (
 	_ "github.com/jackc/pgx/v4/stdlib"
 )
 
+// serverShutdownTimeout is how long in-flight requests are given to finish when the worker shuts down
+const serverShutdownTimeout = 10 * time.Second
+
 // Server contains the bootstrapping code for the worker
 type Server struct {
 	Port         string
@@ -38,6 +54,19 @@ type Server struct {
 	CommitHash   string
 	Settings     *settings.Settings
 	echoInstance *echo.Echo
+
+	changeReportRepository *repositories.ChangeReportRepository
+	jobRunRepository       *repositories.JobRunRepository
+	jobQueue               *artifex.Dispatcher
+	jobs                   map[string]jobs.Job
+	defaultOverlapPolicy   string
+	overlapPolicies        map[string]string
+	defaultJobTimeout      time.Duration
+	jobTimeouts            map[string]time.Duration
+	runningJobsMutex       sync.Mutex
+	runningJobs            map[string]context.CancelFunc
+	db                     *sqlx.DB
+	scheduler              *scheduler.Scheduler
 }
 
 // Bootstrap first initializes the server, then starts it up and blocks
@@ -91,39 +120,132 @@ func (s *Server) Bootstrap() {
 		os.Exit(-1)
 	}
 	defer db.Close()
+	s.db = db
+
+	// Every scraper shares one crawler so that rate limits apply across jobs that hit the same host
+	crawler, err := s.getCrawler()
+	if err != nil {
+		logrus.WithError(err).Error("Encountered an error while initializing the crawler")
+		os.Exit(-1)
+	}
+	httpHelpers.DefaultCrawler = crawler
 
-	productRepository := &repositories.ProductRepository{DB: db}
+	productRepository := &repositories.ProductRepository{DB: db, BaseCurrency: config.BaseCurrency}
+	exchangeRateRepository := &repositories.ExchangeRateRepository{DB: db}
+	s.changeReportRepository = &repositories.ChangeReportRepository{DB: db}
+	s.jobRunRepository = &repositories.JobRunRepository{DB: db}
 
 	importHelmetsJob := &jobs.ImportHelmetsJob{
 		ProductRepository:      productRepository,
-		SHARPHelmetParser:      &parsers.SHARPHelmetParser{Limit: -1},
-		SNELLHelmetParser:      &parsers.SNELLHelmetParser{},
+		SHARPHelmetParser:      &parsers.SHARPHelmetParser{Limit: -1, HTTPClient: crawler},
+		SNELLHelmetParser:      &parsers.SNELLHelmetParser{HTTPClient: crawler},
+		FIMHelmetParser:        &parsers.FIMHelmetParser{Source: config.FIMHelmetListSource},
 		ManufacturerRepository: &repositories.ManufacturerRepository{DB: db},
 		S3Uploader:             s3Uploader,
 		S3Bucket:               config.AWS.S3Bucket,
 	}
 
-	syncRevzillaHelmetsJob := &jobs.SyncRevzillaHelmetsJob{ProductRepository: productRepository, CJAPIKey: config.CJAPIKey}
+	loadExchangeRatesJob := &jobs.LoadExchangeRatesJob{
+		ExchangeRatesParser:    &parsers.ExchangeRatesParser{Source: config.ExchangeRatesSource},
+		ExchangeRateRepository: exchangeRateRepository,
+		BaseCurrency:           config.BaseCurrency,
+	}
 
-	revzillaClient := clients.NewHTTPRevzillaClient()
-	syncRevzillaJacketsJob := &jobs.SyncRevzillaJacketsJob{ProductRepository: productRepository, S3Uploader: s3Uploader, S3Bucket: config.AWS.S3Bucket, RevzillaClient: revzillaClient, EnableMinProductsCheck: true}
-	syncRevzillaPantsJob := &jobs.SyncRevzillaPantsJob{ProductRepository: productRepository, S3Uploader: s3Uploader, S3Bucket: config.AWS.S3Bucket, RevzillaClient: revzillaClient, EnableMinProductsCheck: true}
-	syncRevzillaBootsJob := &jobs.SyncRevzillaBootsJob{ProductRepository: productRepository, S3Uploader: s3Uploader, S3Bucket: config.AWS.S3Bucket, RevzillaClient: revzillaClient, EnableMinProductsCheck: true}
-	syncRevzillaGlovesJob := &jobs.SyncRevzillaGlovesJob{ProductRepository: productRepository, S3Uploader: s3Uploader, S3Bucket: config.AWS.S3Bucket, RevzillaClient: revzillaClient, EnableMinProductsCheck: true}
+	skipHelmetsSyncedWithin, err := s.getSkipHelmetsSyncedWithin()
+	if err != nil {
+		logrus.WithError(err).Error("Encountered an error while reading how recently synced helmets are skipped")
+		os.Exit(-1)
+	}
+	cjClient := clients.NewHTTPCJClient(crawler, clients.HTTPCJClientOptions{APIURL: config.CJAPIURL, APIKey: config.CJAPIKey})
+	missedRevzillaProductSettings, err := s.getMissedRevzillaProductSettings()
+	if err != nil {
+		logrus.WithError(err).Error("Encountered an error while reading the settings for products that are missing from RevZilla")
+		os.Exit(-1)
+	}
+	syncRevzillaHelmetsJob := &jobs.SyncRevzillaHelmetsJob{ProductRepository: productRepository, CJClient: cjClient, SkipSyncedWithin: skipHelmetsSyncedWithin, MaxConsecutiveMisses: missedRevzillaProductSettings.MaxConsecutiveMisses}
+
+	var revzillaClient clients.RevzillaClient = clients.NewHTTPRevzillaClient(crawler)
+	if config.RevzillaCache.Directory != "" {
+		revzillaClient, err = s.getCachingRevzillaClient(revzillaClient)
+		if err != nil {
+			logrus.WithError(err).Error("Encountered an error while initializing the RevZilla cache")
+			os.Exit(-1)
+		}
+	}
+	maxRevzillaFailureRatio, err := s.getMaxRevzillaFailureRatio()
+	if err != nil {
+		logrus.WithError(err).Error("Encountered an error while reading the maximum failure ratio for RevZilla imports")
+		os.Exit(-1)
+	}
+	syncRevzillaJacketsJob := &jobs.SyncRevzillaJacketsJob{ProductRepository: productRepository, S3Uploader: s3Uploader, S3Bucket: config.AWS.S3Bucket, RevzillaClient: revzillaClient, EnableMinProductsCheck: true, MaxFailureRatio: maxRevzillaFailureRatio, MissedProductSettings: missedRevzillaProductSettings}
+	syncRevzillaPantsJob := &jobs.SyncRevzillaPantsJob{ProductRepository: productRepository, S3Uploader: s3Uploader, S3Bucket: config.AWS.S3Bucket, RevzillaClient: revzillaClient, EnableMinProductsCheck: true, MaxFailureRatio: maxRevzillaFailureRatio, MissedProductSettings: missedRevzillaProductSettings}
+	syncRevzillaBootsJob := &jobs.SyncRevzillaBootsJob{ProductRepository: productRepository, S3Uploader: s3Uploader, S3Bucket: config.AWS.S3Bucket, RevzillaClient: revzillaClient, EnableMinProductsCheck: true, MaxFailureRatio: maxRevzillaFailureRatio, MissedProductSettings: missedRevzillaProductSettings}
+	syncRevzillaGlovesJob := &jobs.SyncRevzillaGlovesJob{ProductRepository: productRepository, S3Uploader: s3Uploader, S3Bucket: config.AWS.S3Bucket, RevzillaClient: revzillaClient, EnableMinProductsCheck: true, MaxFailureRatio: maxRevzillaFailureRatio, MissedProductSettings: missedRevzillaProductSettings}
+	syncRevzillaAirbagsJob := &jobs.SyncRevzillaAirbagsJob{ProductRepository: productRepository, S3Uploader: s3Uploader, S3Bucket: config.AWS.S3Bucket, RevzillaClient: revzillaClient, EnableMinProductsCheck: false, MaxFailureRatio: maxRevzillaFailureRatio, MissedProductSettings: missedRevzillaProductSettings}
+	syncRevzillaProtectorsJob := &jobs.SyncRevzillaProtectorsJob{ProductRepository: productRepository, S3Uploader: s3Uploader, S3Bucket: config.AWS.S3Bucket, RevzillaClient: revzillaClient, EnableMinProductsCheck: false, MaxFailureRatio: maxRevzillaFailureRatio, MissedProductSettings: missedRevzillaProductSettings}
 
 	numWorkers := runtime.NumCPU()
 	logrus.WithField("numWorkers", numWorkers).Info("Starting job queue")
-	jobQueue := artifex.NewDispatcher(numWorkers, 100)
-	jobQueue.Start()
+	s.jobQueue = artifex.NewDispatcher(numWorkers, 100)
+	s.jobQueue.Start()
 	logrus.Info("Job queue started")
 
 	// Jobs
-	s.registerJob(e, jobQueue, "import_helmets", importHelmetsJob)
-	s.registerJob(e, jobQueue, "sync_revzilla_helmets", syncRevzillaHelmetsJob)
-	s.registerJob(e, jobQueue, "sync_revzilla_jackets", syncRevzillaJacketsJob)
-	s.registerJob(e, jobQueue, "sync_revzilla_pants", syncRevzillaPantsJob)
-	s.registerJob(e, jobQueue, "sync_revzilla_boots", syncRevzillaBootsJob)
-	s.registerJob(e, jobQueue, "sync_revzilla_gloves", syncRevzillaGlovesJob)
+	s.jobs = map[string]jobs.Job{}
+	s.runningJobs = map[string]context.CancelFunc{}
+	s.registerJob(e, "load_exchange_rates", loadExchangeRatesJob)
+	s.registerJob(e, "import_helmets", importHelmetsJob)
+	s.registerJob(e, "sync_revzilla_helmets", syncRevzillaHelmetsJob)
+	s.registerJob(e, "sync_revzilla_jackets", syncRevzillaJacketsJob)
+	s.registerJob(e, "sync_revzilla_pants", syncRevzillaPantsJob)
+	s.registerJob(e, "sync_revzilla_boots", syncRevzillaBootsJob)
+	s.registerJob(e, "sync_revzilla_gloves", syncRevzillaGlovesJob)
+	s.registerJob(e, "sync_revzilla_airbags", syncRevzillaAirbagsJob)
+	s.registerJob(e, "sync_revzilla_protectors", syncRevzillaProtectorsJob)
+	if err := s.initializeOverlapPolicies(); err != nil {
+		logrus.WithError(err).Error("Encountered an error while reading the overlap policies for jobs")
+		os.Exit(-1)
+	}
+	if err := s.initializeJobTimeouts(); err != nil {
+		logrus.WithError(err).Error("Encountered an error while reading the timeouts for jobs")
+		os.Exit(-1)
+	}
+
+	// Schedules from cron.yaml, which are fired in-process by whichever worker holds the leader lock. The scheduler stops and releases the lock when the worker shuts down.
+	shutdownCtx, shutdown := context.WithCancel(context.Background())
+	defer shutdown()
+	schedulerStopped := make(chan struct{})
+	if config.Scheduler.IsEnabled {
+		s.scheduler, err = s.getScheduler(db)
+		if err != nil {
+			logrus.WithError(err).Error("Encountered an error while initializing the scheduler")
+			os.Exit(-1)
+		}
+		go func() {
+			s.scheduler.Start(shutdownCtx)
+			close(schedulerStopped)
+		}()
+	} else {
+		close(schedulerStopped)
+		logrus.Info("The scheduler is disabled, so jobs will only run when their endpoints are called")
+	}
+	go s.shutdownOnSignal(e, shutdown, schedulerStopped)
+	e.GET("/jobs/schedule", s.getSchedule)
+
+	// Runs of every job, newest first
+	e.GET("/jobs/runs", s.getJobRuns)
+	e.GET("/jobs/runs/:id", s.getJobRunByID)
+	e.POST("/jobs/runs/:id/cancel", s.cancelJobRun)
+	e.POST("/jobs/runs/:id/resume", s.resumeJobRun)
+
+	// Change reports from dry runs
+	e.GET("/jobs/change_reports", s.getChangeReports)
+	e.GET("/jobs/change_reports/:uuid", s.getChangeReportByUUID)
+
+	// Metrics for the requests that scrapers have sent to each host
+	e.GET("/jobs/crawler_metrics", func(context echo.Context) error {
+		return context.JSON(http.StatusOK, crawler.GetMetrics())
+	})
 
 	// Healthcheck endpoint
 	e.GET("/", func(context echo.Context) error {
@@ -132,46 +254,398 @@ func (s *Server) Bootstrap() {
 	})
 
 	err = e.Start(s.Port)
-	if err != nil {
+	if err != nil && err != http.ErrServerClosed {
 		logrus.WithError(err).Error("Failed to start the server")
 	}
 }
 
-func (s *Server) registerJob(e *echo.Echo, jobQueue *artifex.Dispatcher, name string, job jobs.Job) {
+// shutdownOnSignal waits for the worker to be interrupted or terminated, then stops the scheduler so that it releases its leader lock before the server stops
+func (s *Server) shutdownOnSignal(e *echo.Echo, shutdown context.CancelFunc, schedulerStopped <-chan struct{}) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	receivedSignal := <-signals
+	logrus.WithField("signal", receivedSignal.String()).Info("Shutting down the worker")
+
+	shutdown()
+	<-schedulerStopped
+
+	ctx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		logrus.WithError(err).Error("Failed to shut down the server")
+	}
+}
+
+// getCachingRevzillaClient wraps the given client so that the pages it returns are cached on disk, which lets RevZilla jobs be re-run without hitting revzilla.com
+func (s *Server) getCachingRevzillaClient(revzillaClient clients.RevzillaClient) (clients.RevzillaClient, error) {
+	cacheSettings := s.Settings.RevzillaCache
+	ttl := clients.DefaultRevzillaCacheTTL
+	if cacheSettings.TTL != "" {
+		parsedTTL, err := time.ParseDuration(cacheSettings.TTL)
+		if err != nil {
+			return nil, err
+		}
+
+		if parsedTTL <= 0 {
+			return nil, fmt.Errorf("The cache TTL %s must be greater than 0", cacheSettings.TTL)
+		}
+		ttl = parsedTTL
+	}
+
+	mode := cacheSettings.Mode
+	if mode == "" {
+		mode = clients.RevzillaCacheModeCache
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"directory": cacheSettings.Directory,
+		"ttl":       ttl,
+		"mode":      mode,
+	}).Info("Caching pages from RevZilla on disk")
+	return clients.NewCachingRevzillaClient(revzillaClient, cacheSettings.Directory, ttl, mode)
+}
+
+// getCrawler initializes the crawler shared by every scraper, overriding the default options with any that are configured in the environment
+func (s *Server) getCrawler() (*httpHelpers.Crawler, error) {
+	crawlerSettings := s.Settings.Crawler
+	options := httpHelpers.DefaultCrawlerOptions()
+	options.IgnoreRobotsTxt = crawlerSettings.IgnoreRobotsTxt
+
+	if crawlerSettings.RequestsPerSecond != "" {
+		requestsPerSecond, err := strconv.ParseFloat(crawlerSettings.RequestsPerSecond, 64)
+		if err != nil {
+			return nil, err
+		}
+		options.DefaultHostLimit.RequestsPerSecond = requestsPerSecond
+	}
+
+	if crawlerSettings.Burst != "" {
+		burst, err := strconv.Atoi(crawlerSettings.Burst)
+		if err != nil {
+			return nil, err
+		}
+		options.DefaultHostLimit.Burst = burst
+	}
+
+	if crawlerSettings.HostLimits != "" {
+		hostLimits, err := httpHelpers.ParseHostLimits(crawlerSettings.HostLimits)
+		if err != nil {
+			return nil, err
+		}
+		for host, hostLimit := range hostLimits {
+			options.HostLimits[host] = hostLimit
+		}
+	}
+
+	if crawlerSettings.Timeout != "" {
+		timeout, err := time.ParseDuration(crawlerSettings.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		options.Timeout = timeout
+	}
+
+	if crawlerSettings.MaxRetries != "" {
+		maxRetries, err := strconv.Atoi(crawlerSettings.MaxRetries)
+		if err != nil {
+			return nil, err
+		}
+		options.MaxRetries = maxRetries
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"defaultHostLimit": options.DefaultHostLimit,
+		"hostLimits":       options.HostLimits,
+		"timeout":          options.Timeout,
+		"maxRetries":       options.MaxRetries,
+		"ignoreRobotsTxt":  options.IgnoreRobotsTxt,
+	}).Info("Initializing the crawler")
+	return httpHelpers.NewCrawler(options), nil
+}
+
+// getMaxRevzillaFailureRatio returns the share of products that can fail to import before a RevZilla job fails, where 0 fails the job on any failure, or nil to use the default
+func (s *Server) getMaxRevzillaFailureRatio() (*float64, error) {
+	rawMaxFailureRatio := s.Settings.RevzillaImport.MaxFailureRatio
+	if rawMaxFailureRatio == "" {
+		return nil, nil
+	}
+
+	maxFailureRatio, err := strconv.ParseFloat(rawMaxFailureRatio, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxFailureRatio < 0 || maxFailureRatio > 1 {
+		return nil, fmt.Errorf("The maximum failure ratio %s must be between 0 and 1", rawMaxFailureRatio)
+	}
+	return &maxFailureRatio, nil
+}
+
+// getMissedRevzillaProductSettings returns how many syncs in a row a product can be missing from RevZilla before it is discontinued, and how much of the previous listing a RevZilla import has to see before it counts any misses
+func (s *Server) getMissedRevzillaProductSettings() (helpers.MissedProductSettings, error) {
+	missedProductSettings := helpers.MissedProductSettings{}
+
+	rawMaxConsecutiveMisses := s.Settings.RevzillaImport.MaxConsecutiveMisses
+	if rawMaxConsecutiveMisses != "" {
+		maxConsecutiveMisses, err := strconv.Atoi(rawMaxConsecutiveMisses)
+		if err != nil {
+			return missedProductSettings, err
+		}
+
+		if maxConsecutiveMisses < 1 {
+			return missedProductSettings, fmt.Errorf("The maximum number of consecutive misses %s must be at least 1", rawMaxConsecutiveMisses)
+		}
+		missedProductSettings.MaxConsecutiveMisses = maxConsecutiveMisses
+	}
+
+	rawMinSeenRatio := s.Settings.RevzillaImport.MinSeenRatio
+	if rawMinSeenRatio != "" {
+		minSeenRatio, err := strconv.ParseFloat(rawMinSeenRatio, 64)
+		if err != nil {
+			return missedProductSettings, err
+		}
+
+		if minSeenRatio < 0 || minSeenRatio > 1 {
+			return missedProductSettings, fmt.Errorf("The minimum seen ratio %s must be between 0 and 1", rawMinSeenRatio)
+		}
+		missedProductSettings.MinSeenRatio = &minSeenRatio
+	}
+
+	return missedProductSettings, nil
+}
+
+// getSkipHelmetsSyncedWithin returns how recently a helmet must have been synced with RevZilla to be skipped, or 0 to sync every helmet
+func (s *Server) getSkipHelmetsSyncedWithin() (time.Duration, error) {
+	rawSkipSyncedWithin := s.Settings.RevzillaHelmetsSync.SkipSyncedWithin
+	if rawSkipSyncedWithin == "" {
+		return 0, nil
+	}
+
+	skipSyncedWithin, err := time.ParseDuration(rawSkipSyncedWithin)
+	if err != nil {
+		return 0, err
+	}
+
+	if skipSyncedWithin < 0 {
+		return 0, fmt.Errorf("The duration %s must not be negative", rawSkipSyncedWithin)
+	}
+	return skipSyncedWithin, nil
+}
+
+// registerJob adds the endpoint that triggers the given job, and makes it available to the scheduler
+func (s *Server) registerJob(e *echo.Echo, name string, job jobs.Job) {
+	s.jobs[name] = job
 	e.POST("/jobs/"+name, func(context echo.Context) error {
-		jobLogger := logrus.WithField("jobName", name)
-		jobLogger.Info("Triggered, dispatching work to job queue")
-
-		runJobFunc := func() error {
-			jobLogger.Info("Starting Job")
-			err := job.Run()
-			if err != nil {
-				jobLogger.WithError(err).Error("Job completed with errors")
-				return err
-			}
-			jobLogger.Info("Job completed successfully")
-			return nil
-		}
-
-		runJobFuncWrapper := func() {
-			_ = runJobFunc()
-		}
-
-		if s.Settings.UseSynchronousJobRunner {
-			err := runJobFunc()
-			if err != nil {
-				return err
-			}
-		} else {
-			err := jobQueue.Dispatch(runJobFuncWrapper)
-			if err != nil {
-				jobLogger.WithError(err).Error("Could not start job due to an error")
-				return err
-			}
-		}
-
-		jobLogger.Info("Finished dispatching work to job queue, returning OK")
-		var emptyResponse struct{}
-		return context.JSON(http.StatusOK, emptyResponse)
+		// Dry runs don't write to the database or S3, and save a report of the changes that they would have made instead
+		isDryRun := context.QueryParam("dryRun") == "true"
+		jobRun, err := s.triggerJob(name, entities.JobRunTriggerAPI, isDryRun, nil)
+		cannotDryRunErr := &jobCannotDryRunError{}
+		if errors.As(err, &cannotDryRunErr) {
+			return context.NoContent(http.StatusBadRequest)
+		}
+		if err != nil {
+			return err
+		}
+
+		response := &jobResponse{RunID: jobRun.UUID.String()}
+		if jobRun.ChangeReportUUID != nil {
+			response.ChangeReportUUID = jobRun.ChangeReportUUID.String()
+		}
+		return context.JSON(http.StatusOK, response)
 	})
 }
+
+// getScheduler loads the schedules from cron.yaml and checks that each one runs a registered job
+func (s *Server) getScheduler(db *sqlx.DB) (*scheduler.Scheduler, error) {
+	cronFilePath := s.Settings.Scheduler.CronFilePath
+	if cronFilePath == "" {
+		cronFilePath = defaultCronFilePath
+	}
+
+	schedules, err := scheduler.LoadSchedules(cronFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, schedule := range schedules {
+		if _, exists := s.jobs[schedule.JobName]; !exists {
+			return nil, fmt.Errorf("The schedule %s runs the job %s, which does not exist", schedule.Name, schedule.JobName)
+		}
+	}
+
+	maxCatchUp := time.Duration(0)
+	if s.Settings.Scheduler.MaxCatchUp != "" {
+		maxCatchUp, err = time.ParseDuration(s.Settings.Scheduler.MaxCatchUp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"cronFilePath": cronFilePath,
+		"numSchedules": len(schedules),
+		"maxCatchUp":   maxCatchUp,
+	}).Info("Initializing the scheduler")
+	return &scheduler.Scheduler{
+		Schedules: schedules,
+		Leader:    &repositories.AdvisoryLock{DB: db, Name: schedulerLockName},
+		FindLastFire: func(jobName string) (*time.Time, error) {
+			return s.jobRunRepository.GetLastQueuedAt(jobName, entities.JobRunTriggerSchedule)
+		},
+		Trigger: func(jobName string) error {
+			_, err := s.triggerJob(jobName, entities.JobRunTriggerSchedule, false, nil)
+			return err
+		},
+		MaxCatchUp: maxCatchUp,
+	}, nil
+}
+
+const defaultCronFilePath = "cron.yaml"
+const schedulerLockName = "atgatt-worker-scheduler"
+
+// getSchedule returns the next time that each schedule will fire, and whether this worker is the one firing them
+func (s *Server) getSchedule(context echo.Context) error {
+	if s.scheduler == nil {
+		return context.JSON(http.StatusOK, &scheduler.Status{IsEnabled: false, Schedules: []*scheduler.ScheduleStatus{}})
+	}
+
+	return context.JSON(http.StatusOK, s.scheduler.GetStatus())
+}
+
+type jobResponse struct {
+	RunID            string `json:"runID"`
+	ChangeReportUUID string `json:"changeReportUUID,omitempty"`
+}
+
+const maxJobRunsToReturn int = 25
+
+// getJobRuns returns the most recently triggered job runs, optionally only for the job given by the jobName query param
+func (s *Server) getJobRuns(context echo.Context) error {
+	jobRuns, err := s.jobRunRepository.GetLatest(context.QueryParam("jobName"), maxJobRunsToReturn)
+	if err != nil {
+		return err
+	}
+
+	return context.JSON(http.StatusOK, jobRuns)
+}
+
+// getJobRunByID returns a single job run, including its counts and the errors for the items that failed
+func (s *Server) getJobRunByID(context echo.Context) error {
+	if _, err := uuid.Parse(context.Param("id")); err != nil {
+		return context.NoContent(http.StatusNotFound)
+	}
+
+	jobRun, err := s.jobRunRepository.GetByUUID(context.Param("id"))
+	if err == repositories.ErrEntityNotFound {
+		return context.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	return context.JSON(http.StatusOK, jobRun)
+}
+
+// cancelJobRun asks a queued or running job run to stop. A run on this worker is cancelled straight away, while a run on another worker instance is cancelled once that worker next checks for requests, and either way the run finishes with the cancelled status.
+func (s *Server) cancelJobRun(context echo.Context) error {
+	runID := context.Param("id")
+	if _, err := uuid.Parse(runID); err != nil {
+		return context.NoContent(http.StatusNotFound)
+	}
+
+	if _, err := s.jobRunRepository.GetByUUID(runID); err == repositories.ErrEntityNotFound {
+		return context.NoContent(http.StatusNotFound)
+	} else if err != nil {
+		return err
+	}
+
+	isCancelRequested, err := s.jobRunRepository.RequestCancelByUUID(runID)
+	if err != nil {
+		return err
+	}
+
+	// Runs that have already finished can't be cancelled
+	if !isCancelRequested {
+		return context.NoContent(http.StatusConflict)
+	}
+
+	isRunningHere := s.cancelRunningJob(runID)
+	logrus.WithFields(logrus.Fields{"runID": runID, "isRunningHere": isRunningHere}).Warning("Asked a job run to stop")
+	return context.JSON(http.StatusAccepted, &jobResponse{RunID: runID})
+}
+
+// resumeJobRun starts a new run of the same job that picks up from the checkpoint of a run that failed, was cancelled, or was left running by a worker that stopped unexpectedly. Jobs that don't save checkpoints start again from the beginning.
+func (s *Server) resumeJobRun(context echo.Context) error {
+	runID := context.Param("id")
+	if _, err := uuid.Parse(runID); err != nil {
+		return context.NoContent(http.StatusNotFound)
+	}
+
+	previousJobRun, err := s.jobRunRepository.GetByUUID(runID)
+	if err == repositories.ErrEntityNotFound {
+		return context.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	isInterrupted, err := s.isJobRunInterrupted(previousJobRun)
+	if err != nil {
+		return err
+	}
+
+	if isInterrupted {
+		previousJobRun.Finish(errJobRunInterrupted)
+		if err := s.jobRunRepository.UpdateJobRun(previousJobRun); err != nil {
+			return err
+		}
+	}
+
+	if !previousJobRun.CanResume() {
+		return context.NoContent(http.StatusConflict)
+	}
+
+	jobRun, err := s.triggerJob(previousJobRun.JobName, entities.JobRunTriggerAPI, previousJobRun.IsDryRun, previousJobRun)
+	cannotDryRunErr := &jobCannotDryRunError{}
+	if errors.As(err, &cannotDryRunErr) {
+		return context.NoContent(http.StatusBadRequest)
+	}
+	if err != nil {
+		return err
+	}
+
+	response := &jobResponse{RunID: jobRun.UUID.String()}
+	if jobRun.ChangeReportUUID != nil {
+		response.ChangeReportUUID = jobRun.ChangeReportUUID.String()
+	}
+	return context.JSON(http.StatusOK, response)
+}
+
+const maxChangeReportsToReturn int = 25
+
+// getChangeReports returns the latest change reports from dry runs, optionally only for the job given by the jobName query param
+func (s *Server) getChangeReports(context echo.Context) error {
+	changeReports, err := s.changeReportRepository.GetLatest(context.QueryParam("jobName"), maxChangeReportsToReturn)
+	if err != nil {
+		return err
+	}
+
+	return context.JSON(http.StatusOK, changeReports)
+}
+
+// getChangeReportByUUID returns a single change report from a dry run, which only exists once the dry run has finished
+func (s *Server) getChangeReportByUUID(context echo.Context) error {
+	if _, err := uuid.Parse(context.Param("uuid")); err != nil {
+		return context.NoContent(http.StatusNotFound)
+	}
+
+	changeReport, err := s.changeReportRepository.GetByUUID(context.Param("uuid"))
+	if err == repositories.ErrEntityNotFound {
+		return context.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	return context.JSON(http.StatusOK, changeReport)
+}